@@ -0,0 +1,225 @@
+package httpshopify
+
+import (
+	"encoding/json"
+	"fmt"
+	httpCode "net/http"
+	"net/url"
+	"strings"
+
+	"github.com/MOHC-LTD/httpshopify/v2/internal/http"
+)
+
+// AccessScopeRepository retrieves the access scopes granted to the app by a shop
+type AccessScopeRepository interface {
+	// List retrieves the handles of the access scopes granted to the app e.g. read_orders
+	List() ([]string, error)
+	// Check returns ErrMissingScopes when any of the required scopes have not been granted
+	Check(required []string) error
+}
+
+type accessScopeRepository struct {
+	client    http.Client
+	createURL func(endpoint string) string
+}
+
+func newAccessScopeRepository(client http.Client, createURL func(endpoint string) string) accessScopeRepository {
+	return accessScopeRepository{
+		client,
+		createURL,
+	}
+}
+
+func (repository accessScopeRepository) List() ([]string, error) {
+	url := repository.createURL("oauth/access_scopes.json")
+
+	body, _, err := repository.client.WithOperation("accessScopes.List").Get(url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		AccessScopes []struct {
+			Handle string `json:"handle"`
+		} `json:"access_scopes"`
+	}
+
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := make([]string, 0, len(response.AccessScopes))
+	for _, scope := range response.AccessScopes {
+		scopes = append(scopes, scope.Handle)
+	}
+
+	return scopes, nil
+}
+
+func (repository accessScopeRepository) Check(required []string) error {
+	granted, err := repository.List()
+	if err != nil {
+		return err
+	}
+
+	missing := MissingScopes(granted, required)
+	if len(missing) != 0 {
+		return NewErrMissingScopes(missing)
+	}
+
+	return nil
+}
+
+// MissingScopes returns the required scopes that have not been granted.
+/*
+	A write scope also grants the read scope of the same resource, so write_orders satisfies read_orders.
+*/
+func MissingScopes(granted []string, required []string) []string {
+	grantedSet := make(map[string]bool, len(granted)*2)
+	for _, scope := range granted {
+		grantedSet[scope] = true
+
+		if resource, ok := strings.CutPrefix(scope, "write_"); ok {
+			grantedSet["read_"+resource] = true
+		}
+	}
+
+	missing := make([]string, 0)
+	for _, scope := range required {
+		if !grantedSet[scope] {
+			missing = append(missing, scope)
+		}
+	}
+
+	return missing
+}
+
+// adminRootURL works out the URL of the admin from the URL of a versioned Admin API e.g.
+// https://my-shop.myshopify.com/admin/api/2024-01 becomes https://my-shop.myshopify.com/admin
+func adminRootURL(apiURL string) string {
+	if index := strings.Index(apiURL, "/admin/api/"); index != -1 {
+		return apiURL[:index] + "/admin"
+	}
+
+	return apiURL
+}
+
+// scopeResources maps the resources of the Admin API to the access scope needed to use them.
+/*
+	Nested resources are mapped by the last resource in the path that has a scope, so metafields are left out
+	to fall back to the scope of their owner.
+*/
+var scopeResources = map[string]string{
+	"orders":             "orders",
+	"transactions":       "orders",
+	"refunds":            "orders",
+	"risks":              "orders",
+	"draft_orders":       "draft_orders",
+	"fulfillments":       "fulfillments",
+	"fulfillment_orders": "merchant_managed_fulfillment_orders",
+	"products":           "products",
+	"variants":           "products",
+	"images":             "products",
+	"collections":        "products",
+	"custom_collections": "products",
+	"smart_collections":  "products",
+	"collects":           "products",
+	"inventory_levels":   "inventory",
+	"inventory_items":    "inventory",
+	"locations":          "locations",
+	"customers":          "customers",
+	"addresses":          "customers",
+	"blogs":              "content",
+	"articles":           "content",
+	"pages":              "content",
+	"price_rules":        "price_rules",
+	"discount_codes":     "discounts",
+	"themes":             "themes",
+	"script_tags":        "script_tags",
+}
+
+// RequiredScope works out which access scope a request to the Admin API most likely needs, returning an empty string when it is unknown
+func RequiredScope(method string, rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+
+	segments := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+
+	for i := len(segments) - 1; i >= 0; i-- {
+		resource := strings.TrimSuffix(segments[i], ".json")
+
+		scope, ok := scopeResources[resource]
+		if !ok {
+			continue
+		}
+
+		if method == httpCode.MethodGet {
+			return "read_" + scope
+		}
+
+		return "write_" + scope
+	}
+
+	return ""
+}
+
+// mapScopeError replaces a 403 with an ErrScopeForbidden naming the scope the request most likely needs
+func mapScopeError(method string, url string, err http.ErrHTTP) error {
+	if err.Code != httpCode.StatusForbidden {
+		return err
+	}
+
+	scope := RequiredScope(method, url)
+	if scope == "" {
+		return err
+	}
+
+	return NewErrScopeForbidden(scope, err)
+}
+
+// ErrMissingScopes is thrown when scopes the app needs have not been granted by the shop
+type ErrMissingScopes struct {
+	// Missing are the scopes that have not been granted
+	Missing []string
+}
+
+func (err ErrMissingScopes) Error() string {
+	return fmt.Sprintf("access scopes %v have not been granted", strings.Join(err.Missing, ", "))
+}
+
+// NewErrMissingScopes builds the error
+func NewErrMissingScopes(missing []string) ErrMissingScopes {
+	return ErrMissingScopes{
+		missing,
+	}
+}
+
+// ErrScopeForbidden is thrown when Shopify responds to a request with a 403, which usually means a scope has not been granted
+type ErrScopeForbidden struct {
+	// Scope is the scope the request most likely needs
+	Scope string
+	// Body is the body of the response
+	Body string
+	// Err is the error of the response
+	Err http.ErrHTTP
+}
+
+func (err ErrScopeForbidden) Error() string {
+	return fmt.Sprintf("403 %v (the access token is probably missing the %v scope)", err.Body, err.Scope)
+}
+
+func (err ErrScopeForbidden) Unwrap() error {
+	return err.Err
+}
+
+// NewErrScopeForbidden builds the error
+func NewErrScopeForbidden(scope string, err http.ErrHTTP) ErrScopeForbidden {
+	return ErrScopeForbidden{
+		scope,
+		err.Body,
+		err,
+	}
+}
@@ -0,0 +1,93 @@
+package httpshopify
+
+import (
+	"errors"
+	httpCode "net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MOHC-LTD/httpshopify/v2/internal/assertions"
+	"github.com/MOHC-LTD/httpshopify/v2/internal/http"
+)
+
+// Tests that the scopes that have not been granted are reported, counting write scopes as granting read scopes
+func TestMissingScopes(t *testing.T) {
+	missing := MissingScopes(
+		[]string{"write_orders", "read_products"},
+		[]string{"read_orders", "write_orders", "read_products", "write_products", "read_customers"},
+	)
+
+	expected := []string{"write_products", "read_customers"}
+
+	if len(missing) != len(expected) {
+		assertions.ValueAssertionFailure(t, expected, missing)
+		return
+	}
+
+	for i := range expected {
+		if missing[i] != expected[i] {
+			assertions.ValueAssertionFailure(t, expected, missing)
+		}
+	}
+}
+
+// Tests that the scope of a request is worked out from its method and the last resource in its path
+func TestRequiredScope(t *testing.T) {
+	cases := []struct {
+		method   string
+		url      string
+		expected string
+	}{
+		{httpCode.MethodGet, "https://shop.myshopify.com/admin/api/2024-01/orders.json?status=any", "read_orders"},
+		{httpCode.MethodPost, "https://shop.myshopify.com/admin/api/2024-01/orders/1/fulfillments.json", "write_fulfillments"},
+		{httpCode.MethodPut, "https://shop.myshopify.com/admin/api/2024-01/products/1/metafields/2.json", "write_products"},
+		{httpCode.MethodGet, "https://shop.myshopify.com/admin/api/2024-01/webhooks.json", ""},
+	}
+
+	for _, c := range cases {
+		scope := RequiredScope(c.method, c.url)
+		if scope != c.expected {
+			assertions.ValueAssertionFailure(t, c.expected, scope)
+		}
+	}
+}
+
+// Tests that the shop checks its scopes against the admin access scopes endpoint and maps 403s to the missing scope
+func TestShop_CheckScopes(t *testing.T) {
+	server := httptest.NewServer(httpCode.HandlerFunc(func(w httpCode.ResponseWriter, r *httpCode.Request) {
+		switch r.URL.Path {
+		case "/admin/oauth/access_scopes.json":
+			w.Write([]byte(`{"access_scopes":[{"handle":"read_orders"}]}`))
+		default:
+			w.WriteHeader(httpCode.StatusForbidden)
+			w.Write([]byte(`{"errors":"[API] This action requires merchant approval for write_products scope."}`))
+		}
+	}))
+	defer server.Close()
+
+	shop := NewCustomShop(server.URL+"/admin/api/2024-01", "token", IsDefault)
+
+	err := shop.CheckScopes("read_orders")
+	if err != nil {
+		assertions.ErrAssertionFailure(t, err)
+	}
+
+	err = shop.CheckScopes("read_orders", "write_products")
+
+	var missing ErrMissingScopes
+	if !errors.As(err, &missing) || missing.Missing[0] != "write_products" {
+		assertions.ValueAssertionFailure(t, NewErrMissingScopes([]string{"write_products"}), err)
+	}
+
+	err = shop.ExtendedProductImages().Delete(1, 2)
+
+	var forbidden ErrScopeForbidden
+	if !errors.As(err, &forbidden) || forbidden.Scope != "write_products" {
+		assertions.ValueAssertionFailure(t, "write_products", err)
+	}
+
+	var httpErr http.ErrHTTP
+	if !errors.As(err, &httpErr) || httpErr.Code != httpCode.StatusForbidden {
+		assertions.ValueAssertionFailure(t, httpCode.StatusForbidden, err)
+	}
+}
@@ -0,0 +1,193 @@
+package httpshopify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultAppProxyMaxAge is how old the timestamp of an app proxy request can be by default
+const DefaultAppProxyMaxAge = 5 * time.Minute
+
+// AppProxyRequest holds the parameters Shopify adds to a request forwarded through an app proxy
+type AppProxyRequest struct {
+	// Shop is the myshopify.com domain of the shop the request was made to.
+	Shop string
+	// LoggedInCustomerID is the ID of the customer that is logged in to the storefront. Zero when no customer is logged in.
+	LoggedInCustomerID int64
+	// PathPrefix is the storefront path the app proxy is mounted at e.g. /apps/loyalty.
+	PathPrefix string
+	// Timestamp is when Shopify forwarded the request.
+	Timestamp time.Time
+}
+
+// VerifyAppProxySignature returns whether the signature parameter of an app proxy query was signed with the secret.
+/*
+	Unlike webhooks and OAuth callbacks, the signature is the hex encoded HMAC-SHA256 of the other parameters
+	sorted by name and concatenated without a separator, with repeated values joined by commas.
+*/
+func VerifyAppProxySignature(secret string, query url.Values) bool {
+	expected, err := hex.DecodeString(query.Get("signature"))
+	if err != nil || len(expected) == 0 {
+		return false
+	}
+
+	names := make([]string, 0, len(query))
+	for name := range query {
+		if name != "signature" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var message strings.Builder
+	for _, name := range names {
+		message.WriteString(fmt.Sprintf("%v=%v", name, strings.Join(query[name], ",")))
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(message.String()))
+
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// AppProxyOption allows the verifier to be configured
+type AppProxyOption func(*AppProxyVerifier)
+
+// WithAppProxyMaxAge sets how old, or how far in the future, the timestamp of a request can be
+func WithAppProxyMaxAge(maxAge time.Duration) AppProxyOption {
+	return func(verifier *AppProxyVerifier) {
+		verifier.maxAge = maxAge
+	}
+}
+
+// WithAppProxyReplayStore rejects requests whose signature has already been seen within the max age, remembering them in the store.
+/*
+	Replays are not detected by default, as identical storefront requests made in the same second, such as a
+	double click, have the same signature. Use this for proxied requests that must be handled at most once, with a
+	shared store to detect replays across processes.
+*/
+func WithAppProxyReplayStore(store WebhookStore) AppProxyOption {
+	return func(verifier *AppProxyVerifier) {
+		verifier.store = store
+	}
+}
+
+// AppProxyVerifier verifies requests that Shopify forwards from a storefront through an app proxy.
+/*
+	Requests must be signed with the app secret and have a timestamp within the max age. Requests that have been
+	seen before are also rejected when a replay store is configured with WithAppProxyReplayStore.
+	Example:
+	verifier := httpshopify.NewAppProxyVerifier("app-secret")
+	http.Handle("/proxy/", verifier.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxy, _ := httpshopify.AppProxyFromContext(r.Context())
+	})))
+*/
+type AppProxyVerifier struct {
+	secret string
+	maxAge time.Duration
+	store  WebhookStore
+	now    func() time.Time
+}
+
+// NewAppProxyVerifier builds a verifier for the app proxy requests of the app with the secret
+func NewAppProxyVerifier(secret string, options ...AppProxyOption) AppProxyVerifier {
+	verifier := AppProxyVerifier{
+		secret: secret,
+		maxAge: DefaultAppProxyMaxAge,
+		now:    time.Now,
+	}
+
+	for _, option := range options {
+		option(&verifier)
+	}
+
+	return verifier
+}
+
+// Verify checks the signature and timestamp of the query, returning the parameters Shopify added to it
+func (verifier AppProxyVerifier) Verify(query url.Values) (AppProxyRequest, error) {
+	if !VerifyAppProxySignature(verifier.secret, query) {
+		return AppProxyRequest{}, NewErrAppProxyInvalid("signature does not match")
+	}
+
+	seconds, err := strconv.ParseInt(query.Get("timestamp"), 10, 64)
+	if err != nil {
+		return AppProxyRequest{}, NewErrAppProxyInvalid("malformed timestamp")
+	}
+
+	timestamp := time.Unix(seconds, 0)
+
+	age := verifier.now().Sub(timestamp)
+	if age > verifier.maxAge || age < -verifier.maxAge {
+		return AppProxyRequest{}, NewErrAppProxyInvalid("timestamp is stale")
+	}
+
+	if verifier.store != nil {
+		added, err := verifier.store.Add("app_proxy:"+query.Get("signature"), query.Get("shop"), timestamp.Add(verifier.maxAge))
+		if err != nil {
+			return AppProxyRequest{}, err
+		}
+
+		if !added {
+			return AppProxyRequest{}, NewErrAppProxyInvalid("request has been replayed")
+		}
+	}
+
+	customerID, _ := strconv.ParseInt(query.Get("logged_in_customer_id"), 10, 64)
+
+	return AppProxyRequest{
+		Shop:               query.Get("shop"),
+		LoggedInCustomerID: customerID,
+		PathPrefix:         query.Get("path_prefix"),
+		Timestamp:          timestamp,
+	}, nil
+}
+
+type appProxyContextKey struct{}
+
+// Middleware rejects requests that fail verification with a 401, and adds the app proxy parameters to the context of the rest
+func (verifier AppProxyVerifier) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		proxy, err := verifier.Verify(request.URL.Query())
+		if err != nil {
+			writer.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		ctx := context.WithValue(request.Context(), appProxyContextKey{}, proxy)
+
+		next.ServeHTTP(writer, request.WithContext(ctx))
+	})
+}
+
+// AppProxyFromContext returns the app proxy parameters added to the context by the middleware
+func AppProxyFromContext(ctx context.Context) (AppProxyRequest, bool) {
+	proxy, ok := ctx.Value(appProxyContextKey{}).(AppProxyRequest)
+
+	return proxy, ok
+}
+
+// ErrAppProxyInvalid is thrown when an app proxy request fails verification
+type ErrAppProxyInvalid struct {
+	reason string
+}
+
+func (err ErrAppProxyInvalid) Error() string {
+	return fmt.Sprintf("app proxy request is invalid: %v", err.reason)
+}
+
+// NewErrAppProxyInvalid builds the error
+func NewErrAppProxyInvalid(reason string) ErrAppProxyInvalid {
+	return ErrAppProxyInvalid{
+		reason,
+	}
+}
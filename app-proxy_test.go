@@ -0,0 +1,124 @@
+package httpshopify
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/MOHC-LTD/httpshopify/v2/internal/assertions"
+)
+
+func signAppProxyQuery(secret string, timestamp time.Time) url.Values {
+	query := url.Values{
+		"extra":                 {"1", "2"},
+		"logged_in_customer_id": {"7021"},
+		"path_prefix":           {"/apps/loyalty"},
+		"shop":                  {"my-shop.myshopify.com"},
+		"timestamp":             {strconv.FormatInt(timestamp.Unix(), 10)},
+	}
+
+	message := "extra=1,2" +
+		"logged_in_customer_id=7021" +
+		"path_prefix=/apps/loyalty" +
+		"shop=my-shop.myshopify.com" +
+		"timestamp=" + strconv.FormatInt(timestamp.Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(message))
+	query.Set("signature", hex.EncodeToString(mac.Sum(nil)))
+
+	return query
+}
+
+// Tests that a signed request is verified and its parameters are extracted
+func TestAppProxyVerifier_Verify(t *testing.T) {
+	verifier := NewAppProxyVerifier("hush")
+
+	proxy, err := verifier.Verify(signAppProxyQuery("hush", time.Now()))
+	if err != nil {
+		assertions.ErrAssertionFailure(t, err)
+	}
+
+	if proxy.Shop != "my-shop.myshopify.com" {
+		assertions.ValueAssertionFailure(t, "my-shop.myshopify.com", proxy.Shop)
+	}
+
+	if proxy.LoggedInCustomerID != 7021 {
+		assertions.ValueAssertionFailure(t, int64(7021), proxy.LoggedInCustomerID)
+	}
+
+	if proxy.PathPrefix != "/apps/loyalty" {
+		assertions.ValueAssertionFailure(t, "/apps/loyalty", proxy.PathPrefix)
+	}
+}
+
+// Tests that requests with the wrong signature or a stale timestamp are rejected
+func TestAppProxyVerifier_VerifyInvalid(t *testing.T) {
+	verifier := NewAppProxyVerifier("hush")
+
+	_, err := verifier.Verify(signAppProxyQuery("other", time.Now()))
+	if err == nil {
+		assertions.AssertionFailure(t, "request signed with another secret should be rejected")
+	}
+
+	_, err = verifier.Verify(signAppProxyQuery("hush", time.Now().Add(-time.Hour)))
+	if err == nil {
+		assertions.AssertionFailure(t, "request with a stale timestamp should be rejected")
+	}
+
+}
+
+// Tests that identical requests are accepted by default and rejected as replays when a replay store is configured
+func TestAppProxyVerifier_VerifyReplay(t *testing.T) {
+	query := signAppProxyQuery("hush", time.Now())
+
+	verifier := NewAppProxyVerifier("hush")
+	for i := 0; i < 2; i++ {
+		_, err := verifier.Verify(query)
+		if err != nil {
+			assertions.ErrAssertionFailure(t, err)
+		}
+	}
+
+	verifier = NewAppProxyVerifier("hush", WithAppProxyReplayStore(NewMemoryWebhookStore()))
+	verifier.Verify(query)
+
+	_, err := verifier.Verify(query)
+	if err == nil {
+		assertions.AssertionFailure(t, "replayed request should be rejected")
+	}
+}
+
+// Tests that the middleware rejects unsigned requests and passes the parameters of signed ones through the context
+func TestAppProxyVerifier_Middleware(t *testing.T) {
+	verifier := NewAppProxyVerifier("hush")
+
+	var proxy AppProxyRequest
+	handler := verifier.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxy, _ = AppProxyFromContext(r.Context())
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/proxy?shop=my-shop.myshopify.com", nil))
+
+	if recorder.Code != http.StatusUnauthorized {
+		assertions.ValueAssertionFailure(t, http.StatusUnauthorized, recorder.Code)
+	}
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/proxy?"+signAppProxyQuery("hush", time.Now()).Encode(), nil))
+
+	if recorder.Code != http.StatusOK {
+		assertions.ValueAssertionFailure(t, http.StatusOK, recorder.Code)
+	}
+
+	if proxy.Shop != "my-shop.myshopify.com" {
+		assertions.ValueAssertionFailure(t, "my-shop.myshopify.com", proxy.Shop)
+	}
+}
@@ -24,7 +24,7 @@ func newArticleRepository(client http.Client, createURL func(endpoint string) st
 func (repository articleRepository) Get(blogID, id int64) (shopify.Article, error) {
 	url := repository.createURL(fmt.Sprintf("blogs/%v/articles/%v.json", blogID, id))
 
-	body, _, err := repository.client.Get(url, nil)
+	body, _, err := repository.client.WithOperation("articles.Get").Get(url, nil)
 	if err != nil {
 		return shopify.Article{}, err
 	}
@@ -41,7 +41,7 @@ func (repository articleRepository) Get(blogID, id int64) (shopify.Article, erro
 func (repository articleRepository) GetAll(blogID int64) (shopify.Articles, error) {
 	url := repository.createURL(fmt.Sprintf("blogs/%v/articles.json", blogID))
 
-	body, _, err := repository.client.Get(url, nil)
+	body, _, err := repository.client.WithOperation("articles.GetAll").Get(url, nil)
 	if err != nil {
 		return nil, err
 	}
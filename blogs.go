@@ -24,7 +24,7 @@ func newBlogRepository(client http.Client, createURL func(endpoint string) strin
 func (repository blogRepository) Get(id int64) (shopify.Blog, error) {
 	url := repository.createURL(fmt.Sprintf("blogs/%v.json", id))
 
-	body, _, err := repository.client.Get(url, nil)
+	body, _, err := repository.client.WithOperation("blogs.Get").Get(url, nil)
 	if err != nil {
 		return shopify.Blog{}, err
 	}
@@ -41,7 +41,7 @@ func (repository blogRepository) Get(id int64) (shopify.Blog, error) {
 func (repository blogRepository) GetAll() (shopify.Blogs, error) {
 	url := repository.createURL("blogs.json")
 
-	body, _, err := repository.client.Get(url, nil)
+	body, _, err := repository.client.WithOperation("blogs.GetAll").Get(url, nil)
 	if err != nil {
 		return nil, err
 	}
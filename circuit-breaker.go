@@ -0,0 +1,295 @@
+package httpshopify
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CircuitState is the state of a circuit
+type CircuitState int
+
+const (
+	// CircuitClosed lets every request through
+	CircuitClosed CircuitState = iota
+	// CircuitOpen fails every request fast
+	CircuitOpen
+	// CircuitHalfOpen lets a limited number of probe requests through to test whether Shopify has recovered
+	CircuitHalfOpen
+)
+
+func (state CircuitState) String() string {
+	switch state {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreakerSettings configure when a circuit opens and how it recovers
+type CircuitBreakerSettings struct {
+	// FailureThreshold is the number of consecutive failed requests that opens the circuit. Defaults to 5.
+	FailureThreshold int
+	// OpenDuration is how long the circuit stays open before letting probes through. Defaults to 30 seconds.
+	OpenDuration time.Duration
+	// HalfOpenProbes is how many probe requests are let through at once while half open, all of which must succeed
+	// to close the circuit. Defaults to 1.
+	HalfOpenProbes int
+	// Family groups requests into separate circuits, for example by endpoint with EndpointFamily.
+	// All requests share one circuit when nil.
+	Family func(method string, url string) string
+}
+
+// EndpointFamily groups requests by the resource at the start of their path e.g. orders or products.
+/*
+	This keeps an outage of one endpoint from stopping requests to the rest. The resource is the first segment of
+	the EndpointTemplate of the URL.
+*/
+func EndpointFamily(method string, rawURL string) string {
+	resource, _, _ := strings.Cut(EndpointTemplate(rawURL), "/")
+
+	return strings.TrimSuffix(resource, ".json")
+}
+
+type circuit struct {
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	probes   int
+	passed   int
+}
+
+// CircuitBreaker fails requests fast while Shopify is failing, rather than letting them retry and pile up.
+/*
+	A circuit opens once FailureThreshold requests in a row fail with an error or a 5XX status. While open, requests
+	fail immediately with ErrCircuitOpen, including retries of requests that were already in flight. After
+	OpenDuration a limited number of probes are let through, and the circuit closes once they succeed or opens
+	again if any fail.
+
+	Use one breaker per shop.
+	Example:
+	breaker := httpshopify.NewCircuitBreaker(httpshopify.CircuitBreakerSettings{Family: httpshopify.EndpointFamily})
+	shop := httpshopify.NewShop("my-shop", "shppy_21u92h2184ho912h29r01", "2024-01", httpshopify.WithCircuitBreaker(breaker))
+*/
+type CircuitBreaker struct {
+	settings CircuitBreakerSettings
+	mutex    sync.Mutex
+	circuits map[string]*circuit
+	now      func() time.Time
+}
+
+// NewCircuitBreaker builds a breaker with the settings, defaulting any that are not set
+func NewCircuitBreaker(settings CircuitBreakerSettings) *CircuitBreaker {
+	if settings.FailureThreshold <= 0 {
+		settings.FailureThreshold = 5
+	}
+
+	if settings.OpenDuration <= 0 {
+		settings.OpenDuration = 30 * time.Second
+	}
+
+	if settings.HalfOpenProbes <= 0 {
+		settings.HalfOpenProbes = 1
+	}
+
+	return &CircuitBreaker{
+		settings: settings,
+		circuits: make(map[string]*circuit),
+		now:      time.Now,
+	}
+}
+
+// WithCircuitBreaker configures the shop to guard its requests with the breaker
+func WithCircuitBreaker(breaker *CircuitBreaker) OptionFunc {
+	return func(o *Options) {
+		o.circuitBreaker = breaker
+	}
+}
+
+// Allow returns ErrCircuitOpen when the circuit of the request is open, and otherwise a function to report its outcome
+func (breaker *CircuitBreaker) Allow(method string, url string) (func(failed bool), error) {
+	family := breaker.family(method, url)
+
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	c := breaker.circuit(family)
+	now := breaker.now()
+
+	if c.state == CircuitOpen && !now.Before(c.openedAt.Add(breaker.settings.OpenDuration)) {
+		c.state = CircuitHalfOpen
+		c.probes = 0
+		c.passed = 0
+	}
+
+	switch c.state {
+	case CircuitOpen:
+		return nil, NewErrCircuitOpen(family, c.openedAt.Add(breaker.settings.OpenDuration))
+	case CircuitHalfOpen:
+		if c.probes >= breaker.settings.HalfOpenProbes {
+			return nil, NewErrCircuitOpen(family, now)
+		}
+
+		c.probes++
+
+		return func(failed bool) {
+			breaker.probed(c, failed)
+		}, nil
+	default:
+		return func(failed bool) {
+			breaker.record(c, failed)
+		}, nil
+	}
+}
+
+// clone returns a breaker with the same settings whose circuits are all closed
+func (breaker *CircuitBreaker) clone() *CircuitBreaker {
+	clone := NewCircuitBreaker(breaker.settings)
+	clone.now = breaker.now
+
+	return clone
+}
+
+// Check returns ErrCircuitOpen when Allow would, without taking a probe or changing the state of the circuit
+func (breaker *CircuitBreaker) Check(method string, url string) error {
+	family := breaker.family(method, url)
+
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	c, ok := breaker.circuits[family]
+	if !ok {
+		return nil
+	}
+
+	now := breaker.now()
+	retryAt := c.openedAt.Add(breaker.settings.OpenDuration)
+
+	switch {
+	case c.state == CircuitOpen && now.Before(retryAt):
+		return NewErrCircuitOpen(family, retryAt)
+	case c.state == CircuitHalfOpen && c.probes >= breaker.settings.HalfOpenProbes:
+		return NewErrCircuitOpen(family, now)
+	default:
+		return nil
+	}
+}
+
+// State returns the state of the circuit of each family that has been used.
+/*
+	The circuit that all requests share is under an empty family when no Family is configured.
+*/
+func (breaker *CircuitBreaker) State() map[string]CircuitState {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	states := make(map[string]CircuitState, len(breaker.circuits))
+	for family, c := range breaker.circuits {
+		states[family] = c.state
+	}
+
+	return states
+}
+
+// Healthy returns whether every circuit is closed, for use in health checks
+func (breaker *CircuitBreaker) Healthy() bool {
+	for _, state := range breaker.State() {
+		if state != CircuitClosed {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (breaker *CircuitBreaker) family(method string, url string) string {
+	if breaker.settings.Family == nil {
+		return ""
+	}
+
+	return breaker.settings.Family(method, url)
+}
+
+// circuit returns the circuit of the family, creating it when needed. The mutex must be held.
+func (breaker *CircuitBreaker) circuit(family string) *circuit {
+	c, ok := breaker.circuits[family]
+	if !ok {
+		c = &circuit{}
+		breaker.circuits[family] = c
+	}
+
+	return c
+}
+
+// record counts the outcome of a request made while the circuit was closed
+func (breaker *CircuitBreaker) record(c *circuit, failed bool) {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	if c.state != CircuitClosed {
+		return
+	}
+
+	if !failed {
+		c.failures = 0
+		return
+	}
+
+	c.failures++
+	if c.failures >= breaker.settings.FailureThreshold {
+		c.state = CircuitOpen
+		c.openedAt = breaker.now()
+	}
+}
+
+// probed counts the outcome of a probe made while the circuit was half open
+func (breaker *CircuitBreaker) probed(c *circuit, failed bool) {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	if c.state != CircuitHalfOpen {
+		return
+	}
+
+	if failed {
+		c.state = CircuitOpen
+		c.openedAt = breaker.now()
+		return
+	}
+
+	c.passed++
+	if c.passed >= breaker.settings.HalfOpenProbes {
+		c.state = CircuitClosed
+		c.failures = 0
+	}
+}
+
+// ErrCircuitOpen is thrown when a request is not sent because its circuit is open
+type ErrCircuitOpen struct {
+	// Family is the family of the circuit, empty when all requests share one circuit
+	Family string
+	// RetryAt is when the circuit will next let a probe through
+	RetryAt time.Time
+}
+
+func (err ErrCircuitOpen) Error() string {
+	if err.Family == "" {
+		return fmt.Sprintf("circuit is open until %v", err.RetryAt.Format(time.RFC3339))
+	}
+
+	return fmt.Sprintf("circuit for %v is open until %v", err.Family, err.RetryAt.Format(time.RFC3339))
+}
+
+// NewErrCircuitOpen builds the error
+func NewErrCircuitOpen(family string, retryAt time.Time) ErrCircuitOpen {
+	return ErrCircuitOpen{
+		family,
+		retryAt,
+	}
+}
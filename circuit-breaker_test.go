@@ -0,0 +1,130 @@
+package httpshopify
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MOHC-LTD/httpshopify/v2/internal/assertions"
+)
+
+// Tests that the circuit opens after consecutive failures, lets a probe through once the open duration has passed and closes when it succeeds
+func TestCircuitBreaker_Lifecycle(t *testing.T) {
+	breaker := NewCircuitBreaker(CircuitBreakerSettings{FailureThreshold: 2, OpenDuration: time.Minute})
+
+	now := time.Now()
+	breaker.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		done, err := breaker.Allow(http.MethodGet, "https://shop/admin/api/2024-01/orders.json")
+		if err != nil {
+			assertions.ErrAssertionFailure(t, err)
+		}
+		done(true)
+	}
+
+	_, err := breaker.Allow(http.MethodGet, "https://shop/admin/api/2024-01/orders.json")
+
+	var open ErrCircuitOpen
+	if !errors.As(err, &open) {
+		assertions.ValueAssertionFailure(t, ErrCircuitOpen{}, err)
+	}
+
+	if breaker.Healthy() {
+		assertions.ValueAssertionFailure(t, false, true)
+	}
+
+	now = now.Add(time.Minute)
+
+	probe, err := breaker.Allow(http.MethodGet, "https://shop/admin/api/2024-01/orders.json")
+	if err != nil {
+		assertions.ErrAssertionFailure(t, err)
+	}
+
+	_, err = breaker.Allow(http.MethodGet, "https://shop/admin/api/2024-01/orders.json")
+	if !errors.As(err, &open) {
+		assertions.AssertionFailure(t, "only one probe should be let through while half open")
+	}
+
+	probe(false)
+
+	if state := breaker.State()[""]; state != CircuitClosed {
+		assertions.ValueAssertionFailure(t, CircuitClosed, state)
+	}
+}
+
+// Tests that endpoint families have separate circuits
+func TestCircuitBreaker_EndpointFamily(t *testing.T) {
+	breaker := NewCircuitBreaker(CircuitBreakerSettings{FailureThreshold: 1, Family: EndpointFamily})
+
+	done, _ := breaker.Allow(http.MethodGet, "https://shop/admin/api/2024-01/orders/1.json")
+	done(true)
+
+	_, err := breaker.Allow(http.MethodGet, "https://shop/admin/api/2024-01/products.json")
+	if err != nil {
+		assertions.ErrAssertionFailure(t, err)
+	}
+
+	if state := breaker.State()["orders"]; state != CircuitOpen {
+		assertions.ValueAssertionFailure(t, CircuitOpen, state)
+	}
+}
+
+// Tests that an open circuit stops a shop retrying a failing endpoint
+func TestWithCircuitBreaker_StopsRetries(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	breaker := NewCircuitBreaker(CircuitBreakerSettings{FailureThreshold: 2})
+	shop := NewCustomShop(server.URL, "token", IsDefault, WithExponentialBackoff(5, time.Millisecond, time.Millisecond), WithCircuitBreaker(breaker))
+
+	_, err := shop.Info().Get()
+
+	var open ErrCircuitOpen
+	if !errors.As(err, &open) {
+		assertions.ValueAssertionFailure(t, ErrCircuitOpen{}, err)
+	}
+
+	if requests != 2 {
+		assertions.ValueAssertionFailure(t, 2, requests)
+	}
+}
+
+// Tests that a request fails while the circuit is open without waiting for the rate limit
+func TestWithCircuitBreaker_SkipsRateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	limiter := gateRateLimiter{make(chan struct{}, 1)}
+	limiter.gate <- struct{}{}
+
+	breaker := NewCircuitBreaker(CircuitBreakerSettings{FailureThreshold: 1})
+	shop := NewCustomShop(server.URL, "token", IsDefault, WithExponentialBackoff(0, time.Millisecond, time.Millisecond), WithRateLimiter(limiter), WithCircuitBreaker(breaker))
+
+	shop.Info().Get()
+
+	// The gate is closed, so the request would block if it waited for the rate limit
+	done := make(chan error, 1)
+	go func() {
+		_, err := shop.Info().Get()
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		var open ErrCircuitOpen
+		if !errors.As(err, &open) {
+			assertions.ValueAssertionFailure(t, ErrCircuitOpen{}, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the request")
+	}
+}
@@ -24,7 +24,7 @@ func newCollectionRepository(client http.Client, createURL func(endpoint string)
 func (repository collectionRepository) Get(id int64) (shopify.Collection, error) {
 	url := repository.createURL(fmt.Sprintf("collections/%v.json", id))
 
-	body, _, err := repository.client.Get(url, nil)
+	body, _, err := repository.client.WithOperation("collections.Get").Get(url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -33,50 +33,107 @@ func (repository collectionRepository) Get(id int64) (shopify.Collection, error)
 		Collection CollectionDTO `json:"collection"`
 	}
 
-	json.Unmarshal(body, &resultDTO)
+	err = json.Unmarshal(body, &resultDTO)
+	if err != nil {
+		return nil, err
+	}
 
 	return resultDTO.Collection.ToShopify(), nil
 }
 
 func (repository collectionRepository) GetSmartCollectionsList() (shopify.Collections, error) {
-	url := repository.createURL("smart_collections.json")
+	collections := make(shopify.Collections, 0)
 
-	body, _, err := repository.client.Get(url, nil)
-	if err != nil {
-		return nil, err
-	}
+	url := repository.createURL("smart_collections.json?limit=250")
 
-	var resultDTO struct {
-		Collections CollectionDTOs `json:"smart_collections"`
+	client, span := repository.client.StartOperation("collections.GetSmartCollectionsList", url)
+
+	for {
+		body, headers, err := client.Get(url, nil)
+		if err != nil {
+			span.End(err)
+			return nil, err
+		}
+
+		var resultDTO struct {
+			Collections CollectionDTOs `json:"smart_collections"`
+		}
+
+		err = json.Unmarshal(body, &resultDTO)
+		if err != nil {
+			span.End(err)
+			return nil, err
+		}
+
+		for _, dto := range resultDTO.Collections {
+			dto.CollectionType = shopify.CollectionTypeSmart
+			collections = append(collections, dto.ToShopify())
+		}
+
+		links := ParseLinkHeader(headers.Get("Link"))
+
+		if !links.HasNext() {
+			break
+		}
+
+		url = links.Next
 	}
 
-	json.Unmarshal(body, &resultDTO)
+	span.SetAttribute(http.AttributeRecords, len(collections))
+	span.End(nil)
 
-	return resultDTO.Collections.ToShopify(), nil
+	return collections, nil
 }
 
 func (repository collectionRepository) GetCustomCollectionsList() (shopify.Collections, error) {
-	url := repository.createURL("custom_collections.json")
+	collections := make(shopify.Collections, 0)
 
-	body, _, err := repository.client.Get(url, nil)
-	if err != nil {
-		return nil, err
-	}
+	url := repository.createURL("custom_collections.json?limit=250")
 
-	var resultDTO struct {
-		Collections CollectionDTOs `json:"custom_collections"`
+	client, span := repository.client.StartOperation("collections.GetCustomCollectionsList", url)
+
+	for {
+		body, headers, err := client.Get(url, nil)
+		if err != nil {
+			span.End(err)
+			return nil, err
+		}
+
+		var resultDTO struct {
+			Collections CollectionDTOs `json:"custom_collections"`
+		}
+
+		err = json.Unmarshal(body, &resultDTO)
+		if err != nil {
+			span.End(err)
+			return nil, err
+		}
+
+		for _, dto := range resultDTO.Collections {
+			dto.CollectionType = shopify.CollectionTypeCustom
+			collections = append(collections, dto.ToShopify())
+		}
+
+		links := ParseLinkHeader(headers.Get("Link"))
+
+		if !links.HasNext() {
+			break
+		}
+
+		url = links.Next
 	}
 
-	json.Unmarshal(body, &resultDTO)
+	span.SetAttribute(http.AttributeRecords, len(collections))
+	span.End(nil)
 
-	return resultDTO.Collections.ToShopify(), nil
+	return collections, nil
 }
 
 func (repository collectionRepository) Products(id int64) (shopify.Products, error) {
 
 	url := repository.createURL(fmt.Sprintf("collections/%v/products.json", id))
 
-	body, _, err := repository.client.Get(url, nil)
+	body, _, err := repository.client.WithOperation("collections.Products").Get(url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -123,6 +180,16 @@ type CollectionDTO struct {
 
 // ToShopify converts the DTO to the Shopify equivalent
 func (dto CollectionDTO) ToShopify() shopify.Collection {
+	switch dto.CollectionType {
+	case shopify.CollectionTypeSmart:
+		return dto.ToShopifySmartCollection()
+	default:
+		return dto.ToShopifyCustomCollection()
+	}
+}
+
+// ToShopifyCustomCollection converts the DTO to the Shopify custom collection equivalent
+func (dto CollectionDTO) ToShopifyCustomCollection() shopify.CustomCollection {
 	var publishedAt time.Time
 	if dto.PublishedAt != nil {
 		publishedAt = *dto.PublishedAt
@@ -133,38 +200,48 @@ func (dto CollectionDTO) ToShopify() shopify.Collection {
 		updatedAt = *dto.UpdatedAt
 	}
 
-	switch dto.CollectionType {
-	case shopify.CollectionTypeSmart:
-		return shopify.NewSmartCollection(
-			dto.BodyHTML,
-			dto.CollectionType,
-			dto.Handle,
-			dto.ID,
-			dto.Image.ToShopify(),
-			dto.ProductsCount,
-			publishedAt,
-			dto.PublishedScope,
-			dto.Rules.ToShopify(),
-			dto.Disjunctive,
-			dto.SortOrder,
-			dto.TemplateSuffix,
-			dto.Title,
-			updatedAt,
-		)
-	default:
-		return shopify.NewCustomCollection(
-			dto.BodyHTML,
-			dto.CollectionType,
-			dto.Handle,
-			dto.ID,
-			dto.Image.ToShopify(),
-			dto.ProductsCount,
-			publishedAt,
-			dto.PublishedScope,
-			dto.SortOrder,
-			dto.TemplateSuffix,
-			dto.Title,
-			updatedAt,
-		)
+	return shopify.NewCustomCollection(
+		dto.BodyHTML,
+		dto.CollectionType,
+		dto.Handle,
+		dto.ID,
+		dto.Image.ToShopify(),
+		dto.ProductsCount,
+		publishedAt,
+		dto.PublishedScope,
+		dto.SortOrder,
+		dto.TemplateSuffix,
+		dto.Title,
+		updatedAt,
+	)
+}
+
+// ToShopifySmartCollection converts the DTO to the Shopify smart collection equivalent
+func (dto CollectionDTO) ToShopifySmartCollection() shopify.SmartCollection {
+	var publishedAt time.Time
+	if dto.PublishedAt != nil {
+		publishedAt = *dto.PublishedAt
 	}
+
+	var updatedAt time.Time
+	if dto.UpdatedAt != nil {
+		updatedAt = *dto.UpdatedAt
+	}
+
+	return shopify.NewSmartCollection(
+		dto.BodyHTML,
+		dto.CollectionType,
+		dto.Handle,
+		dto.ID,
+		dto.Image.ToShopify(),
+		dto.ProductsCount,
+		publishedAt,
+		dto.PublishedScope,
+		dto.Rules.ToShopify(),
+		dto.Disjunctive,
+		dto.SortOrder,
+		dto.TemplateSuffix,
+		dto.Title,
+		updatedAt,
+	)
 }
@@ -0,0 +1,275 @@
+package httpshopify
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/MOHC-LTD/httpshopify/v2/internal/http"
+)
+
+// Collect connects a product to a custom collection
+type Collect struct {
+	// ID is the ID for the collect.
+	ID int64
+	// CollectionID is the ID of the custom collection containing the product.
+	CollectionID int64
+	// ProductID is the unique numeric identifier for the product in the custom collection.
+	ProductID int64
+	// Position is the position of this product in a manually sorted custom collection. The first position is 1.
+	Position int
+	// SortValue is the position of the product as a string, padded with leading zeroes.
+	SortValue string
+	// CreatedAt is the date and time when the collect was created.
+	CreatedAt time.Time
+	// UpdatedAt is the date and time when the collect was last updated.
+	UpdatedAt time.Time
+}
+
+// Collects is a collection of collects
+type Collects []Collect
+
+// CollectQuery are properties that can be used to filter the returned collects
+type CollectQuery struct {
+	// CollectionID restricts the collects to those in the collection
+	CollectionID int64
+	// ProductID restricts the collects to those for the product
+	ProductID int64
+}
+
+// CollectRepository maintains the links between products and custom collections.
+type CollectRepository interface {
+	// List retrieves a list of collects
+	List(query CollectQuery) (Collects, error)
+	// Get retrieves a single collect
+	Get(id int64) (Collect, error)
+	// Add adds a product to a custom collection
+	Add(collectionID int64, productID int64) (Collect, error)
+	// Remove removes a product from a custom collection
+	Remove(collectionID int64, productID int64) error
+	// Delete deletes a collect
+	Delete(id int64) error
+}
+
+type collectRepository struct {
+	client    http.Client
+	createURL func(endpoint string) string
+}
+
+func newCollectRepository(client http.Client, createURL func(endpoint string) string) collectRepository {
+	return collectRepository{
+		client,
+		createURL,
+	}
+}
+
+func (repository collectRepository) List(query CollectQuery) (Collects, error) {
+	collects := make(Collects, 0)
+
+	url := repository.createURL(fmt.Sprintf("collects.json?%v", parseCollectQuery(query)))
+
+	client, span := repository.client.StartOperation("collects.List", url)
+
+	for {
+		body, headers, err := client.Get(url, nil)
+		if err != nil {
+			span.End(err)
+			return nil, err
+		}
+
+		var resultDTO struct {
+			Collects CollectDTOs `json:"collects"`
+		}
+
+		err = json.Unmarshal(body, &resultDTO)
+		if err != nil {
+			span.End(err)
+			return nil, err
+		}
+
+		collects = append(collects, resultDTO.Collects.ToShopify()...)
+
+		links := ParseLinkHeader(headers.Get("Link"))
+
+		if !links.HasNext() {
+			break
+		}
+
+		url = links.Next
+	}
+
+	span.SetAttribute(http.AttributeRecords, len(collects))
+	span.End(nil)
+
+	return collects, nil
+}
+
+func (repository collectRepository) Get(id int64) (Collect, error) {
+	url := repository.createURL(fmt.Sprintf("collects/%d.json", id))
+
+	body, _, err := repository.client.WithOperation("collects.Get").Get(url, nil)
+	if err != nil {
+		return Collect{}, err
+	}
+
+	var response struct {
+		Collect CollectDTO `json:"collect"`
+	}
+
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return Collect{}, err
+	}
+
+	if response.Collect.ID == 0 {
+		return Collect{}, NewErrCollectNotFound(id)
+	}
+
+	return response.Collect.ToShopify(), nil
+}
+
+func (repository collectRepository) Add(collectionID int64, productID int64) (Collect, error) {
+	request := struct {
+		Collect CollectDTO `json:"collect"`
+	}{
+		Collect: CollectDTO{
+			CollectionID: collectionID,
+			ProductID:    productID,
+		},
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return Collect{}, err
+	}
+
+	url := repository.createURL("collects.json")
+
+	respBody, _, err := repository.client.WithOperation("collects.Add").Post(url, body, nil)
+	if err != nil {
+		return Collect{}, err
+	}
+
+	var response struct {
+		Collect CollectDTO `json:"collect"`
+	}
+
+	err = json.Unmarshal(respBody, &response)
+	if err != nil {
+		return Collect{}, err
+	}
+
+	return response.Collect.ToShopify(), nil
+}
+
+func (repository collectRepository) Remove(collectionID int64, productID int64) error {
+	collects, err := repository.List(CollectQuery{
+		CollectionID: collectionID,
+		ProductID:    productID,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, collect := range collects {
+		err = repository.Delete(collect.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (repository collectRepository) Delete(id int64) error {
+	url := repository.createURL(fmt.Sprintf("collects/%d.json", id))
+
+	_, _, err := repository.client.WithOperation("collects.Delete").Delete(url, nil)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func parseCollectQuery(query CollectQuery) string {
+	params := url.Values{}
+
+	params.Add("limit", "250")
+
+	if query.CollectionID != 0 {
+		params.Add("collection_id", strconv.FormatInt(query.CollectionID, 10))
+	}
+
+	if query.ProductID != 0 {
+		params.Add("product_id", strconv.FormatInt(query.ProductID, 10))
+	}
+
+	return params.Encode()
+}
+
+// CollectDTOs is a collection of Collect DTOs
+type CollectDTOs []CollectDTO
+
+// ToShopify converts the DTOs to the Shopify equivalent
+func (dtos CollectDTOs) ToShopify() Collects {
+	collects := make(Collects, 0, len(dtos))
+
+	for _, dto := range dtos {
+		collects = append(collects, dto.ToShopify())
+	}
+
+	return collects
+}
+
+// CollectDTO represents a Shopify collect in HTTP requests and responses
+type CollectDTO struct {
+	ID           int64      `json:"id,omitempty"`
+	CollectionID int64      `json:"collection_id,omitempty"`
+	ProductID    int64      `json:"product_id,omitempty"`
+	Position     int        `json:"position,omitempty"`
+	SortValue    string     `json:"sort_value,omitempty"`
+	CreatedAt    *time.Time `json:"created_at,omitempty"`
+	UpdatedAt    *time.Time `json:"updated_at,omitempty"`
+}
+
+// ToShopify converts the DTO to the Shopify equivalent
+func (dto CollectDTO) ToShopify() Collect {
+	var createdAt time.Time
+	if dto.CreatedAt != nil {
+		createdAt = *dto.CreatedAt
+	}
+
+	var updatedAt time.Time
+	if dto.UpdatedAt != nil {
+		updatedAt = *dto.UpdatedAt
+	}
+
+	return Collect{
+		ID:           dto.ID,
+		CollectionID: dto.CollectionID,
+		ProductID:    dto.ProductID,
+		Position:     dto.Position,
+		SortValue:    dto.SortValue,
+		CreatedAt:    createdAt,
+		UpdatedAt:    updatedAt,
+	}
+}
+
+// ErrCollectNotFound is thrown when no collect is found with the id
+type ErrCollectNotFound struct {
+	id int64
+}
+
+func (err ErrCollectNotFound) Error() string {
+	return fmt.Sprintf("collect %v not found", err.id)
+}
+
+// NewErrCollectNotFound builds the error
+func NewErrCollectNotFound(id int64) ErrCollectNotFound {
+	return ErrCollectNotFound{
+		id,
+	}
+}
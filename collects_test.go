@@ -0,0 +1,61 @@
+package httpshopify
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MOHC-LTD/httpshopify/v2/internal/assertions"
+)
+
+// Tests that collect can be built correctly when date fields are not nil
+func TestCollectDTO_ToShopify(t *testing.T) {
+	createdAt := time.Now()
+	updatedAt := time.Now()
+
+	var collectDTO = CollectDTO{
+		CreatedAt: &createdAt,
+		UpdatedAt: &updatedAt,
+	}
+
+	collect := collectDTO.ToShopify()
+
+	if !collect.CreatedAt.Equal(createdAt) {
+		assertions.ValueAssertionFailure(t, createdAt, collect.CreatedAt)
+	}
+
+	if !collect.UpdatedAt.Equal(updatedAt) {
+		assertions.ValueAssertionFailure(t, updatedAt, collect.UpdatedAt)
+	}
+}
+
+// Tests that collect can be built correctly when date fields are nil
+func TestCollectDTO_ToShopifyEmptyTimes(t *testing.T) {
+	var createdAt *time.Time
+	var updatedAt *time.Time
+
+	var collectDTO = CollectDTO{
+		CreatedAt: createdAt,
+		UpdatedAt: updatedAt,
+	}
+
+	collect := collectDTO.ToShopify()
+
+	if !collect.CreatedAt.IsZero() {
+		assertions.ValueAssertionFailure(t, createdAt, collect.CreatedAt)
+	}
+
+	if !collect.UpdatedAt.IsZero() {
+		assertions.ValueAssertionFailure(t, updatedAt, collect.UpdatedAt)
+	}
+}
+
+// Tests that the collect query is encoded with the filters that are set
+func TestParseCollectQuery(t *testing.T) {
+	actual := parseCollectQuery(CollectQuery{CollectionID: 1, ProductID: 2})
+
+	expected := "collection_id=1&limit=250&product_id=2"
+
+	if actual != expected {
+		assertions.ValueAssertionFailure(t, expected, actual)
+	}
+}
@@ -0,0 +1,207 @@
+package httpshopify
+
+import (
+	"context"
+	"time"
+)
+
+// Mandatory compliance webhook topics that every public app must handle
+const (
+	// CustomersDataRequestTopic is sent when a customer requests their data from a shop
+	CustomersDataRequestTopic = "customers/data_request"
+	// CustomersRedactTopic is sent when a shop requests that the data of a customer is erased
+	CustomersRedactTopic = "customers/redact"
+	// ShopRedactTopic is sent 48 hours after a shop uninstalls the app, requesting that the data of the shop is erased
+	ShopRedactTopic = "shop/redact"
+)
+
+// ComplianceDeadline is how long an app has to complete a compliance request after it is received
+const ComplianceDeadline = 30 * 24 * time.Hour
+
+// ComplianceCustomer identifies the customer a compliance request is about
+type ComplianceCustomer struct {
+	// ID is the ID of the customer.
+	ID int64
+	// Email is the email address of the customer.
+	Email string
+	// Phone is the phone number of the customer.
+	Phone string
+}
+
+// CustomerDataRequest asks the app to provide the data it holds about a customer to the shop
+type CustomerDataRequest struct {
+	// ShopID is the ID of the shop.
+	ShopID int64
+	// ShopDomain is the myshopify.com domain of the shop.
+	ShopDomain string
+	// Customer is the customer whose data is requested.
+	Customer ComplianceCustomer
+	// OrdersRequested are the IDs of the orders of the customer whose data is requested.
+	OrdersRequested []int64
+	// DataRequestID is the ID of the request.
+	DataRequestID int64
+	// Deadline is when the data must have been provided to the shop by.
+	Deadline time.Time
+}
+
+// CustomerRedact asks the app to erase the data it holds about a customer
+type CustomerRedact struct {
+	// ShopID is the ID of the shop.
+	ShopID int64
+	// ShopDomain is the myshopify.com domain of the shop.
+	ShopDomain string
+	// Customer is the customer whose data must be erased.
+	Customer ComplianceCustomer
+	// OrdersToRedact are the IDs of the orders of the customer whose data must be erased.
+	OrdersToRedact []int64
+	// Deadline is when the data must have been erased by.
+	Deadline time.Time
+}
+
+// ShopRedact asks the app to erase the data it holds about a shop
+type ShopRedact struct {
+	// ShopID is the ID of the shop.
+	ShopID int64
+	// ShopDomain is the myshopify.com domain of the shop.
+	ShopDomain string
+	// Deadline is when the data must have been erased by.
+	Deadline time.Time
+}
+
+// ComplianceHandler exports and erases the data an app holds to meet the mandatory compliance webhooks.
+/*
+	Each method is called once the webhook is verified. Shopify only expects the webhook to be acknowledged, so the
+	request can be queued and completed later as long as it is done before its deadline. Returning an error responds
+	to Shopify with a 500 so that the webhook is retried.
+*/
+type ComplianceHandler interface {
+	// CustomerDataRequest provides the data held about a customer to the shop
+	CustomerDataRequest(ctx context.Context, request CustomerDataRequest) error
+	// CustomerRedact erases the data held about a customer
+	CustomerRedact(ctx context.Context, request CustomerRedact) error
+	// ShopRedact erases the data held about a shop
+	ShopRedact(ctx context.Context, request ShopRedact) error
+}
+
+// HandleCompliance registers the handler for the mandatory compliance topics.
+/*
+	Webhooks with an invalid HMAC are rejected with a 401, which Shopify checks for during app review.
+	Example:
+	receiver := httpshopify.NewWebhookReceiver("app-secret")
+	receiver.HandleCompliance(complianceHandler)
+	http.Handle("/webhooks/compliance", receiver)
+*/
+func (receiver *WebhookReceiver) HandleCompliance(handler ComplianceHandler) {
+	receiver.HandleFunc(CustomersDataRequestTopic, func(ctx context.Context, delivery WebhookDelivery) error {
+		var dto CustomerDataRequestDTO
+		err := delivery.Decode(&dto)
+		if err != nil {
+			return err
+		}
+
+		return handler.CustomerDataRequest(ctx, dto.ToShopify(complianceDeadline(delivery)))
+	})
+
+	receiver.HandleFunc(CustomersRedactTopic, func(ctx context.Context, delivery WebhookDelivery) error {
+		var dto CustomerRedactDTO
+		err := delivery.Decode(&dto)
+		if err != nil {
+			return err
+		}
+
+		return handler.CustomerRedact(ctx, dto.ToShopify(complianceDeadline(delivery)))
+	})
+
+	receiver.HandleFunc(ShopRedactTopic, func(ctx context.Context, delivery WebhookDelivery) error {
+		var dto ShopRedactDTO
+		err := delivery.Decode(&dto)
+		if err != nil {
+			return err
+		}
+
+		return handler.ShopRedact(ctx, dto.ToShopify(complianceDeadline(delivery)))
+	})
+}
+
+// complianceDeadline works out the deadline of the request from when it was triggered, or from now when that is unknown
+func complianceDeadline(delivery WebhookDelivery) time.Time {
+	receivedAt := delivery.TriggeredAt
+	if receivedAt.IsZero() {
+		receivedAt = time.Now()
+	}
+
+	return receivedAt.Add(ComplianceDeadline)
+}
+
+// ComplianceCustomerDTO represents the customer of a compliance webhook
+type ComplianceCustomerDTO struct {
+	ID    int64  `json:"id"`
+	Email string `json:"email"`
+	Phone string `json:"phone"`
+}
+
+// ToShopify converts the DTO to the Shopify equivalent
+func (dto ComplianceCustomerDTO) ToShopify() ComplianceCustomer {
+	return ComplianceCustomer{
+		ID:    dto.ID,
+		Email: dto.Email,
+		Phone: dto.Phone,
+	}
+}
+
+// CustomerDataRequestDTO represents the payload of a customers/data_request webhook
+type CustomerDataRequestDTO struct {
+	ShopID          int64                 `json:"shop_id"`
+	ShopDomain      string                `json:"shop_domain"`
+	Customer        ComplianceCustomerDTO `json:"customer"`
+	OrdersRequested []int64               `json:"orders_requested"`
+	DataRequest     struct {
+		ID int64 `json:"id"`
+	} `json:"data_request"`
+}
+
+// ToShopify converts the DTO to the Shopify equivalent
+func (dto CustomerDataRequestDTO) ToShopify(deadline time.Time) CustomerDataRequest {
+	return CustomerDataRequest{
+		ShopID:          dto.ShopID,
+		ShopDomain:      dto.ShopDomain,
+		Customer:        dto.Customer.ToShopify(),
+		OrdersRequested: dto.OrdersRequested,
+		DataRequestID:   dto.DataRequest.ID,
+		Deadline:        deadline,
+	}
+}
+
+// CustomerRedactDTO represents the payload of a customers/redact webhook
+type CustomerRedactDTO struct {
+	ShopID         int64                 `json:"shop_id"`
+	ShopDomain     string                `json:"shop_domain"`
+	Customer       ComplianceCustomerDTO `json:"customer"`
+	OrdersToRedact []int64               `json:"orders_to_redact"`
+}
+
+// ToShopify converts the DTO to the Shopify equivalent
+func (dto CustomerRedactDTO) ToShopify(deadline time.Time) CustomerRedact {
+	return CustomerRedact{
+		ShopID:         dto.ShopID,
+		ShopDomain:     dto.ShopDomain,
+		Customer:       dto.Customer.ToShopify(),
+		OrdersToRedact: dto.OrdersToRedact,
+		Deadline:       deadline,
+	}
+}
+
+// ShopRedactDTO represents the payload of a shop/redact webhook
+type ShopRedactDTO struct {
+	ShopID     int64  `json:"shop_id"`
+	ShopDomain string `json:"shop_domain"`
+}
+
+// ToShopify converts the DTO to the Shopify equivalent
+func (dto ShopRedactDTO) ToShopify(deadline time.Time) ShopRedact {
+	return ShopRedact{
+		ShopID:     dto.ShopID,
+		ShopDomain: dto.ShopDomain,
+		Deadline:   deadline,
+	}
+}
@@ -0,0 +1,88 @@
+package httpshopify
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MOHC-LTD/httpshopify/v2/internal/assertions"
+)
+
+type recordingComplianceHandler struct {
+	dataRequest    CustomerDataRequest
+	customerRedact CustomerRedact
+	shopRedact     ShopRedact
+}
+
+func (handler *recordingComplianceHandler) CustomerDataRequest(ctx context.Context, request CustomerDataRequest) error {
+	handler.dataRequest = request
+	return nil
+}
+
+func (handler *recordingComplianceHandler) CustomerRedact(ctx context.Context, request CustomerRedact) error {
+	handler.customerRedact = request
+	return nil
+}
+
+func (handler *recordingComplianceHandler) ShopRedact(ctx context.Context, request ShopRedact) error {
+	handler.shopRedact = request
+	return nil
+}
+
+// Tests that a customers/data_request webhook is decoded and given a deadline 30 days after it was triggered
+func TestWebhookReceiver_HandleComplianceDataRequest(t *testing.T) {
+	handler := &recordingComplianceHandler{}
+	receiver := NewWebhookReceiver(testWebhookSecret)
+	receiver.HandleCompliance(handler)
+
+	body := []byte(`{"shop_id":954889,"shop_domain":"example.myshopify.com","orders_requested":[299938,280263],"customer":{"id":191167,"email":"john@example.com","phone":"555-625-1199"},"data_request":{"id":9999}}`)
+	request := newWebhookRequest(CustomersDataRequestTopic, body, signWebhook(testWebhookSecret, body))
+	request.Header.Set(WebhookHeaderTriggeredAt, "2024-01-01T00:00:00Z")
+
+	recorder := httptest.NewRecorder()
+	receiver.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusOK {
+		assertions.ValueAssertionFailure(t, http.StatusOK, recorder.Code)
+	}
+
+	if handler.dataRequest.Customer.ID != 191167 {
+		assertions.ValueAssertionFailure(t, int64(191167), handler.dataRequest.Customer.ID)
+	}
+
+	if len(handler.dataRequest.OrdersRequested) != 2 {
+		assertions.ValueAssertionFailure(t, 2, len(handler.dataRequest.OrdersRequested))
+	}
+
+	if handler.dataRequest.DataRequestID != 9999 {
+		assertions.ValueAssertionFailure(t, int64(9999), handler.dataRequest.DataRequestID)
+	}
+
+	deadline := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	if !handler.dataRequest.Deadline.Equal(deadline) {
+		assertions.ValueAssertionFailure(t, deadline, handler.dataRequest.Deadline)
+	}
+}
+
+// Tests that redact webhooks are dispatched to the handler
+func TestWebhookReceiver_HandleComplianceRedact(t *testing.T) {
+	handler := &recordingComplianceHandler{}
+	receiver := NewWebhookReceiver(testWebhookSecret)
+	receiver.HandleCompliance(handler)
+
+	customerBody := []byte(`{"shop_id":954889,"shop_domain":"example.myshopify.com","customer":{"id":191167},"orders_to_redact":[299938]}`)
+	receiver.ServeHTTP(httptest.NewRecorder(), newWebhookRequest(CustomersRedactTopic, customerBody, signWebhook(testWebhookSecret, customerBody)))
+
+	shopBody := []byte(`{"shop_id":954889,"shop_domain":"example.myshopify.com"}`)
+	receiver.ServeHTTP(httptest.NewRecorder(), newWebhookRequest(ShopRedactTopic, shopBody, signWebhook(testWebhookSecret, shopBody)))
+
+	if handler.customerRedact.OrdersToRedact[0] != 299938 {
+		assertions.ValueAssertionFailure(t, int64(299938), handler.customerRedact.OrdersToRedact[0])
+	}
+
+	if handler.shopRedact.ShopDomain != "example.myshopify.com" {
+		assertions.ValueAssertionFailure(t, "example.myshopify.com", handler.shopRedact.ShopDomain)
+	}
+}
@@ -0,0 +1,188 @@
+package httpshopify
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/MOHC-LTD/httpshopify/v2/internal/http"
+	"github.com/MOHC-LTD/shopify/v2"
+)
+
+// CustomCollectionRepository maintains the custom collections of a shop.
+type CustomCollectionRepository interface {
+	// Create creates a new custom collection
+	Create(collection shopify.CustomCollection) (shopify.CustomCollection, error)
+	// Update updates an existing custom collection
+	Update(collection shopify.CustomCollection) (shopify.CustomCollection, error)
+	// Delete deletes a custom collection
+	Delete(id int64) error
+	// Order sets the manual sort order of the products in a custom collection.
+	/*
+		The products are positioned in the order that their IDs are passed in and the
+		sort order of the collection is set to manual.
+	*/
+	Order(id int64, productIDs []int64) error
+}
+
+type customCollectionRepository struct {
+	client    http.Client
+	createURL func(endpoint string) string
+}
+
+func newCustomCollectionRepository(client http.Client, createURL func(endpoint string) string) customCollectionRepository {
+	return customCollectionRepository{
+		client,
+		createURL,
+	}
+}
+
+func (repository customCollectionRepository) Create(collection shopify.CustomCollection) (shopify.CustomCollection, error) {
+	request := struct {
+		CustomCollection CustomCollectionDTO `json:"custom_collection"`
+	}{
+		CustomCollection: BuildCustomCollectionDTO(collection),
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return shopify.CustomCollection{}, err
+	}
+
+	url := repository.createURL("custom_collections.json")
+
+	respBody, _, err := repository.client.WithOperation("customCollections.Create").Post(url, body, nil)
+	if err != nil {
+		return shopify.CustomCollection{}, err
+	}
+
+	return parseCustomCollectionResponse(respBody)
+}
+
+func (repository customCollectionRepository) Update(collection shopify.CustomCollection) (shopify.CustomCollection, error) {
+	request := struct {
+		CustomCollection CustomCollectionDTO `json:"custom_collection"`
+	}{
+		CustomCollection: BuildCustomCollectionDTO(collection),
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return shopify.CustomCollection{}, err
+	}
+
+	url := repository.createURL(fmt.Sprintf("custom_collections/%d.json", collection.ID()))
+
+	respBody, _, err := repository.client.WithOperation("customCollections.Update").Put(url, body, nil)
+	if err != nil {
+		return shopify.CustomCollection{}, err
+	}
+
+	return parseCustomCollectionResponse(respBody)
+}
+
+func (repository customCollectionRepository) Delete(id int64) error {
+	url := repository.createURL(fmt.Sprintf("custom_collections/%d.json", id))
+
+	_, _, err := repository.client.WithOperation("customCollections.Delete").Delete(url, nil)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repository customCollectionRepository) Order(id int64, productIDs []int64) error {
+	collects := make(CollectDTOs, 0, len(productIDs))
+	for i, productID := range productIDs {
+		collects = append(collects, CollectDTO{
+			ProductID: productID,
+			Position:  i + 1,
+		})
+	}
+
+	request := struct {
+		CustomCollection CustomCollectionDTO `json:"custom_collection"`
+	}{
+		CustomCollection: CustomCollectionDTO{
+			ID:        id,
+			SortOrder: shopify.CollectionSortOrderManual,
+			Collects:  collects,
+		},
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	url := repository.createURL(fmt.Sprintf("custom_collections/%d.json", id))
+
+	_, _, err = repository.client.WithOperation("customCollections.Order").Put(url, body, nil)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func parseCustomCollectionResponse(body []byte) (shopify.CustomCollection, error) {
+	var response struct {
+		CustomCollection CollectionDTO `json:"custom_collection"`
+	}
+
+	err := json.Unmarshal(body, &response)
+	if err != nil {
+		return shopify.CustomCollection{}, err
+	}
+
+	response.CustomCollection.CollectionType = shopify.CollectionTypeCustom
+
+	return response.CustomCollection.ToShopifyCustomCollection(), nil
+}
+
+// CustomCollectionDTO represents a Shopify custom collection in HTTP create and update requests
+type CustomCollectionDTO struct {
+	ID             int64       `json:"id,omitempty"`
+	BodyHTML       string      `json:"body_html,omitempty"`
+	Handle         string      `json:"handle,omitempty"`
+	Image          *ImageDTO   `json:"image,omitempty"`
+	PublishedAt    *time.Time  `json:"published_at,omitempty"`
+	PublishedScope string      `json:"published_scope,omitempty"`
+	SortOrder      string      `json:"sort_order,omitempty"`
+	TemplateSuffix string      `json:"template_suffix,omitempty"`
+	Title          string      `json:"title,omitempty"`
+	Collects       CollectDTOs `json:"collects,omitempty"`
+}
+
+// BuildCustomCollectionDTO builds the DTO from the Shopify equivalent
+func BuildCustomCollectionDTO(collection shopify.CustomCollection) CustomCollectionDTO {
+	var publishedAt *time.Time
+	if published := collection.PublishedAt(); !published.IsZero() {
+		publishedAt = &published
+	}
+
+	return CustomCollectionDTO{
+		ID:             collection.ID(),
+		BodyHTML:       collection.BodyHTML(),
+		Handle:         collection.Handle(),
+		Image:          buildCollectionImageDTO(collection.Image()),
+		PublishedAt:    publishedAt,
+		PublishedScope: collection.PublishedScope(),
+		SortOrder:      collection.SortOrder(),
+		TemplateSuffix: collection.TemplateSuffix(),
+		Title:          collection.Title(),
+	}
+}
+
+// buildCollectionImageDTO builds the image of a collection, returning nil when there is no image to send
+func buildCollectionImageDTO(image shopify.Image) *ImageDTO {
+	if image.SRC == "" && image.Alt == "" {
+		return nil
+	}
+
+	return &ImageDTO{
+		SRC: image.SRC,
+		Alt: image.Alt,
+	}
+}
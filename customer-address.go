@@ -23,7 +23,7 @@ func newCustomerAddressRepository(client http.Client, createURL func(endpoint st
 func (r customerAddressRepository) List(id int64) (shopify.CustomerAddresses, error) {
 	url := r.createURL(fmt.Sprintf("customers/%v/addresses.json", id))
 
-	body, _, err := r.client.Get(url, nil)
+	body, _, err := r.client.WithOperation("customerAddresses.List").Get(url, nil)
 	if err != nil {
 		return shopify.CustomerAddresses{}, err
 	}
@@ -56,7 +56,7 @@ func (r customerAddressRepository) Create(id int64, address shopify.CustomerAddr
 		return shopify.CustomerAddress{}, err
 	}
 
-	resBody, _, err := r.client.Post(url, body, nil)
+	resBody, _, err := r.client.WithOperation("customerAddresses.Create").Post(url, body, nil)
 	if err != nil {
 		return shopify.CustomerAddress{}, err
 	}
@@ -90,7 +90,7 @@ func (r customerAddressRepository) Update(id int64, address shopify.CustomerAddr
 
 	url := r.createURL(fmt.Sprintf("customers/%v/addresses/%v.json", id, address.ID))
 
-	resBody, _, err := r.client.Put(url, body, nil)
+	resBody, _, err := r.client.WithOperation("customerAddresses.Update").Put(url, body, nil)
 	if err != nil {
 		return shopify.CustomerAddress{}, err
 	}
@@ -110,7 +110,7 @@ func (r customerAddressRepository) Update(id int64, address shopify.CustomerAddr
 func (r customerAddressRepository) SetDefault(id int64, addressID int64) (shopify.CustomerAddress, error) {
 	url := r.createURL(fmt.Sprintf("customers/%v/addresses/%v/default.json", id, addressID))
 
-	resBody, _, err := r.client.Put(url, nil, nil)
+	resBody, _, err := r.client.WithOperation("customerAddresses.SetDefault").Put(url, nil, nil)
 	if err != nil {
 		return shopify.CustomerAddress{}, err
 	}
@@ -130,7 +130,7 @@ func (r customerAddressRepository) SetDefault(id int64, addressID int64) (shopif
 func (r customerAddressRepository) Delete(id int64, addressID int64) error {
 	url := r.createURL(fmt.Sprintf("customers/%v/addresses/%v.json", id, addressID))
 
-	_, _, err := r.client.Delete(url, nil)
+	_, _, err := r.client.WithOperation("customerAddresses.Delete").Delete(url, nil)
 	if err != nil {
 		return err
 	}
@@ -119,7 +119,7 @@ func newCustomerRepository(client http.Client, createURL func(endpoint string) s
 func (c customerRepository) Get(id int64) (shopify.Customer, error) {
 	url := c.createURL(fmt.Sprintf("customers/%v.json", id))
 
-	body, _, err := c.client.Get(url, nil)
+	body, _, err := c.client.WithOperation("customers.Get").Get(url, nil)
 	if err != nil {
 		switch err.(type) {
 		// TODO This ErrHTTP feels like bloat now. Can probably simplify the http work
@@ -166,7 +166,7 @@ func (c customerRepository) Update(customer shopify.Customer) (shopify.Customer,
 
 	url := c.createURL(fmt.Sprintf("customers/%d.json", customer.ID))
 
-	respBody, _, err := c.client.Put(url, body, nil)
+	respBody, _, err := c.client.WithOperation("customers.Update").Put(url, body, nil)
 	if err != nil {
 		switch err.(type) {
 		// TODO This ErrHTTP feels like bloat now. Can probably simplify the http work
@@ -203,14 +203,9 @@ func (c customerRepository) Update(customer shopify.Customer) (shopify.Customer,
 func (c customerRepository) GetByQuery(fields []string, query shopify.CustomerSearchQuery) (shopify.Customers, error) {
 	url := c.createURL(fmt.Sprintf("customers/search.json?fields=%v&query=%s", strings.Join(fields, ","), query.String()))
 
-	body, _, err := c.client.Get(url, nil)
+	body, _, err := c.client.WithOperation("customers.GetByQuery").Get(url, nil)
 	if err != nil {
-		switch err.(http.ErrHTTP).Code {
-		case httpCode.StatusNotFound:
-			return shopify.Customers{}, err
-		default:
-			return shopify.Customers{}, err
-		}
+		return shopify.Customers{}, err
 	}
 
 	var responseDTO struct {
@@ -230,9 +225,12 @@ func (c customerRepository) List(query shopify.CustomerSearchQuery) (shopify.Cus
 
 	url := c.createURL(fmt.Sprintf("customers.json%v", parseCustomerQuery(query)))
 
+	client, span := c.client.StartOperation("customers.List", url)
+
 	for {
-		body, headers, err := c.client.Get(url, nil)
+		body, headers, err := client.Get(url, nil)
 		if err != nil {
+			span.End(err)
 			return nil, err
 		}
 
@@ -254,6 +252,9 @@ func (c customerRepository) List(query shopify.CustomerSearchQuery) (shopify.Cus
 		url = links.Next
 	}
 
+	span.SetAttribute(http.AttributeRecords, len(customers))
+	span.End(nil)
+
 	return customers, nil
 }
 
@@ -261,7 +262,7 @@ func (c customerRepository) Orders(id int64, query shopify.OrderQuery) (shopify.
 
 	url := c.createURL(fmt.Sprintf("customers/%v/orders.json%v", id, parseOrderQuery(query)))
 
-	body, _, err := c.client.Get(url, nil)
+	body, _, err := c.client.WithOperation("customers.Orders").Get(url, nil)
 	if err != nil {
 		return shopify.Orders{}, err
 	}
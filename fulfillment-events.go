@@ -40,7 +40,7 @@ func (repository fulfillmentEventRepository) Create(orderID int64, fulfillmentID
 
 	url := repository.createURL(fmt.Sprintf("orders/%v/fulfillments/%v/events.json", orderID, fulfillmentID))
 
-	respBody, _, err := repository.client.Post(url, body, nil)
+	respBody, _, err := repository.client.WithOperation("fulfillmentEvents.Create").Post(url, body, nil)
 	if err != nil {
 		return shopify.FulfillmentEvent{}, err
 	}
@@ -60,7 +60,7 @@ func (repository fulfillmentEventRepository) Create(orderID int64, fulfillmentID
 func (repository fulfillmentEventRepository) Delete(orderID int64, fulfillmentID int64, eventID int64) error {
 	url := repository.createURL(fmt.Sprintf("orders/%v/fulfillments/%v/events/%v.json", orderID, fulfillmentID, eventID))
 
-	_, _, err := repository.client.Delete(url, nil)
+	_, _, err := repository.client.WithOperation("fulfillmentEvents.Delete").Delete(url, nil)
 	if err != nil {
 		return err
 	}
@@ -71,7 +71,7 @@ func (repository fulfillmentEventRepository) Delete(orderID int64, fulfillmentID
 func (repository fulfillmentEventRepository) List(orderID int64, fulfillmentID int64) ([]shopify.FulfillmentEvent, error) {
 	url := repository.createURL(fmt.Sprintf("orders/%v/fulfillments/%v/events.json", orderID, fulfillmentID))
 
-	respBody, _, err := repository.client.Get(url, nil)
+	respBody, _, err := repository.client.WithOperation("fulfillmentEvents.List").Get(url, nil)
 	if err != nil {
 		return nil, err
 	}
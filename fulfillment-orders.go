@@ -25,7 +25,7 @@ func newFulfillmentOrderRepository(client http.Client, createURL func(endpoint s
 func (repository fulfillmentOrderRepository) Get(id int64) (shopify.FulfillmentOrder, error) {
 	url := repository.createURL(fmt.Sprintf("fulfillment_orders/%d.json", id))
 
-	respBody, _, err := repository.client.Get(url, nil)
+	respBody, _, err := repository.client.WithOperation("fulfillmentOrders.Get").Get(url, nil)
 	if err != nil {
 		return shopify.FulfillmentOrder{}, err
 	}
@@ -45,7 +45,7 @@ func (repository fulfillmentOrderRepository) Get(id int64) (shopify.FulfillmentO
 func (repository fulfillmentOrderRepository) List(orderID int64) (shopify.FulfillmentOrders, error) {
 	url := repository.createURL(fmt.Sprintf("orders/%d/fulfillment_orders.json", orderID))
 
-	respBody, _, err := repository.client.Get(url, nil)
+	respBody, _, err := repository.client.WithOperation("fulfillmentOrders.List").Get(url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -42,7 +42,7 @@ func (repository fulfillmentRepository) Create(fulfillment shopify.Fulfillment)
 
 	url := repository.createURL("fulfillments.json")
 
-	respBody, _, err := repository.client.Post(url, body, nil)
+	respBody, _, err := repository.client.WithOperation("fulfillments.Create").Post(url, body, nil)
 	if err != nil {
 		return shopify.Fulfillment{}, err
 	}
@@ -78,7 +78,7 @@ func (repository fulfillmentRepository) UpdateTracking(update shopify.Fulfillmen
 
 	url := repository.createURL(fmt.Sprintf("fulfillments/%d/update_tracking.json", update.ID))
 
-	respBody, _, err := repository.client.Post(url, body, nil)
+	respBody, _, err := repository.client.WithOperation("fulfillments.UpdateTracking").Post(url, body, nil)
 	if err != nil {
 		return shopify.Fulfillment{}, err
 	}
@@ -98,7 +98,7 @@ func (repository fulfillmentRepository) UpdateTracking(update shopify.Fulfillmen
 func (repository fulfillmentRepository) Cancel(id int64) error {
 	url := repository.createURL(fmt.Sprintf("fulfillments/%d/cancel.json", id))
 
-	_, _, err := repository.client.Post(url, nil, nil)
+	_, _, err := repository.client.WithOperation("fulfillments.Cancel").Post(url, nil, nil)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,392 @@
+package httpshopify
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MOHC-LTD/httpshopify/v2/internal/http"
+)
+
+// DefaultGraphQLQueryCost is the cost assumed for a query before Shopify has reported what it costs
+const DefaultGraphQLQueryCost = 50
+
+// DefaultGraphQLThrottleRetries is how many times a query that Shopify throttles is retried
+const DefaultGraphQLThrottleRetries = 3
+
+// graphQLCostCacheSize is how many queries the cost is remembered for, so that queries built dynamically do not grow the cache without bound
+const graphQLCostCacheSize = 1024
+
+// GraphQLThrottleStatus is the state of the shop's GraphQL cost bucket
+type GraphQLThrottleStatus struct {
+	// MaximumAvailable is the size of the bucket
+	MaximumAvailable float64 `json:"maximumAvailable"`
+	// CurrentlyAvailable is the cost that can be spent right now
+	CurrentlyAvailable float64 `json:"currentlyAvailable"`
+	// RestoreRate is the cost restored to the bucket each second
+	RestoreRate float64 `json:"restoreRate"`
+}
+
+// GraphQLCost is the cost of a query reported by Shopify
+type GraphQLCost struct {
+	// RequestedQueryCost is the cost Shopify estimated for the query before running it
+	RequestedQueryCost float64 `json:"requestedQueryCost"`
+	// ActualQueryCost is the cost the query was charged, which is nil when it was throttled
+	ActualQueryCost *float64 `json:"actualQueryCost"`
+	// ThrottleStatus is the state of the bucket after the query
+	ThrottleStatus GraphQLThrottleStatus `json:"throttleStatus"`
+}
+
+// GraphQLError is an error returned in the errors of a GraphQL response
+type GraphQLError struct {
+	Message    string                     `json:"message"`
+	Path       []any                      `json:"path,omitempty"`
+	Locations  []GraphQLErrorLocation     `json:"locations,omitempty"`
+	Extensions map[string]json.RawMessage `json:"extensions,omitempty"`
+}
+
+// GraphQLErrorLocation is the location in the query that an error is for
+type GraphQLErrorLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Code returns the code in the extensions of the error e.g. THROTTLED, or an empty string when it has none
+func (err GraphQLError) Code() string {
+	var code string
+	json.Unmarshal(err.Extensions["code"], &code)
+
+	return code
+}
+
+// GraphQLUserError is an error with the input of a mutation, returned in its userErrors
+type GraphQLUserError struct {
+	// Field is the path to the input field the error is for
+	Field   []string `json:"field"`
+	Message string   `json:"message"`
+	Code    string   `json:"code,omitempty"`
+}
+
+// GraphQLClient sends queries to the GraphQL Admin API of a shop.
+/*
+	Queries share the token, transport, retries, circuit breaker, metrics and tracing of the shop. Rather than the
+	REST rate limit, they are throttled by the cost bucket Shopify reports in the extensions of each response, and
+	are retried when Shopify throttles them anyway.
+
+	Mutations are sent once rather than retried on server errors, as a mutation that fails with a server error may
+	still have run. They are only resent when Shopify throttles them, as a throttled mutation does not run.
+
+	Queries do not wait on the REST rate limit, so the priority of a shop from Shop.WithPriority does not apply
+	to them. They wait on the cost bucket in the order they are sent.
+	Example:
+	var data struct {
+		Metaobject struct {
+			Handle string `json:"handle"`
+		} `json:"metaobject"`
+	}
+	err := shop.GraphQL().Query(`query($id: ID!) { metaobject(id: $id) { handle } }`, map[string]any{"id": id}, &data)
+*/
+type GraphQLClient struct {
+	client   http.Client
+	url      string
+	throttle *graphQLThrottle
+}
+
+func newGraphQLClient(client http.Client, createURL func(endpoint string) string, throttle *graphQLThrottle) GraphQLClient {
+	return GraphQLClient{
+		client.WithoutLimiter(),
+		createURL("graphql.json"),
+		throttle,
+	}
+}
+
+// Query sends the query with its variables and decodes the data of the response into the value pointed to by data.
+/*
+	Errors in the response are returned as ErrGraphQL. When a mutation in the query returns userErrors, the data is
+	still decoded and ErrGraphQLUserErrors is returned. Data may be nil when the response is not needed.
+
+	Mutations are not retried on server errors.
+*/
+func (client GraphQLClient) Query(query string, variables map[string]any, data any) error {
+	request := struct {
+		Query     string         `json:"query"`
+		Variables map[string]any `json:"variables,omitempty"`
+	}{
+		Query:     query,
+		Variables: variables,
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	httpClient := client.client
+	if mutation(query) {
+		httpClient = httpClient.WithoutRetries()
+	}
+
+	for attempt := 0; ; attempt++ {
+		client.throttle.wait(query)
+
+		respBody, _, err := httpClient.Post(client.url, body, nil)
+		if err != nil {
+			return err
+		}
+
+		var response struct {
+			Data       json.RawMessage `json:"data"`
+			Errors     []GraphQLError  `json:"errors"`
+			Extensions struct {
+				Cost *GraphQLCost `json:"cost"`
+			} `json:"extensions"`
+		}
+
+		err = json.Unmarshal(respBody, &response)
+		if err != nil {
+			return err
+		}
+
+		if response.Extensions.Cost != nil {
+			client.throttle.observe(query, *response.Extensions.Cost)
+		}
+
+		if len(response.Errors) > 0 {
+			if throttledQuery(response.Errors) && attempt < DefaultGraphQLThrottleRetries {
+				continue
+			}
+
+			return NewErrGraphQL(response.Errors)
+		}
+
+		if data != nil && len(response.Data) > 0 {
+			err = json.Unmarshal(response.Data, data)
+			if err != nil {
+				return err
+			}
+		}
+
+		return userErrors(response.Data)
+	}
+}
+
+// ThrottleStatus returns the state of the shop's cost bucket last reported by Shopify
+func (client GraphQLClient) ThrottleStatus() GraphQLThrottleStatus {
+	return client.throttle.available()
+}
+
+// QueryGraphQL sends the query with its variables, decoding the data of the response into a T
+func QueryGraphQL[T any](client GraphQLClient, query string, variables map[string]any) (T, error) {
+	var data T
+	err := client.Query(query, variables, &data)
+
+	return data, err
+}
+
+// throttledQuery returns whether Shopify rejected the query because the cost bucket was empty
+func throttledQuery(errs []GraphQLError) bool {
+	for _, err := range errs {
+		if err.Code() == "THROTTLED" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// mutationDefinition matches the start of a mutation definition in a GraphQL document
+var mutationDefinition = regexp.MustCompile(`(^|\})\s*mutation\b`)
+
+// mutation returns whether the query defines a mutation, ignoring comments
+func mutation(query string) bool {
+	lines := strings.Split(query, "\n")
+	for i, line := range lines {
+		line, _, _ = strings.Cut(line, "#")
+		lines[i] = line
+	}
+
+	return mutationDefinition.MatchString(strings.TrimSpace(strings.Join(lines, "\n")))
+}
+
+// userErrors returns the userErrors of the mutations at the top level of the data, or nil when there are none
+func userErrors(data json.RawMessage) error {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(data, &fields) != nil {
+		return nil
+	}
+
+	found := make(map[string][]GraphQLUserError)
+	for name, field := range fields {
+		var payload struct {
+			UserErrors []GraphQLUserError `json:"userErrors"`
+		}
+
+		if json.Unmarshal(field, &payload) == nil && len(payload.UserErrors) > 0 {
+			found[name] = payload.UserErrors
+		}
+	}
+
+	if len(found) == 0 {
+		return nil
+	}
+
+	return NewErrGraphQLUserErrors(found)
+}
+
+// graphQLThrottle tracks the cost bucket of a shop so that queries wait until there is room for them
+type graphQLThrottle struct {
+	mutex     sync.Mutex
+	status    GraphQLThrottleStatus
+	updatedAt time.Time
+	costs     map[[sha256.Size]byte]float64
+	now       func() time.Time
+	sleep     func(duration time.Duration)
+}
+
+func newGraphQLThrottle() *graphQLThrottle {
+	return &graphQLThrottle{
+		costs: make(map[[sha256.Size]byte]float64),
+		now:   time.Now,
+		sleep: time.Sleep,
+	}
+}
+
+// wait blocks until the bucket has room for the cost of the query, then takes it
+func (throttle *graphQLThrottle) wait(query string) {
+	for {
+		throttle.mutex.Lock()
+
+		// Nothing is known about the bucket until Shopify has reported it
+		if throttle.status.MaximumAvailable == 0 || throttle.status.RestoreRate <= 0 {
+			throttle.mutex.Unlock()
+			return
+		}
+
+		cost, ok := throttle.costs[sha256.Sum256([]byte(query))]
+		if !ok {
+			cost = DefaultGraphQLQueryCost
+		}
+
+		// A query can never cost more than the bucket holds
+		cost = math.Min(cost, throttle.status.MaximumAvailable)
+
+		available := throttle.restored()
+		if available >= cost {
+			throttle.status.CurrentlyAvailable = available - cost
+			throttle.updatedAt = throttle.now()
+			throttle.mutex.Unlock()
+			return
+		}
+
+		wait := time.Duration((cost - available) / throttle.status.RestoreRate * float64(time.Second))
+		throttle.mutex.Unlock()
+
+		throttle.sleep(wait)
+	}
+}
+
+// observe replaces the state of the bucket with the one Shopify reported, and remembers the cost of the query by its hash
+func (throttle *graphQLThrottle) observe(query string, cost GraphQLCost) {
+	throttle.mutex.Lock()
+	defer throttle.mutex.Unlock()
+
+	throttle.status = cost.ThrottleStatus
+	throttle.updatedAt = throttle.now()
+
+	key := sha256.Sum256([]byte(query))
+
+	// Forget a query to make room, which falls back to the default cost if it is sent again
+	if _, ok := throttle.costs[key]; !ok && len(throttle.costs) >= graphQLCostCacheSize {
+		for forgotten := range throttle.costs {
+			delete(throttle.costs, forgotten)
+			break
+		}
+	}
+
+	if cost.ActualQueryCost != nil {
+		throttle.costs[key] = *cost.ActualQueryCost
+	} else {
+		throttle.costs[key] = cost.RequestedQueryCost
+	}
+}
+
+// available returns the state of the bucket with the cost restored since it was last updated
+func (throttle *graphQLThrottle) available() GraphQLThrottleStatus {
+	throttle.mutex.Lock()
+	defer throttle.mutex.Unlock()
+
+	status := throttle.status
+	status.CurrentlyAvailable = throttle.restored()
+
+	return status
+}
+
+// restored returns the cost available now. The mutex must be held.
+func (throttle *graphQLThrottle) restored() float64 {
+	elapsed := throttle.now().Sub(throttle.updatedAt).Seconds()
+
+	return math.Min(throttle.status.MaximumAvailable, throttle.status.CurrentlyAvailable+elapsed*throttle.status.RestoreRate)
+}
+
+// ErrGraphQL is thrown when a GraphQL response has errors
+type ErrGraphQL struct {
+	Errors []GraphQLError
+}
+
+func (err ErrGraphQL) Error() string {
+	messages := make([]string, 0, len(err.Errors))
+	for _, graphQLErr := range err.Errors {
+		messages = append(messages, graphQLErr.Message)
+	}
+
+	return fmt.Sprintf("graphql: %v", strings.Join(messages, "; "))
+}
+
+// NewErrGraphQL builds the error
+func NewErrGraphQL(errs []GraphQLError) ErrGraphQL {
+	return ErrGraphQL{
+		errs,
+	}
+}
+
+// ErrGraphQLUserErrors is thrown when mutations return userErrors
+type ErrGraphQLUserErrors struct {
+	// UserErrors are the errors of each mutation by the name of its field in the data
+	UserErrors map[string][]GraphQLUserError
+}
+
+func (err ErrGraphQLUserErrors) Error() string {
+	mutations := make([]string, 0, len(err.UserErrors))
+	for mutation := range err.UserErrors {
+		mutations = append(mutations, mutation)
+	}
+
+	sort.Strings(mutations)
+
+	messages := make([]string, 0)
+	for _, mutation := range mutations {
+		for _, userErr := range err.UserErrors[mutation] {
+			if len(userErr.Field) == 0 {
+				messages = append(messages, fmt.Sprintf("%v: %v", mutation, userErr.Message))
+				continue
+			}
+
+			messages = append(messages, fmt.Sprintf("%v: %v %v", mutation, strings.Join(userErr.Field, "."), userErr.Message))
+		}
+	}
+
+	return fmt.Sprintf("graphql user errors: %v", strings.Join(messages, "; "))
+}
+
+// NewErrGraphQLUserErrors builds the error
+func NewErrGraphQLUserErrors(userErrors map[string][]GraphQLUserError) ErrGraphQLUserErrors {
+	return ErrGraphQLUserErrors{
+		userErrors,
+	}
+}
@@ -0,0 +1,198 @@
+package httpshopify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MOHC-LTD/httpshopify/v2/internal/assertions"
+)
+
+func newGraphQLTestServer(t *testing.T, responses ...string) (*httptest.Server, *int) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/admin/api/2024-01/graphql.json" {
+			assertions.ValueAssertionFailure(t, "/admin/api/2024-01/graphql.json", r.URL.Path)
+		}
+
+		if r.Header.Get("X-Shopify-Access-Token") != "token" {
+			assertions.ValueAssertionFailure(t, "token", r.Header.Get("X-Shopify-Access-Token"))
+		}
+
+		w.Write([]byte(responses[requests]))
+		requests++
+	}))
+
+	return server, &requests
+}
+
+// Tests that a query is sent with its variables and its data is decoded
+func TestGraphQLClient_Query(t *testing.T) {
+	var variables map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		var request struct {
+			Variables map[string]any `json:"variables"`
+		}
+		json.Unmarshal(body, &request)
+		variables = request.Variables
+
+		w.Write([]byte(`{
+			"data":{"metaobject":{"handle":"summer"}},
+			"extensions":{"cost":{"requestedQueryCost":2,"actualQueryCost":1,"throttleStatus":{"maximumAvailable":2000,"currentlyAvailable":1999,"restoreRate":100}}}
+		}`))
+	}))
+	defer server.Close()
+
+	shop := NewCustomShop(server.URL+"/admin/api/2024-01", "token", IsDefault)
+
+	type data struct {
+		Metaobject struct {
+			Handle string `json:"handle"`
+		} `json:"metaobject"`
+	}
+
+	result, err := QueryGraphQL[data](shop.GraphQL(), `query($id: ID!) { metaobject(id: $id) { handle } }`, map[string]any{"id": "gid://shopify/Metaobject/1"})
+	if err != nil {
+		assertions.ErrAssertionFailure(t, err)
+	}
+
+	if result.Metaobject.Handle != "summer" {
+		assertions.ValueAssertionFailure(t, "summer", result.Metaobject.Handle)
+	}
+
+	if variables["id"] != "gid://shopify/Metaobject/1" {
+		assertions.ValueAssertionFailure(t, "gid://shopify/Metaobject/1", variables["id"])
+	}
+
+	if status := shop.GraphQL().ThrottleStatus(); status.MaximumAvailable != 2000 {
+		assertions.ValueAssertionFailure(t, float64(2000), status.MaximumAvailable)
+	}
+}
+
+// Tests that a throttled query is retried once the bucket has room for it
+func TestGraphQLClient_Query_Throttled(t *testing.T) {
+	server, requests := newGraphQLTestServer(t,
+		`{"errors":[{"message":"Throttled","extensions":{"code":"THROTTLED"}}],"extensions":{"cost":{"requestedQueryCost":10,"actualQueryCost":null,"throttleStatus":{"maximumAvailable":1000,"currentlyAvailable":5,"restoreRate":1000}}}}`,
+		`{"data":{"shop":{"name":"Shop"}}}`,
+	)
+	defer server.Close()
+
+	shop := NewCustomShop(server.URL+"/admin/api/2024-01", "token", IsDefault)
+
+	err := shop.GraphQL().Query(`{ shop { name } }`, nil, nil)
+	if err != nil {
+		assertions.ErrAssertionFailure(t, err)
+	}
+
+	if *requests != 2 {
+		assertions.ValueAssertionFailure(t, 2, *requests)
+	}
+}
+
+// Tests that mutations are sent once on server errors while queries are retried
+func TestGraphQLClient_Query_MutationNotRetried(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	shop := NewCustomShop(server.URL+"/admin/api/2024-01", "token", IsDefault, WithExponentialBackoff(2, time.Millisecond, time.Millisecond))
+
+	err := shop.GraphQL().Query("# Tag a product\nmutation { tagsAdd(id: \"1\", tags: [\"a\"]) { node { id } } }", nil, nil)
+	if err == nil {
+		assertions.AssertionFailure(t, "expected an error")
+	}
+
+	if requests != 1 {
+		assertions.ValueAssertionFailure(t, 1, requests)
+	}
+
+	requests = 0
+
+	err = shop.GraphQL().Query(`{ shop { name } }`, nil, nil)
+	if err == nil {
+		assertions.AssertionFailure(t, "expected an error")
+	}
+
+	if requests != 3 {
+		assertions.ValueAssertionFailure(t, 3, requests)
+	}
+}
+
+// Tests that errors and userErrors are returned as typed errors
+func TestGraphQLClient_Query_Errors(t *testing.T) {
+	server, _ := newGraphQLTestServer(t,
+		`{"errors":[{"message":"Field 'nope' doesn't exist on type 'Shop'","extensions":{"code":"undefinedField"}}]}`,
+		`{"data":{"productCreate":{"product":null,"userErrors":[{"field":["title"],"message":"Title can't be blank"}]}}}`,
+	)
+	defer server.Close()
+
+	client := NewCustomShop(server.URL+"/admin/api/2024-01", "token", IsDefault).GraphQL()
+
+	err := client.Query(`{ shop { nope } }`, nil, nil)
+
+	var graphQLErr ErrGraphQL
+	if !errors.As(err, &graphQLErr) || graphQLErr.Errors[0].Code() != "undefinedField" {
+		assertions.ValueAssertionFailure(t, "undefinedField", err)
+	}
+
+	err = client.Query(`mutation { productCreate(input: {}) { product { id } userErrors { field message } } }`, nil, nil)
+
+	var userErr ErrGraphQLUserErrors
+	if !errors.As(err, &userErr) || userErr.UserErrors["productCreate"][0].Message != "Title can't be blank" {
+		assertions.ValueAssertionFailure(t, "Title can't be blank", err)
+	}
+}
+
+// Tests that a query waits for the cost it was last charged to be restored
+func TestGraphQLThrottle_Wait(t *testing.T) {
+	throttle := newGraphQLThrottle()
+
+	now := time.Now()
+	throttle.now = func() time.Time { return now }
+
+	slept := time.Duration(0)
+	throttle.sleep = func(duration time.Duration) {
+		slept += duration
+		now = now.Add(duration)
+	}
+
+	actual := float64(30)
+	throttle.observe("query", GraphQLCost{
+		RequestedQueryCost: 50,
+		ActualQueryCost:    &actual,
+		ThrottleStatus:     GraphQLThrottleStatus{MaximumAvailable: 1000, CurrentlyAvailable: 10, RestoreRate: 50},
+	})
+
+	throttle.wait("query")
+
+	if slept != 400*time.Millisecond {
+		assertions.ValueAssertionFailure(t, 400*time.Millisecond, slept)
+	}
+
+	if status := throttle.available(); status.CurrentlyAvailable != 0 {
+		assertions.ValueAssertionFailure(t, float64(0), status.CurrentlyAvailable)
+	}
+}
+
+// Tests that the costs of dynamically built queries are not remembered without bound
+func TestGraphQLThrottle_CostCacheSize(t *testing.T) {
+	throttle := newGraphQLThrottle()
+
+	for i := 0; i < graphQLCostCacheSize+10; i++ {
+		throttle.observe(fmt.Sprintf(`{ product(id: "gid://shopify/Product/%d") { title } }`, i), GraphQLCost{RequestedQueryCost: 1})
+	}
+
+	if len(throttle.costs) != graphQLCostCacheSize {
+		assertions.ValueAssertionFailure(t, graphQLCostCacheSize, len(throttle.costs))
+	}
+}
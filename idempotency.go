@@ -0,0 +1,608 @@
+package httpshopify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MOHC-LTD/httpshopify/v2/internal/http"
+
+	"github.com/MOHC-LTD/shopify/v2"
+)
+
+// IdempotencyKeyAttribute is the name of the note attribute that marks an order with the idempotency key it was created with
+const IdempotencyKeyAttribute = "_idempotency_key"
+
+// DefaultIdempotencyTTL is how long the result of a create is remembered by default
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+// DefaultIdempotencyAttempts is how many times a create is attempted by default
+const DefaultIdempotencyAttempts = 3
+
+// DefaultIdempotencyReservation is how long a key is reserved for a create in progress by default, after which another create with the key may take it over
+const DefaultIdempotencyReservation = 5 * time.Minute
+
+// idempotencyPending is recorded under the lock of a key while a create with it is in progress
+const idempotencyPending = "pending"
+
+// idempotencyLockSuffix is added to a key to build the key of its lock
+const idempotencyLockSuffix = ":lock"
+
+// idempotencyAttempted prefixes the time a create started, which is recorded under its key until its result is. Results are recorded as JSON, so it cannot be mistaken for one.
+const idempotencyAttempted = "attempted:"
+
+// idempotencyClockSkew widens the window searched for a resource that may have been created, allowing for the clocks of Shopify and this machine to differ
+const idempotencyClockSkew = time.Minute
+
+// IdempotencyStore records the result of each create under its idempotency key.
+/*
+	MemoryWebhookStore and FileWebhookStore can be used as idempotency stores.
+	Implementations must be safe for concurrent use, and Add must be atomic so that only one create with a key
+	can reserve it.
+*/
+type IdempotencyStore interface {
+	// Add records the value under the key, returning false when the key is already recorded and has not expired
+	Add(key string, value string, expiresAt time.Time) (bool, error)
+	// Get returns the value recorded under the key and whether it is recorded and has not expired
+	Get(key string) (string, bool, error)
+	// Put records the value under the key, replacing any recorded value
+	Put(key string, value string, expiresAt time.Time) error
+	// Delete removes the key
+	Delete(key string) error
+}
+
+// IdempotencyOption configures an idempotent creator
+type IdempotencyOption func(creator *IdempotentCreator)
+
+// WithIdempotencyTTL configures how long the result of a create is remembered
+func WithIdempotencyTTL(ttl time.Duration) IdempotencyOption {
+	return func(creator *IdempotentCreator) {
+		creator.ttl = ttl
+	}
+}
+
+// WithIdempotencyReservation configures how long a key is reserved for a create in progress, which should be longer than a create can take
+func WithIdempotencyReservation(reservation time.Duration) IdempotencyOption {
+	return func(creator *IdempotentCreator) {
+		creator.reservation = reservation
+	}
+}
+
+// WithIdempotencyRetries configures how many times a create is attempted and how long to wait before the first retry, doubling for each retry after it
+func WithIdempotencyRetries(attempts int, wait time.Duration) IdempotencyOption {
+	return func(creator *IdempotentCreator) {
+		creator.attempts = attempts
+		creator.wait = wait
+	}
+}
+
+// IdempotentCreator creates resources at most once for each idempotency key.
+/*
+	Creating a resource is not safe to retry blindly. If the response to a create is lost, to a timeout or a 5XX
+	status, the resource may or may not exist. Before retrying, the creator looks for the resource it may have
+	created, and only creates it again when it is not found. Once created, the resource is recorded in the store
+	under its key so that creating it again with the same key returns it without a request.
+
+	Before creating, the key is reserved in the store. A create with a key that is reserved waits for the create
+	holding it and returns its result, or takes the key over when that create fails or its reservation expires.
+	A create that takes over a key whose earlier create may have made the resource looks for it before creating it.
+
+	Orders, fulfillments and transactions can be created idempotently, and CreateIdempotently makes other creates
+	idempotent.
+
+	The requests of the creator are not retried by the backoff of the shop, as the creator retries them itself.
+
+	Example:
+	creator := httpshopify.NewIdempotentCreator(shop, httpshopify.NewMemoryWebhookStore())
+	order, err := creator.CreateOrder("checkout-8213", order)
+*/
+type IdempotentCreator struct {
+	store        IdempotencyStore
+	ttl          time.Duration
+	reservation  time.Duration
+	attempts     int
+	wait         time.Duration
+	orders       orderRepository
+	fulfillments fulfillmentRepository
+	transactions transactionRepository
+	client       http.Client
+	createURL    func(endpoint string) string
+}
+
+// NewIdempotentCreator builds a creator for the shop that records its results in the store
+func NewIdempotentCreator(shop Shop, store IdempotencyStore, options ...IdempotencyOption) IdempotentCreator {
+	createURL := func(endpoint string) string {
+		return fmt.Sprintf("%v/%v", shop.url, endpoint)
+	}
+
+	client := shop.client.WithoutRetries()
+
+	creator := IdempotentCreator{
+		store:        store,
+		ttl:          DefaultIdempotencyTTL,
+		reservation:  DefaultIdempotencyReservation,
+		attempts:     DefaultIdempotencyAttempts,
+		wait:         time.Second,
+		orders:       newOrderRepository(client, createURL),
+		fulfillments: newFulfillmentRepository(client, createURL),
+		transactions: newTransactionRepository(client, createURL),
+		client:       shop.client,
+		createURL:    createURL,
+	}
+
+	for _, option := range options {
+		option(&creator)
+	}
+
+	return creator
+}
+
+// CreateOrder creates the order at most once for the key.
+/*
+	The order is marked with the key in a note attribute named IdempotencyKeyAttribute, which is used to find
+	the order when the response to creating it is lost.
+*/
+func (creator IdempotentCreator) CreateOrder(key string, order shopify.Order) (shopify.Order, error) {
+	attributes := make(shopify.NoteAttributes, 0, len(order.NoteAttributes)+1)
+	for _, attribute := range order.NoteAttributes {
+		if attribute.Name != IdempotencyKeyAttribute {
+			attributes = append(attributes, attribute)
+		}
+	}
+
+	order.NoteAttributes = append(attributes, shopify.NoteAttribute{Name: IdempotencyKeyAttribute, Value: key})
+
+	create := func() (shopify.Order, error) {
+		return creator.orders.Create(order)
+	}
+
+	find := func(since time.Time) (shopify.Order, bool, error) {
+		return creator.findOrder(key, since)
+	}
+
+	return CreateIdempotently(creator, "order:"+key, create, find)
+}
+
+// CreateFulfillment creates the fulfillment at most once for the key.
+/*
+	Fulfillments cannot be marked, so a fulfillment is found when the response to creating it is lost by looking
+	for one of its fulfillment orders that has a fulfillment created since the first attempt with the same
+	tracking number. A fulfillment without a tracking number cannot be found, so when the response to creating it
+	is lost ErrCreateAmbiguous is returned rather than creating it again.
+*/
+func (creator IdempotentCreator) CreateFulfillment(key string, fulfillment shopify.Fulfillment) (shopify.Fulfillment, error) {
+	create := func() (shopify.Fulfillment, error) {
+		return creator.fulfillments.Create(fulfillment)
+	}
+
+	find := func(since time.Time) (shopify.Fulfillment, bool, error) {
+		return creator.findFulfillment(fulfillment, since)
+	}
+
+	return CreateIdempotently(creator, "fulfillment:"+key, create, find)
+}
+
+// CreateTransaction creates the transaction on the order at most once for the key.
+/*
+	Transactions cannot be marked, so a transaction is found when the response to creating it is lost by looking
+	for a transaction of the order created since the first attempt with the same kind, parent and, when they are
+	set, amount and currency. Set the amount so that partial captures and refunds of the same parent can be told
+	apart.
+*/
+func (creator IdempotentCreator) CreateTransaction(key string, orderID int64, transaction shopify.Transaction) (shopify.Transaction, error) {
+	create := func() (shopify.Transaction, error) {
+		return creator.transactions.Create(orderID, transaction)
+	}
+
+	find := func(since time.Time) (shopify.Transaction, bool, error) {
+		return creator.findTransaction(orderID, transaction, since)
+	}
+
+	return CreateIdempotently(creator, "transaction:"+key, create, find)
+}
+
+// CreateIdempotently creates a resource at most once for the key using the creator's store and retries.
+/*
+	Use this to make other creates idempotent. create makes the resource and find looks for a resource that was
+	created since the time it is given, returning false when there is none. The resource is recorded in the store
+	as JSON.
+
+	Before the first attempt, the key is marked in the store with the time the create started. When a create may
+	have made the resource without it being recorded, because its response was lost, the process stopped or the
+	store failed, the mark is kept so that the next create with the key looks for the resource before creating it.
+*/
+func CreateIdempotently[T any](creator IdempotentCreator, key string, create func() (T, error), find func(since time.Time) (T, bool, error)) (T, error) {
+	var result T
+
+	stored, err := creator.reserve(key)
+	if err != nil {
+		return result, err
+	}
+
+	if stored != "" && !strings.HasPrefix(stored, idempotencyAttempted) {
+		err = json.Unmarshal([]byte(stored), &result)
+		return result, creator.release(key, err)
+	}
+
+	// Look for the resource first when an earlier create may have made it
+	since, resumed := attemptedSince(stored)
+	if !resumed {
+		since = time.Now().Add(-idempotencyClockSkew)
+
+		err = creator.store.Put(key, idempotencyAttempted+since.Format(time.RFC3339Nano), time.Now().Add(creator.ttl))
+		if err != nil {
+			return result, creator.release(key, err)
+		}
+	}
+
+	result, err = createOnce(creator, key, since, resumed, create, find)
+	if err != nil {
+		var ambiguousErr ErrCreateAmbiguous
+		if !resumed && !errors.As(err, &ambiguousErr) {
+			// Nothing was created, so a later create with the key does not need to look for it
+			deleteErr := creator.store.Delete(key)
+			if deleteErr != nil {
+				err = errors.Join(err, deleteErr)
+			}
+		}
+
+		return result, creator.release(key, err)
+	}
+
+	err = creator.record(key, result)
+	if err != nil {
+		return result, creator.release(key, NewErrCreateNotRecorded(key, err))
+	}
+
+	return result, creator.release(key, nil)
+}
+
+// reserve takes the lock of the key so that only one create with it runs at a time, returning what is recorded under the key.
+/*
+	While another create holds the lock, reserve waits for it to record its result or give the lock up. The lock
+	expires after the reservation, so a create that stopped without releasing it does not hold the key forever.
+*/
+func (creator IdempotentCreator) reserve(key string) (string, error) {
+	for {
+		stored, ok, err := creator.store.Get(key)
+		if err != nil {
+			return "", err
+		}
+
+		// A recorded result is returned without waiting for the lock
+		if ok && !strings.HasPrefix(stored, idempotencyAttempted) {
+			return stored, nil
+		}
+
+		reserved, err := creator.store.Add(key+idempotencyLockSuffix, idempotencyPending, time.Now().Add(creator.reservation))
+		if err != nil {
+			return "", err
+		}
+
+		if reserved {
+			// The create holding the lock before may have recorded its result since it was looked up
+			stored, _, err = creator.store.Get(key)
+			if err != nil {
+				return "", creator.release(key, err)
+			}
+
+			return stored, nil
+		}
+
+		time.Sleep(creator.wait)
+	}
+}
+
+// release gives up the lock of the key, returning the error along with any error giving it up
+func (creator IdempotentCreator) release(key string, err error) error {
+	releaseErr := creator.store.Delete(key + idempotencyLockSuffix)
+	if releaseErr != nil {
+		return errors.Join(err, releaseErr)
+	}
+
+	return err
+}
+
+// attemptedSince returns the time an earlier create with the key started, and whether the value records one
+func attemptedSince(stored string) (time.Time, bool) {
+	value, found := strings.CutPrefix(stored, idempotencyAttempted)
+	if !found {
+		return time.Time{}, false
+	}
+
+	since, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		// Look back as far as a result is remembered when the time cannot be read
+		return time.Now().Add(-DefaultIdempotencyTTL), true
+	}
+
+	return since, true
+}
+
+// createOnce makes the attempts of a create whose key is reserved, looking for the resource after each attempt that may have created it.
+/*
+	When resumed, an earlier create may have made the resource, so it is looked for before the first attempt.
+*/
+func createOnce[T any](creator IdempotentCreator, key string, since time.Time, resumed bool, create func() (T, error), find func(since time.Time) (T, bool, error)) (T, error) {
+	var result T
+
+	if resumed {
+		found, ok, err := find(since)
+		if err != nil {
+			return result, NewErrCreateAmbiguous(key, err)
+		}
+
+		if ok {
+			return found, nil
+		}
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < creator.attempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(creator.wait * (1 << (attempt - 1)))
+		}
+
+		result, lastErr = create()
+		if lastErr == nil {
+			return result, nil
+		}
+
+		if throttled(lastErr) {
+			continue
+		}
+
+		if !ambiguous(lastErr) {
+			return result, lastErr
+		}
+
+		found, ok, err := find(since)
+		if err != nil {
+			return result, NewErrCreateAmbiguous(key, errors.Join(lastErr, err))
+		}
+
+		if ok {
+			return found, nil
+		}
+	}
+
+	if ambiguous(lastErr) {
+		return result, NewErrCreateAmbiguous(key, lastErr)
+	}
+
+	return result, lastErr
+}
+
+// record stores the result under the key, retrying when the store fails
+func (creator IdempotentCreator) record(key string, result any) error {
+	value, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; attempt < creator.attempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(creator.wait * (1 << (attempt - 1)))
+		}
+
+		err = creator.store.Put(key, string(value), time.Now().Add(creator.ttl))
+		if err == nil {
+			return nil
+		}
+	}
+
+	return err
+}
+
+// findOrder looks for an order created since the time that is marked with the key
+func (creator IdempotentCreator) findOrder(key string, since time.Time) (shopify.Order, bool, error) {
+	query := url.Values{}
+	query.Set("status", "any")
+	query.Set("limit", "250")
+	query.Set("created_at_min", since.Format(time.RFC3339))
+
+	url := creator.createURL(fmt.Sprintf("orders.json?%v", query.Encode()))
+
+	for {
+		body, headers, err := creator.client.Get(url, nil)
+		if err != nil {
+			return shopify.Order{}, false, err
+		}
+
+		var resultDTO struct {
+			Orders []OrderDTO `json:"orders"`
+		}
+
+		err = json.Unmarshal(body, &resultDTO)
+		if err != nil {
+			return shopify.Order{}, false, err
+		}
+
+		for _, dto := range resultDTO.Orders {
+			for _, attribute := range dto.NoteAttributes {
+				if attribute.Name == IdempotencyKeyAttribute && attribute.Value == key {
+					return dto.ToShopify(), true, nil
+				}
+			}
+		}
+
+		links := ParseLinkHeader(headers.Get("Link"))
+
+		if !links.HasNext() {
+			return shopify.Order{}, false, nil
+		}
+
+		url = links.Next
+	}
+}
+
+// findFulfillment looks for a fulfillment of the fulfillment's fulfillment orders created since the time with the same tracking number
+func (creator IdempotentCreator) findFulfillment(fulfillment shopify.Fulfillment, since time.Time) (shopify.Fulfillment, bool, error) {
+	// Without a tracking number any fulfillment created in the window would match
+	if fulfillment.TrackingNumber == "" {
+		return shopify.Fulfillment{}, false, ErrFulfillmentUnidentifiable
+	}
+
+	for _, fulfillmentOrder := range fulfillment.LineItemsByFulfillmentOrder {
+		url := creator.createURL(fmt.Sprintf("fulfillment_orders/%d/fulfillments.json", fulfillmentOrder.ID))
+
+		body, _, err := creator.client.Get(url, nil)
+		if err != nil {
+			return shopify.Fulfillment{}, false, err
+		}
+
+		var resultDTO struct {
+			Fulfillments FulfillmentDTOs `json:"fulfillments"`
+		}
+
+		err = json.Unmarshal(body, &resultDTO)
+		if err != nil {
+			return shopify.Fulfillment{}, false, err
+		}
+
+		for _, dto := range resultDTO.Fulfillments {
+			if dto.CreatedAt == nil || dto.CreatedAt.Before(since) {
+				continue
+			}
+
+			if dto.TrackingNumber != fulfillment.TrackingNumber {
+				continue
+			}
+
+			return dto.ToShopify(), true, nil
+		}
+	}
+
+	return shopify.Fulfillment{}, false, nil
+}
+
+// findTransaction looks for a transaction of the order created since the time that matches the transaction
+func (creator IdempotentCreator) findTransaction(orderID int64, transaction shopify.Transaction, since time.Time) (shopify.Transaction, bool, error) {
+	url := creator.createURL(fmt.Sprintf("orders/%v/transactions.json", orderID))
+
+	body, _, err := creator.client.Get(url, nil)
+	if err != nil {
+		return shopify.Transaction{}, false, err
+	}
+
+	var resultDTO struct {
+		Transactions TransactionDTOs `json:"transactions"`
+	}
+
+	err = json.Unmarshal(body, &resultDTO)
+	if err != nil {
+		return shopify.Transaction{}, false, err
+	}
+
+	for _, dto := range resultDTO.Transactions {
+		if dto.CreatedAt == nil || dto.CreatedAt.Before(since) {
+			continue
+		}
+
+		if dto.Kind != transaction.Kind || dto.ParentID != transaction.ParentID {
+			continue
+		}
+
+		if transaction.Amount != "" && !sameAmount(dto.Amount, transaction.Amount) {
+			continue
+		}
+
+		if transaction.Currency != "" && !strings.EqualFold(dto.Currency, transaction.Currency) {
+			continue
+		}
+
+		return dto.ToShopify(), true, nil
+	}
+
+	return shopify.Transaction{}, false, nil
+}
+
+// sameAmount returns whether the decimal amounts are equal, allowing for them to be written differently e.g. 10 and 10.00
+func sameAmount(a string, b string) bool {
+	x, errA := strconv.ParseFloat(a, 64)
+	y, errB := strconv.ParseFloat(b, 64)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+
+	return x == y
+}
+
+// throttled returns whether the request was rejected by the rate limit, in which case it was not processed and can be retried
+func throttled(err error) bool {
+	var httpErr http.ErrHTTP
+
+	return errors.As(err, &httpErr) && httpErr.Code == 429
+}
+
+// ambiguous returns whether the request may have been processed despite failing
+func ambiguous(err error) bool {
+	var httpErr http.ErrHTTP
+	if errors.As(err, &httpErr) {
+		return httpErr.Code >= 500
+	}
+
+	var scopeErr ErrScopeForbidden
+	var circuitErr ErrCircuitOpen
+
+	return !errors.As(err, &scopeErr) && !errors.As(err, &circuitErr)
+}
+
+// ErrFulfillmentUnidentifiable is thrown when a fulfillment whose create may have succeeded cannot be looked for, as it has no tracking number
+var ErrFulfillmentUnidentifiable = errors.New("fulfillment has no tracking number to find it by")
+
+// ErrCreateNotRecorded is thrown when a resource was created but recording it in the store failed.
+/*
+	The result of the create is returned along with the error. The key stays marked as attempted, so a later create
+	with the key looks for the resource rather than creating it again.
+*/
+type ErrCreateNotRecorded struct {
+	// Key is the idempotency key of the create
+	Key string
+	// Err is the error of the store
+	Err error
+}
+
+func (err ErrCreateNotRecorded) Error() string {
+	return fmt.Sprintf("create with idempotency key %v succeeded but could not be recorded: %v", err.Key, err.Err)
+}
+
+func (err ErrCreateNotRecorded) Unwrap() error {
+	return err.Err
+}
+
+// NewErrCreateNotRecorded builds the error
+func NewErrCreateNotRecorded(key string, err error) ErrCreateNotRecorded {
+	return ErrCreateNotRecorded{
+		key,
+		err,
+	}
+}
+
+// ErrCreateAmbiguous is thrown when a create failed in a way that leaves it unknown whether the resource was created
+type ErrCreateAmbiguous struct {
+	// Key is the idempotency key of the create
+	Key string
+	// Err is the error of the last attempt
+	Err error
+}
+
+func (err ErrCreateAmbiguous) Error() string {
+	return fmt.Sprintf("create with idempotency key %v may or may not have succeeded: %v", err.Key, err.Err)
+}
+
+func (err ErrCreateAmbiguous) Unwrap() error {
+	return err.Err
+}
+
+// NewErrCreateAmbiguous builds the error
+func NewErrCreateAmbiguous(key string, err error) ErrCreateAmbiguous {
+	return ErrCreateAmbiguous{
+		key,
+		err,
+	}
+}
@@ -0,0 +1,371 @@
+package httpshopify
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MOHC-LTD/httpshopify/v2/internal/assertions"
+
+	"github.com/MOHC-LTD/shopify/v2"
+)
+
+// Tests that an order whose create response was lost is found by its marker rather than created again
+func TestIdempotentCreator_CreateOrder_LostResponse(t *testing.T) {
+	posts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			posts++
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+
+		if r.URL.Query().Get("status") != "any" {
+			assertions.ValueAssertionFailure(t, "any", r.URL.Query().Get("status"))
+		}
+
+		w.Write([]byte(`{"orders":[
+			{"id":1,"note_attributes":[{"name":"_idempotency_key","value":"other"}]},
+			{"id":2,"note_attributes":[{"name":"_idempotency_key","value":"checkout-1"}]}
+		]}`))
+	}))
+	defer server.Close()
+
+	shop := NewCustomShop(server.URL, "token", IsDefault, WithExponentialBackoff(3, time.Millisecond, time.Millisecond))
+	creator := NewIdempotentCreator(shop, NewMemoryWebhookStore(), WithIdempotencyRetries(3, time.Millisecond))
+
+	order, err := creator.CreateOrder("checkout-1", shopify.Order{Email: "a@b.com"})
+	if err != nil {
+		assertions.ErrAssertionFailure(t, err)
+	}
+
+	if order.ID != 2 {
+		assertions.ValueAssertionFailure(t, int64(2), order.ID)
+	}
+
+	if posts != 1 {
+		assertions.ValueAssertionFailure(t, 1, posts)
+	}
+}
+
+// Tests that creating with a key that has already been used returns the recorded order without a request
+func TestIdempotentCreator_CreateOrder_Recorded(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		body, _ := io.ReadAll(r.Body)
+
+		if !strings.Contains(string(body), `"_idempotency_key","value":"checkout-1"`) {
+			assertions.AssertionFailure(t, "expected the order to be marked with its key")
+		}
+
+		w.Write([]byte(`{"order":{"id":7}}`))
+	}))
+	defer server.Close()
+
+	shop := NewCustomShop(server.URL, "token", IsDefault)
+	creator := NewIdempotentCreator(shop, NewMemoryWebhookStore())
+
+	for i := 0; i < 2; i++ {
+		order, err := creator.CreateOrder("checkout-1", shopify.Order{})
+		if err != nil {
+			assertions.ErrAssertionFailure(t, err)
+		}
+
+		if order.ID != 7 {
+			assertions.ValueAssertionFailure(t, int64(7), order.ID)
+		}
+	}
+
+	if requests != 1 {
+		assertions.ValueAssertionFailure(t, 1, requests)
+	}
+}
+
+// Tests that a create rejected by Shopify is not retried
+func TestIdempotentCreator_CreateOrder_Rejected(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}))
+	defer server.Close()
+
+	shop := NewCustomShop(server.URL, "token", IsDefault)
+	creator := NewIdempotentCreator(shop, NewMemoryWebhookStore(), WithIdempotencyRetries(3, time.Millisecond))
+
+	_, err := creator.CreateOrder("checkout-1", shopify.Order{})
+
+	var ambiguousErr ErrCreateAmbiguous
+	if err == nil || errors.As(err, &ambiguousErr) {
+		assertions.ValueAssertionFailure(t, "422", err)
+	}
+
+	if requests != 1 {
+		assertions.ValueAssertionFailure(t, 1, requests)
+	}
+}
+
+// Tests that a fulfillment is created again when it is not found after a failure, and an error is returned once the attempts run out
+func TestIdempotentCreator_CreateFulfillment_NotFound(t *testing.T) {
+	posts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			posts++
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if r.URL.Path != "/fulfillment_orders/5/fulfillments.json" {
+			assertions.ValueAssertionFailure(t, "/fulfillment_orders/5/fulfillments.json", r.URL.Path)
+		}
+
+		w.Write([]byte(`{"fulfillments":[{"id":1,"tracking_number":"other","created_at":"2099-01-01T00:00:00Z"}]}`))
+	}))
+	defer server.Close()
+
+	shop := NewCustomShop(server.URL, "token", IsDefault)
+	creator := NewIdempotentCreator(shop, NewMemoryWebhookStore(), WithIdempotencyRetries(2, time.Millisecond))
+
+	fulfillment := shopify.Fulfillment{
+		TrackingNumber:              "1Z999",
+		LineItemsByFulfillmentOrder: shopify.FulfillmentOrders{{ID: 5}},
+	}
+
+	_, err := creator.CreateFulfillment("shipment-1", fulfillment)
+
+	var ambiguousErr ErrCreateAmbiguous
+	if !errors.As(err, &ambiguousErr) {
+		assertions.ValueAssertionFailure(t, ErrCreateAmbiguous{}, err)
+	}
+
+	if posts != 2 {
+		assertions.ValueAssertionFailure(t, 2, posts)
+	}
+}
+
+// Tests that concurrent creates with the same key create the order once and return the same order
+func TestIdempotentCreator_CreateOrder_Concurrent(t *testing.T) {
+	var mutex sync.Mutex
+	posts := 0
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		posts++
+		mutex.Unlock()
+
+		<-release
+		w.Write([]byte(`{"order":{"id":7}}`))
+	}))
+	defer server.Close()
+
+	shop := NewCustomShop(server.URL, "token", IsDefault)
+	store := NewMemoryWebhookStore()
+	creator := NewIdempotentCreator(shop, store, WithIdempotencyRetries(3, time.Millisecond))
+
+	results := make(chan shopify.Order, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			order, err := creator.CreateOrder("checkout-1", shopify.Order{})
+			if err != nil {
+				assertions.ErrAssertionFailure(t, err)
+			}
+			results <- order
+		}()
+	}
+
+	// Hold the first create until the second is waiting on its lock
+	waitFor(t, func() bool {
+		_, ok, _ := store.Get("order:checkout-1" + idempotencyLockSuffix)
+		return ok
+	})
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+
+	for i := 0; i < 2; i++ {
+		order := <-results
+		if order.ID != 7 {
+			assertions.ValueAssertionFailure(t, int64(7), order.ID)
+		}
+	}
+
+	if posts != 1 {
+		assertions.ValueAssertionFailure(t, 1, posts)
+	}
+}
+
+// Tests that a fulfillment without a tracking number is not created again when the response to creating it is lost, including by a later create with the key
+func TestIdempotentCreator_CreateFulfillment_NoTrackingNumber(t *testing.T) {
+	posts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			posts++
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Write([]byte(`{"fulfillments":[{"id":1,"created_at":"2099-01-01T00:00:00Z"}]}`))
+	}))
+	defer server.Close()
+
+	shop := NewCustomShop(server.URL, "token", IsDefault)
+	creator := NewIdempotentCreator(shop, NewMemoryWebhookStore(), WithIdempotencyRetries(2, time.Millisecond))
+
+	fulfillment := shopify.Fulfillment{
+		LineItemsByFulfillmentOrder: shopify.FulfillmentOrders{{ID: 5}},
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err := creator.CreateFulfillment("shipment-1", fulfillment)
+
+		var ambiguousErr ErrCreateAmbiguous
+		if !errors.As(err, &ambiguousErr) || !errors.Is(err, ErrFulfillmentUnidentifiable) {
+			assertions.ValueAssertionFailure(t, ErrFulfillmentUnidentifiable, err)
+		}
+	}
+
+	if posts != 1 {
+		assertions.ValueAssertionFailure(t, 1, posts)
+	}
+}
+
+// Tests that a create with a key whose earlier create was ambiguous looks for the resource before creating it
+func TestIdempotentCreator_CreateOrder_AfterAmbiguous(t *testing.T) {
+	posts := 0
+	failing := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			posts++
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+
+		// Shopify is failing while the first create runs, so the order it made cannot be found
+		if failing {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+
+		w.Write([]byte(`{"orders":[{"id":2,"note_attributes":[{"name":"_idempotency_key","value":"checkout-1"}]}]}`))
+	}))
+	defer server.Close()
+
+	shop := NewCustomShop(server.URL, "token", IsDefault)
+	creator := NewIdempotentCreator(shop, NewMemoryWebhookStore(), WithIdempotencyRetries(2, time.Millisecond))
+
+	_, err := creator.CreateOrder("checkout-1", shopify.Order{})
+
+	var ambiguousErr ErrCreateAmbiguous
+	if !errors.As(err, &ambiguousErr) {
+		assertions.ValueAssertionFailure(t, ErrCreateAmbiguous{}, err)
+	}
+
+	failing = false
+	sent := posts
+
+	order, err := creator.CreateOrder("checkout-1", shopify.Order{})
+	if err != nil {
+		assertions.ErrAssertionFailure(t, err)
+	}
+
+	if order.ID != 2 {
+		assertions.ValueAssertionFailure(t, int64(2), order.ID)
+	}
+
+	if posts != sent {
+		assertions.ValueAssertionFailure(t, sent, posts)
+	}
+}
+
+// resultFailingStore is a store that fails to record results
+type resultFailingStore struct {
+	*MemoryWebhookStore
+}
+
+func (store resultFailingStore) Put(key string, value string, expiresAt time.Time) error {
+	if !strings.HasPrefix(value, idempotencyAttempted) {
+		return errors.New("store is unavailable")
+	}
+
+	return store.MemoryWebhookStore.Put(key, value, expiresAt)
+}
+
+// Tests that a create that succeeds but cannot be recorded returns its result, and is found rather than created again by a later create with the key
+func TestIdempotentCreator_CreateOrder_NotRecorded(t *testing.T) {
+	posts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			posts++
+			w.Write([]byte(`{"order":{"id":7}}`))
+			return
+		}
+
+		w.Write([]byte(`{"orders":[{"id":7,"note_attributes":[{"name":"_idempotency_key","value":"checkout-1"}]}]}`))
+	}))
+	defer server.Close()
+
+	shop := NewCustomShop(server.URL, "token", IsDefault)
+	creator := NewIdempotentCreator(shop, resultFailingStore{NewMemoryWebhookStore()}, WithIdempotencyRetries(2, time.Millisecond))
+
+	for i := 0; i < 2; i++ {
+		order, err := creator.CreateOrder("checkout-1", shopify.Order{})
+
+		var notRecorded ErrCreateNotRecorded
+		if !errors.As(err, &notRecorded) {
+			assertions.ValueAssertionFailure(t, ErrCreateNotRecorded{}, err)
+		}
+
+		if order.ID != 7 {
+			assertions.ValueAssertionFailure(t, int64(7), order.ID)
+		}
+	}
+
+	if posts != 1 {
+		assertions.ValueAssertionFailure(t, 1, posts)
+	}
+}
+
+// Tests that a transaction whose create response was lost is found by its kind, parent and amount rather than created again
+func TestIdempotentCreator_CreateTransaction_LostResponse(t *testing.T) {
+	posts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/orders/4/transactions.json" {
+			assertions.ValueAssertionFailure(t, "/orders/4/transactions.json", r.URL.Path)
+		}
+
+		if r.Method == http.MethodPost {
+			posts++
+			w.WriteHeader(http.StatusGatewayTimeout)
+			return
+		}
+
+		w.Write([]byte(`{"transactions":[
+			{"id":1,"kind":"capture","parent_id":9,"amount":"5.00","created_at":"2099-01-01T00:00:00Z"},
+			{"id":2,"kind":"capture","parent_id":9,"amount":"10.00","created_at":"2099-01-01T00:00:00Z"}
+		]}`))
+	}))
+	defer server.Close()
+
+	shop := NewCustomShop(server.URL, "token", IsDefault)
+	creator := NewIdempotentCreator(shop, NewMemoryWebhookStore(), WithIdempotencyRetries(3, time.Millisecond))
+
+	transaction, err := creator.CreateTransaction("capture-1", 4, shopify.Transaction{Kind: "capture", ParentID: 9, Amount: "10"})
+	if err != nil {
+		assertions.ErrAssertionFailure(t, err)
+	}
+
+	if transaction.ID != 2 {
+		assertions.ValueAssertionFailure(t, int64(2), transaction.ID)
+	}
+
+	if posts != 1 {
+		assertions.ValueAssertionFailure(t, 1, posts)
+	}
+}
@@ -0,0 +1,32 @@
+package http
+
+// Breaker decides whether a request may be sent, so that requests fail fast while a service is down.
+type Breaker interface {
+	// Allow returns an error when the request must not be sent. Otherwise it returns a function that must be
+	// called with whether the request failed once its outcome is known.
+	Allow(method string, url string) (func(failed bool), error)
+	// Check returns an error when the request would not be allowed, without counting it as an attempt.
+	Check(method string, url string) error
+}
+
+// OptionBreaker holds configuration for a circuit breaker around requests.
+type OptionBreaker struct {
+	breaker Breaker
+}
+
+func (option OptionBreaker) configure(client *Client) error {
+	client.breaker = option.breaker
+
+	return nil
+}
+
+// WithBreaker allows every attempt of a request, including retries, to be guarded by the breaker.
+/*
+	An attempt fails when it errors or Shopify responds with a 5XX status. The breaker is also checked before a
+	request waits on the limiter, so that requests fail fast while it is open instead of using up the rate limit.
+*/
+func WithBreaker(breaker Breaker) OptionBreaker {
+	return OptionBreaker{
+		breaker,
+	}
+}
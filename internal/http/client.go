@@ -7,34 +7,94 @@ import (
 	"math/rand"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
-
-	"golang.org/x/time/rate"
 )
 
 // Client is a HTTP client
 type Client struct {
 	client            *http.Client
 	defaultHeaders    RequestHeaders
-	limiter           *rate.Limiter
+	limiter           Limiter
 	retryMaxDuration  time.Duration
 	retryBaseDuration time.Duration
 	retryCount        int
+	tokenHeader       string
+	token             func() (string, error)
+	refreshToken      func(rejected string) (string, error)
+	mapError          func(method string, url string, err ErrHTTP) error
+	checkResponse     func(method string, url string, headers http.Header) error
+	priority          Priority
+	priorityReserve   int
+	scheduler         *scheduler
+	breaker           Breaker
+	metrics           Metrics
+	tracer            Tracer
+	span              Span
+	pages             *int32
+	operation         string
 }
 
 // NewClient builds a new HTTP client
 func NewClient(options ...Option) Client {
 	client := Client{
-		client: &http.Client{},
+		client:          &http.Client{},
+		priorityReserve: DefaultPriorityReserve,
 	}
 
 	for _, option := range options {
 		option.configure(&client)
 	}
 
+	if client.limiter != nil {
+		client.scheduler = newScheduler(client.limiter, client.priorityReserve)
+		client.limiter = client.scheduler
+	}
+
 	return client
 }
 
+// WithPriority returns a copy of the client that sends its requests with the priority.
+/*
+	Priorities above PriorityHigh or below PriorityLow are treated as PriorityHigh or PriorityLow.
+	The copy shares the limiter of the client, so its requests are ordered against the requests of the client.
+*/
+func (c Client) WithPriority(priority Priority) Client {
+	c.priority = priority.clamp()
+
+	return c
+}
+
+// WithoutRetries returns a copy of the client that sends each request once.
+/*
+	Requests that are not safe to repeat, such as creating a resource, can then decide for themselves whether to
+	retry after a failure.
+*/
+func (c Client) WithoutRetries() Client {
+	c.retryCount = 0
+
+	return c
+}
+
+// WithoutLimiter returns a copy of the client whose requests do not wait for the limiter.
+/*
+	Requests that are limited by other means, such as by the cost of a GraphQL query, can then do their own waiting.
+*/
+func (c Client) WithoutLimiter() Client {
+	c.limiter = nil
+
+	return c
+}
+
+// QueueDepth returns the number of requests waiting for the limiter in each priority class
+func (c Client) QueueDepth() QueueDepth {
+	if c.scheduler == nil {
+		return QueueDepth{}
+	}
+
+	return c.scheduler.Depth()
+}
+
 // AppendDefaultHeaders appends the default headers to the passed ones.
 func (c Client) AppendDefaultHeaders(headers RequestHeaders) RequestHeaders {
 	for _, header := range c.defaultHeaders {
@@ -59,6 +119,29 @@ func (c Client) retryDuration(retry int) time.Duration {
 
 // Do does a request
 func (c Client) Do(method string, url string, headers RequestHeaders, body io.Reader) ([]byte, ResponseHeaders, error) {
+	// Trace a request made outside of an operation span as an operation of its own
+	if c.operation != "" && c.tracer != nil && c.pages == nil {
+		client, operation := c.StartOperation(c.operation, url)
+
+		responseBody, responseHeaders, err := client.Do(method, url, headers, body)
+
+		operation.End(err)
+
+		return responseBody, responseHeaders, err
+	}
+
+	span := c.startRequest(method, url)
+	c.span = span
+
+	responseBody, responseHeaders, err := c.do(method, url, headers, body)
+
+	span.End(err)
+
+	return responseBody, responseHeaders, err
+}
+
+// do does a request as a child of the client's span
+func (c Client) do(method string, url string, headers RequestHeaders, body io.Reader) ([]byte, ResponseHeaders, error) {
 	var requestBody []byte
 	var err error
 
@@ -69,27 +152,156 @@ func (c Client) Do(method string, url string, headers RequestHeaders, body io.Re
 		}
 	}
 
+	// Fail fast rather than waiting for the limiter when the request would not be sent anyway
+	if c.breaker != nil {
+		err = c.breaker.Check(method, url)
+		if err != nil {
+			return nil, ResponseHeaders{}, err
+		}
+	}
+
 	if c.limiter != nil {
-		ctx := context.Background()
-		c.limiter.Wait(ctx)
+		ctx := context.WithValue(context.Background(), priorityContextKey{}, c.priority)
+
+		waitStart := time.Now()
+		waitSpan := c.startSpan(SpanLimiterWait, method, url)
+
+		err = c.limiter.Wait(ctx)
+
+		waitSpan.End(err)
+
+		if c.metrics != nil {
+			c.metrics.Wait(method, url, time.Since(waitStart))
+		}
+
+		if err != nil {
+			return nil, ResponseHeaders{}, err
+		}
 	}
 
 	headers = c.AppendDefaultHeaders(headers)
 
+	var token string
+	if c.token != nil {
+		token, err = c.token()
+		if err != nil {
+			return nil, ResponseHeaders{}, err
+		}
+	}
+
+	resp, err := c.send(method, url, c.withToken(headers, token), requestBody)
+	if err != nil {
+		return nil, ResponseHeaders{}, err
+	}
+
+	// Refresh a rejected token and try once more
+	if resp.StatusCode == http.StatusUnauthorized && c.refreshToken != nil {
+		refreshed, refreshErr := c.refreshToken(token)
+		if refreshErr == nil && refreshed != token {
+			resp.Body.Close()
+
+			resp, err = c.send(method, url, c.withToken(headers, refreshed), requestBody)
+			if err != nil {
+				return nil, ResponseHeaders{}, err
+			}
+		}
+	}
+
+	defer resp.Body.Close()
+
+	c.span.SetAttribute(AttributeStatus, resp.StatusCode)
+
+	responseBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, ResponseHeaders{}, err
+	}
+
+	if c.metrics != nil {
+		c.metrics.Received(method, url, len(responseBody))
+	}
+
+	if c.checkResponse != nil {
+		err = c.checkResponse(method, url, resp.Header)
+		if err != nil {
+			return nil, ResponseHeaders{}, err
+		}
+	}
+
+	err = HandleStatus(resp.StatusCode, responseBody)
+	if err != nil {
+		if httpErr, ok := err.(ErrHTTP); ok && c.mapError != nil {
+			err = c.mapError(method, url, httpErr)
+		}
+
+		return nil, ResponseHeaders{}, err
+	}
+
+	return responseBody, ResponseHeaders{resp.Header}, nil
+}
+
+// withToken sets the token header, replacing any default value
+func (c Client) withToken(headers RequestHeaders, token string) RequestHeaders {
+	if c.token == nil {
+		return headers
+	}
+
+	withToken := make(RequestHeaders, 0, len(headers)+1)
+	for _, header := range headers {
+		if !strings.EqualFold(header.Name, c.tokenHeader) {
+			withToken = append(withToken, header)
+		}
+	}
+
+	return append(withToken, RequestHeader{Name: c.tokenHeader, Value: token})
+}
+
+// send sends the request, retrying responses that indicate the request can be retried
+func (c Client) send(method string, url string, headers RequestHeaders, requestBody []byte) (*http.Response, error) {
 	var resp *http.Response
+	var err error
 
 	for i := 0; i < c.retryCount+1; i++ {
-		req, err := http.NewRequest(method, url, bytes.NewReader(requestBody))
+		var req *http.Request
+		req, err = http.NewRequest(method, url, bytes.NewReader(requestBody))
 		if err != nil {
-			return nil, ResponseHeaders{}, err
+			return nil, err
 		}
 
 		for _, header := range headers {
 			req.Header.Set(header.Name, header.Value)
 		}
 
+		var done func(failed bool)
+		if c.breaker != nil {
+			done, err = c.breaker.Allow(method, url)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		start := time.Now()
+		attemptSpan := c.startSpan(SpanAttempt, method, url)
+
 		resp, err = c.client.Do(req)
 
+		if err == nil {
+			attemptSpan.SetAttribute(AttributeStatus, resp.StatusCode)
+		}
+
+		attemptSpan.End(err)
+
+		if done != nil {
+			done(err != nil || resp.StatusCode >= 500)
+		}
+
+		if err == nil && c.limiter != nil {
+			observeLimit(c.limiter, resp)
+		}
+
+		if c.metrics != nil {
+			c.report(method, url, resp, err, time.Since(start), len(requestBody))
+		}
+
 		// Break if client config not set for retries
 		if c.retryCount == i {
 			break
@@ -99,8 +311,6 @@ func (c Client) Do(method string, url string, headers RequestHeaders, body io.Re
 		waitTime := c.retryDuration(i)
 
 		if err == nil {
-			defer resp.Body.Close()
-
 			isRetryResponse := resp.StatusCode == 429 || resp.StatusCode >= 500
 			if !isRetryResponse {
 				break
@@ -111,24 +321,43 @@ func (c Client) Do(method string, url string, headers RequestHeaders, body io.Re
 				retryAfter, _ := strconv.ParseFloat(retryAfterHeader, 64)
 				waitTime = time.Duration(retryAfter * float64(time.Second))
 			}
+
+			resp.Body.Close()
 		}
 
+		if c.metrics != nil {
+			c.metrics.Retry(method, url, status(resp, err))
+		}
+
+		sleepSpan := c.startSpan(SpanRetrySleep, method, url)
+		sleepSpan.SetAttribute(AttributeRetry, i+1)
+
 		time.Sleep(waitTime)
+
+		sleepSpan.End(nil)
 	}
 
+	return resp, err
+}
+
+// report tells the metrics about an attempt of a request
+func (c Client) report(method string, url string, resp *http.Response, err error, duration time.Duration, sent int) {
+	c.metrics.Request(method, url, status(resp, err), duration, sent)
+
 	if err != nil {
-		return nil, ResponseHeaders{}, err
+		return
 	}
 
-	responseBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, ResponseHeaders{}, err
+	if used, size := callLimit(resp); size > 0 {
+		c.metrics.Bucket(url, used, size)
 	}
+}
 
-	err = HandleStatus(resp.StatusCode, responseBody)
+// status returns the status of the response, or zero when the request failed without one
+func status(resp *http.Response, err error) int {
 	if err != nil {
-		return nil, ResponseHeaders{}, err
+		return 0
 	}
 
-	return responseBody, ResponseHeaders{resp.Header}, nil
+	return resp.StatusCode
 }
@@ -0,0 +1,23 @@
+package http
+
+// OptionErrorMapper holds configuration for mapping the errors of failed requests.
+type OptionErrorMapper struct {
+	mapError func(method string, url string, err ErrHTTP) error
+}
+
+func (option OptionErrorMapper) configure(client *Client) error {
+	client.mapError = option.mapError
+
+	return nil
+}
+
+// WithErrorMapper allows the errors of requests that fail with an error status to be replaced.
+/*
+	The mapper is passed the method and URL of the request along with the error, and returns the error to use instead.
+	It should return the error it was passed for statuses it does not handle.
+*/
+func WithErrorMapper(mapError func(method string, url string, err ErrHTTP) error) OptionErrorMapper {
+	return OptionErrorMapper{
+		mapError,
+	}
+}
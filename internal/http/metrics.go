@@ -0,0 +1,37 @@
+package http
+
+import (
+	"time"
+)
+
+// Metrics is told about the requests made by a client.
+type Metrics interface {
+	// Request is called for each attempt of a request, with a status of zero when no response was received
+	Request(method string, url string, status int, duration time.Duration, sent int)
+	// Received is called with the size of the body of each response that is read
+	Received(method string, url string, size int)
+	// Retry is called each time an attempt is retried, with a status of zero when no response was received
+	Retry(method string, url string, status int)
+	// Wait is called with how long each request waited for the limiter
+	Wait(method string, url string, wait time.Duration)
+	// Bucket is called with the call limit reported in the response to a request
+	Bucket(url string, used int, size int)
+}
+
+// OptionMetrics holds configuration for reporting the requests of a client.
+type OptionMetrics struct {
+	metrics Metrics
+}
+
+func (option OptionMetrics) configure(client *Client) error {
+	client.metrics = option.metrics
+
+	return nil
+}
+
+// WithMetrics allows the requests of the client to be reported to metrics.
+func WithMetrics(metrics Metrics) OptionMetrics {
+	return OptionMetrics{
+		metrics,
+	}
+}
@@ -1,6 +1,9 @@
 package http
 
-import "time"
+import (
+	"net/http"
+	"time"
+)
 
 // Option allows the client to be fully configurable
 type Option interface {
@@ -29,3 +32,23 @@ func WithBackoffOptions(retryCount int, retryBaseDuration time.Duration, retryMa
 		retryMaxDuration,
 	}
 }
+
+// OptionTransport holds configuration for the transport used to send requests.
+type OptionTransport struct {
+	transport http.RoundTripper
+}
+
+func (option OptionTransport) configure(client *Client) error {
+	client.client = &http.Client{
+		Transport: option.transport,
+	}
+
+	return nil
+}
+
+// WithTransport allows the transport, and so its connection pool, to be shared between clients.
+func WithTransport(transport http.RoundTripper) OptionTransport {
+	return OptionTransport{
+		transport,
+	}
+}
@@ -1,6 +1,34 @@
 package http
 
-import "golang.org/x/time/rate"
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Limiter decides when the client may send a request.
+type Limiter interface {
+	// Wait blocks until a request may be sent
+	Wait(ctx context.Context) error
+	// Observe reports the state of the bucket seen on a response. Used and size are zero when it was not reported,
+	// and retryAfter is zero unless the request was throttled.
+	Observe(used int, size int, retryAfter time.Duration)
+}
+
+// tokenBucket limits requests with a token bucket held in this process.
+type tokenBucket struct {
+	limiter *rate.Limiter
+}
+
+func (bucket tokenBucket) Wait(ctx context.Context) error {
+	return bucket.limiter.Wait(ctx)
+}
+
+func (bucket tokenBucket) Observe(used int, size int, retryAfter time.Duration) {}
 
 // OptionRateLimit holds configuration for rate limiting requests.
 type OptionRateLimit struct {
@@ -9,7 +37,7 @@ type OptionRateLimit struct {
 }
 
 func (option OptionRateLimit) configure(client *Client) error {
-	client.limiter = rate.NewLimiter(rate.Limit(option.rate), option.burst)
+	client.limiter = tokenBucket{rate.NewLimiter(rate.Limit(option.rate), option.burst)}
 
 	return nil
 }
@@ -24,3 +52,48 @@ func WithRateLimit(rate int, burst int) OptionRateLimit {
 		burst,
 	}
 }
+
+// OptionLimiter holds configuration for a custom limiter.
+type OptionLimiter struct {
+	limiter Limiter
+}
+
+func (option OptionLimiter) configure(client *Client) error {
+	client.limiter = option.limiter
+
+	return nil
+}
+
+// WithLimiter allows the requests of the client to be limited by a custom limiter, replacing any rate limit.
+func WithLimiter(limiter Limiter) OptionLimiter {
+	return OptionLimiter{
+		limiter,
+	}
+}
+
+// observeLimit reports the call limit and retry after headers of the response to the limiter
+func observeLimit(limiter Limiter, resp *http.Response) {
+	used, size := callLimit(resp)
+
+	var retryAfter time.Duration
+	if resp.StatusCode == http.StatusTooManyRequests {
+		seconds, _ := strconv.ParseFloat(resp.Header.Get("Retry-After"), 64)
+		retryAfter = time.Duration(seconds * float64(time.Second))
+	}
+
+	limiter.Observe(used, size, retryAfter)
+}
+
+// callLimit returns the used and size of the call limit header of the response, both zero when it is not set
+func callLimit(resp *http.Response) (int, int) {
+	var used, size int
+
+	// The header is formatted as used/size e.g. 32/40
+	if callLimit := resp.Header.Get("X-Shopify-Shop-Api-Call-Limit"); callLimit != "" {
+		usedPart, sizePart, _ := strings.Cut(callLimit, "/")
+		used, _ = strconv.Atoi(usedPart)
+		size, _ = strconv.Atoi(sizePart)
+	}
+
+	return used, size
+}
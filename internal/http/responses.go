@@ -0,0 +1,27 @@
+package http
+
+import (
+	"net/http"
+)
+
+// OptionResponseCheck holds configuration for checking the headers of responses.
+type OptionResponseCheck struct {
+	check func(method string, url string, headers http.Header) error
+}
+
+func (option OptionResponseCheck) configure(client *Client) error {
+	client.checkResponse = option.check
+
+	return nil
+}
+
+// WithResponseCheck allows the headers of the response to each request to be checked.
+/*
+	The check is passed the method and URL of the request along with the headers of its final response. The request
+	fails with the error it returns, if any.
+*/
+func WithResponseCheck(check func(method string, url string, headers http.Header) error) OptionResponseCheck {
+	return OptionResponseCheck{
+		check,
+	}
+}
@@ -0,0 +1,204 @@
+package http
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Priority is the priority class of a request. Higher priorities are sent first.
+type Priority int
+
+const (
+	// PriorityLow is for background work such as bulk jobs
+	PriorityLow Priority = -1
+	// PriorityNormal is the default priority
+	PriorityNormal Priority = 0
+	// PriorityHigh is for interactive work that a person is waiting on
+	PriorityHigh Priority = 1
+)
+
+// clamp returns the priority class closest to the priority, so that values outside the classes are still served
+func (priority Priority) clamp() Priority {
+	if priority > PriorityHigh {
+		return PriorityHigh
+	}
+
+	if priority < PriorityLow {
+		return PriorityLow
+	}
+
+	return priority
+}
+
+// priorities are the priority classes from highest to lowest
+var priorities = []Priority{PriorityHigh, PriorityNormal, PriorityLow}
+
+// DefaultPriorityReserve is how often a slot is given to a lower priority request when higher priority requests are waiting
+const DefaultPriorityReserve = 5
+
+type priorityContextKey struct{}
+
+// ContextPriority returns the priority of the request the context is for
+func ContextPriority(ctx context.Context) Priority {
+	priority, _ := ctx.Value(priorityContextKey{}).(Priority)
+
+	return priority
+}
+
+// QueueDepth is the number of requests waiting for the limiter in each priority class
+type QueueDepth map[Priority]int
+
+type schedulerWaiter struct {
+	ready chan struct{}
+}
+
+// scheduler orders the requests waiting for a limiter by priority.
+/*
+	Only one request waits on the limiter at a time. When it is let through, the next request is picked from the
+	highest priority class that has one waiting, except that every reserve-th slot is given to the lowest class
+	that has one waiting so that background work is never starved.
+*/
+type scheduler struct {
+	limiter Limiter
+	reserve int
+	mutex   sync.Mutex
+	queues  map[Priority][]*schedulerWaiter
+	busy    bool
+	granted int
+}
+
+func newScheduler(limiter Limiter, reserve int) *scheduler {
+	return &scheduler{
+		limiter: limiter,
+		reserve: reserve,
+		queues:  make(map[Priority][]*schedulerWaiter),
+	}
+}
+
+func (s *scheduler) Wait(ctx context.Context) error {
+	waiter := &schedulerWaiter{ready: make(chan struct{})}
+	priority := ContextPriority(ctx).clamp()
+
+	s.mutex.Lock()
+	s.queues[priority] = append(s.queues[priority], waiter)
+	s.dispatch()
+	s.mutex.Unlock()
+
+	select {
+	case <-waiter.ready:
+	case <-ctx.Done():
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		if s.remove(priority, waiter) {
+			return ctx.Err()
+		}
+
+		// The waiter was picked as it was cancelled, so pass the turn on
+		s.busy = false
+		s.dispatch()
+
+		return ctx.Err()
+	}
+
+	err := s.limiter.Wait(ctx)
+
+	s.mutex.Lock()
+	s.busy = false
+	s.dispatch()
+	s.mutex.Unlock()
+
+	return err
+}
+
+func (s *scheduler) Observe(used int, size int, retryAfter time.Duration) {
+	s.limiter.Observe(used, size, retryAfter)
+}
+
+// Depth returns the number of requests waiting in each priority class
+func (s *scheduler) Depth() QueueDepth {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	depth := make(QueueDepth, len(priorities))
+	for _, priority := range priorities {
+		depth[priority] = len(s.queues[priority])
+	}
+
+	return depth
+}
+
+// dispatch lets the next request wait on the limiter when none is. The mutex must be held.
+func (s *scheduler) dispatch() {
+	if s.busy {
+		return
+	}
+
+	priority, ok := s.next()
+	if !ok {
+		return
+	}
+
+	waiter := s.queues[priority][0]
+	s.queues[priority] = s.queues[priority][1:]
+
+	s.busy = true
+	s.granted++
+	close(waiter.ready)
+}
+
+// next picks the priority class to serve next. The mutex must be held.
+func (s *scheduler) next() (Priority, bool) {
+	waiting := make([]Priority, 0, len(priorities))
+	for _, priority := range priorities {
+		if len(s.queues[priority]) > 0 {
+			waiting = append(waiting, priority)
+		}
+	}
+
+	if len(waiting) == 0 {
+		return 0, false
+	}
+
+	if s.reserve > 0 && len(waiting) > 1 && (s.granted+1)%s.reserve == 0 {
+		return waiting[len(waiting)-1], true
+	}
+
+	return waiting[0], true
+}
+
+// remove takes the waiter out of its queue, returning false when it is no longer queued. The mutex must be held.
+func (s *scheduler) remove(priority Priority, waiter *schedulerWaiter) bool {
+	queue := s.queues[priority]
+
+	for i, queued := range queue {
+		if queued == waiter {
+			s.queues[priority] = append(queue[:i:i], queue[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// OptionPriorityReserve holds configuration for how the scheduler shares slots between priorities.
+type OptionPriorityReserve struct {
+	reserve int
+}
+
+func (option OptionPriorityReserve) configure(client *Client) error {
+	client.priorityReserve = option.reserve
+
+	return nil
+}
+
+// WithPriorityReserve allows configuring how often a slot is given to lower priority requests while higher priority requests are waiting.
+/*
+	One in every reserve slots goes to the lowest priority that has a request waiting. Zero serves strictly by priority.
+*/
+func WithPriorityReserve(reserve int) OptionPriorityReserve {
+	return OptionPriorityReserve{
+		reserve,
+	}
+}
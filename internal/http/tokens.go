@@ -0,0 +1,29 @@
+package http
+
+// OptionToken holds configuration for an access token that is fetched for every request made by the client.
+type OptionToken struct {
+	header  string
+	token   func() (string, error)
+	refresh func(rejected string) (string, error)
+}
+
+func (option OptionToken) configure(client *Client) error {
+	client.tokenHeader = option.header
+	client.token = option.token
+	client.refreshToken = option.refresh
+
+	return nil
+}
+
+// WithToken allows the access token sent in the header to change between requests.
+/*
+	The token is fetched before every request. When a request is rejected with a 401 the token is refreshed and the
+	request is retried once. The refresh is passed the rejected token and returns an error when no new token is available.
+*/
+func WithToken(header string, token func() (string, error), refresh func(rejected string) (string, error)) OptionToken {
+	return OptionToken{
+		header,
+		token,
+		refresh,
+	}
+}
@@ -0,0 +1,115 @@
+package http
+
+import (
+	"sync/atomic"
+)
+
+// Span is a unit of traced work.
+type Span interface {
+	// SetAttribute records the attribute on the span
+	SetAttribute(key string, value any)
+	// End ends the span, with the error it failed with if any
+	End(err error)
+}
+
+// Tracer opens spans.
+type Tracer interface {
+	// Start opens a span as a child of the parent, which is nil for a span without one. The method and URL are those
+	// of the request the span is for, and are empty for work that is not a single request.
+	Start(parent Span, name string, method string, url string) Span
+}
+
+const (
+	// SpanOperation is the name of the span of a repository operation
+	SpanOperation = "shopify.operation"
+	// SpanRequest is the name of the span of a request, including its retries
+	SpanRequest = "shopify.request"
+	// SpanLimiterWait is the name of the span of the time a request waits for the limiter
+	SpanLimiterWait = "shopify.limiter_wait"
+	// SpanAttempt is the name of the span of each attempt of a request
+	SpanAttempt = "shopify.attempt"
+	// SpanRetrySleep is the name of the span of the time waited before retrying a request
+	SpanRetrySleep = "shopify.retry_sleep"
+)
+
+const (
+	// AttributeOperation is the name of the repository operation e.g. orders.List
+	AttributeOperation = "shopify.operation"
+	// AttributeStatus is the status of a response
+	AttributeStatus = "http.status_code"
+	// AttributePage is the page number of a request of an operation
+	AttributePage = "shopify.page"
+	// AttributeRecords is the number of records returned by an operation
+	AttributeRecords = "shopify.records"
+	// AttributeRetry is the number of the retry being waited for
+	AttributeRetry = "shopify.retry"
+)
+
+// OptionTracer holds configuration for tracing the requests of a client.
+type OptionTracer struct {
+	tracer Tracer
+}
+
+func (option OptionTracer) configure(client *Client) error {
+	client.tracer = option.tracer
+
+	return nil
+}
+
+// WithTracer allows the requests of the client to be traced.
+func WithTracer(tracer Tracer) OptionTracer {
+	return OptionTracer{
+		tracer,
+	}
+}
+
+// StartOperation opens the span of a repository operation, returning a copy of the client whose requests are traced as its children.
+/*
+	Each request of the operation is numbered with the page attribute. The span must be ended by the caller.
+*/
+func (c Client) StartOperation(name string, url string) (Client, Span) {
+	span := c.startSpan(SpanOperation, "", url)
+	span.SetAttribute(AttributeOperation, name)
+
+	c.span = span
+	c.pages = new(int32)
+
+	return c, span
+}
+
+// WithOperation returns a copy of the client whose requests are each traced as a repository operation with the name.
+/*
+	This is for operations made up of a single request. Operations made up of several requests, such as listing
+	every page of a resource, open one span for all of them with StartOperation instead.
+*/
+func (c Client) WithOperation(name string) Client {
+	c.operation = name
+
+	return c
+}
+
+// startSpan opens a span as a child of the client's span, or a span that does nothing when the client is not traced
+func (c Client) startSpan(name string, method string, url string) Span {
+	if c.tracer == nil {
+		return noopSpan{}
+	}
+
+	return c.tracer.Start(c.span, name, method, url)
+}
+
+// startRequest opens the span of a request, numbering it when it is part of an operation
+func (c Client) startRequest(method string, url string) Span {
+	span := c.startSpan(SpanRequest, method, url)
+
+	if c.pages != nil {
+		span.SetAttribute(AttributePage, int(atomic.AddInt32(c.pages, 1)))
+	}
+
+	return span
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(key string, value any) {}
+
+func (noopSpan) End(err error) {}
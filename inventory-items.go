@@ -25,7 +25,7 @@ func newInventoryItemRepository(client http.Client, createURL func(endpoint stri
 func (repository inventoryItemRepository) Get(id int64) (shopify.InventoryItem, error) {
 	url := repository.createURL(fmt.Sprintf("inventory_items/%v.json", id))
 
-	body, _, err := repository.client.Get(url, nil)
+	body, _, err := repository.client.WithOperation("inventoryItems.Get").Get(url, nil)
 	if err != nil {
 		return shopify.InventoryItem{}, err
 	}
@@ -55,7 +55,7 @@ func (repository inventoryItemRepository) Update(inventoryItem shopify.Inventory
 		return shopify.InventoryItem{}, err
 	}
 
-	respBody, _, err := repository.client.Put(url, body, nil)
+	respBody, _, err := repository.client.WithOperation("inventoryItems.Update").Put(url, body, nil)
 	if err != nil {
 		return shopify.InventoryItem{}, err
 	}
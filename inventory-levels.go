@@ -35,7 +35,7 @@ func (repository inventoryLevelRepository) Set(inventoryItemID int64, locationID
 
 	url := repository.createURL("inventory_levels/set.json")
 
-	respBody, _, err := repository.client.Post(url, body, nil)
+	respBody, _, err := repository.client.WithOperation("inventoryLevels.Set").Post(url, body, nil)
 	if err != nil {
 		return shopify.InventoryLevel{}, err
 	}
@@ -0,0 +1,612 @@
+package httpshopify
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MOHC-LTD/shopify/v2"
+)
+
+// Measurement is the value of a dimension, weight or volume metafield
+type Measurement struct {
+	// Value is the amount of the measurement
+	Value float64 `json:"value"`
+	// Unit is the unit of the measurement
+	/*
+		Valid values:
+		- dimension: in, ft, yd, mm, cm, m
+		- weight: oz, lb, g, kg
+		- volume: ml, cl, l, m3, us_fl_oz, us_pt, us_qt, us_gal, imp_fl_oz, imp_pt, imp_qt, imp_gal
+	*/
+	Unit string `json:"unit"`
+}
+
+// Rating is the value of a rating metafield
+type Rating struct {
+	// Value is the rating
+	Value float64 `json:"value,string"`
+	// ScaleMin is the lowest value the rating can have
+	ScaleMin float64 `json:"scale_min,string"`
+	// ScaleMax is the highest value the rating can have
+	ScaleMax float64 `json:"scale_max,string"`
+}
+
+const (
+	// MoneyMetaFieldType is an amount of money in a currency
+	MoneyMetaFieldType = "money"
+	// CollectionReferenceMetaFieldType is a reference to a collection
+	CollectionReferenceMetaFieldType = "collection_reference"
+	// MetaobjectReferenceMetaFieldType is a reference to a metaobject
+	MetaobjectReferenceMetaFieldType = "metaobject_reference"
+	// MixedReferenceMetaFieldType is a reference to a metaobject of any definition
+	MixedReferenceMetaFieldType = "mixed_reference"
+
+	// listMetafieldTypePrefix is the prefix that turns a metafield type into a list of that type
+	listMetafieldTypePrefix = "list."
+)
+
+// DecodeMetafieldValue decodes the wire value of a metafield into the Go type of its metafield type.
+/*
+	The Go types are as follows, with list types decoded into a slice of the same type:
+	- single_line_text_field, multi_line_text_field, color and *_reference: string
+	- number_integer: int64
+	- number_decimal: json.Number
+	- boolean: bool
+	- json: json.RawMessage
+	- date and date_time: time.Time
+	- money: shopify.Money
+	- dimension, weight and volume: Measurement
+	- rating: Rating
+	- url: *url.URL
+
+	Values of unknown types are returned as the string they were sent as.
+*/
+func DecodeMetafieldValue(metafieldType string, value string) (interface{}, error) {
+	if elementType, isList := strings.CutPrefix(metafieldType, listMetafieldTypePrefix); isList {
+		codec, ok := metafieldCodecFor(elementType)
+		if !ok {
+			return value, nil
+		}
+
+		return codec.decodeList(metafieldType, value)
+	}
+
+	codec, ok := metafieldCodecFor(metafieldType)
+	if !ok {
+		return value, nil
+	}
+
+	return codec.decode(metafieldType, value)
+}
+
+// EncodeMetafieldValue encodes a Go value into the wire format of the metafield type.
+/*
+	The value can either be the Go type that DecodeMetafieldValue returns for the type, or a string that is
+	already in the wire format. Both are validated against the metafield type.
+*/
+func EncodeMetafieldValue(metafieldType string, value interface{}) (string, error) {
+	if str, ok := value.(string); ok {
+		_, err := DecodeMetafieldValue(metafieldType, str)
+		if err != nil {
+			return "", err
+		}
+
+		return str, nil
+	}
+
+	if elementType, isList := strings.CutPrefix(metafieldType, listMetafieldTypePrefix); isList {
+		codec, ok := metafieldCodecFor(elementType)
+		if !ok {
+			return encodeUnknownMetafieldValue(value)
+		}
+
+		return codec.encodeList(metafieldType, value)
+	}
+
+	codec, ok := metafieldCodecFor(metafieldType)
+	if !ok {
+		return encodeUnknownMetafieldValue(value)
+	}
+
+	return codec.encode(metafieldType, value)
+}
+
+// DecodeMetafield decodes the value of the metafield into the Go type of its metafield type
+func DecodeMetafield(metafield shopify.Metafield) (interface{}, error) {
+	if value, ok := metafield.Value.(string); ok {
+		return DecodeMetafieldValue(metafield.Type, value)
+	}
+
+	value, err := EncodeMetafieldValue(metafield.Type, metafield.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeMetafieldValue(metafield.Type, value)
+}
+
+func encodeUnknownMetafieldValue(value interface{}) (string, error) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	return string(encoded), nil
+}
+
+// metafieldCodec converts the values of a metafield type between the wire format and Go
+type metafieldCodec interface {
+	decode(metafieldType string, value string) (interface{}, error)
+	encode(metafieldType string, value interface{}) (string, error)
+	decodeList(metafieldType string, value string) (interface{}, error)
+	encodeList(metafieldType string, value interface{}) (string, error)
+}
+
+// scalarCodec is a metafield codec for a single Go type
+type scalarCodec[T any] struct {
+	// parse converts a value in the wire format to Go
+	parse func(value string) (T, error)
+	// format converts a Go value to the wire format
+	format func(value T) (string, error)
+	// convert accepts Go values other than T, such as int for int64
+	convert func(value interface{}) (T, bool)
+	// quoted is whether an element of a list of this type is a JSON string rather than raw JSON
+	quoted bool
+}
+
+func (codec scalarCodec[T]) decode(metafieldType string, value string) (interface{}, error) {
+	decoded, err := codec.parse(value)
+	if err != nil {
+		return nil, NewErrMetafieldValueInvalid(metafieldType, value, err.Error())
+	}
+
+	return decoded, nil
+}
+
+func (codec scalarCodec[T]) toType(value interface{}) (T, bool) {
+	if typed, ok := value.(T); ok {
+		return typed, true
+	}
+
+	if codec.convert != nil {
+		return codec.convert(value)
+	}
+
+	var zero T
+	return zero, false
+}
+
+func (codec scalarCodec[T]) encode(metafieldType string, value interface{}) (string, error) {
+	typed, ok := codec.toType(value)
+	if !ok {
+		return "", NewErrMetafieldValueInvalid(metafieldType, value, fmt.Sprintf("expected %T but got %T", *new(T), value))
+	}
+
+	encoded, err := codec.format(typed)
+	if err != nil {
+		return "", NewErrMetafieldValueInvalid(metafieldType, value, err.Error())
+	}
+
+	_, err = codec.parse(encoded)
+	if err != nil {
+		return "", NewErrMetafieldValueInvalid(metafieldType, value, err.Error())
+	}
+
+	return encoded, nil
+}
+
+func (codec scalarCodec[T]) decodeList(metafieldType string, value string) (interface{}, error) {
+	var elements []json.RawMessage
+	err := json.Unmarshal([]byte(value), &elements)
+	if err != nil {
+		return nil, NewErrMetafieldValueInvalid(metafieldType, value, "expected a JSON array")
+	}
+
+	decoded := make([]T, 0, len(elements))
+	for _, element := range elements {
+		elementValue := string(element)
+
+		var str string
+		if json.Unmarshal(element, &str) == nil {
+			elementValue = str
+		}
+
+		parsed, err := codec.parse(elementValue)
+		if err != nil {
+			return nil, NewErrMetafieldValueInvalid(metafieldType, value, err.Error())
+		}
+
+		decoded = append(decoded, parsed)
+	}
+
+	return decoded, nil
+}
+
+func (codec scalarCodec[T]) encodeList(metafieldType string, value interface{}) (string, error) {
+	var values []T
+	switch typed := value.(type) {
+	case []T:
+		values = typed
+	case []interface{}:
+		for _, element := range typed {
+			converted, ok := codec.toType(element)
+			if !ok {
+				return "", NewErrMetafieldValueInvalid(metafieldType, value, fmt.Sprintf("expected %T but got %T", *new(T), element))
+			}
+
+			values = append(values, converted)
+		}
+	default:
+		return "", NewErrMetafieldValueInvalid(metafieldType, value, fmt.Sprintf("expected %T but got %T", []T{}, value))
+	}
+
+	elements := make([]json.RawMessage, 0, len(values))
+	for _, element := range values {
+		encoded, err := codec.encode(metafieldType, element)
+		if err != nil {
+			return "", err
+		}
+
+		if codec.quoted {
+			quoted, err := json.Marshal(encoded)
+			if err != nil {
+				return "", err
+			}
+
+			encoded = string(quoted)
+		}
+
+		elements = append(elements, json.RawMessage(encoded))
+	}
+
+	encoded, err := json.Marshal(elements)
+	if err != nil {
+		return "", err
+	}
+
+	return string(encoded), nil
+}
+
+func metafieldCodecFor(metafieldType string) (metafieldCodec, bool) {
+	if strings.HasSuffix(metafieldType, "_reference") {
+		return referenceCodec, true
+	}
+
+	codec, ok := metafieldCodecs[metafieldType]
+
+	return codec, ok
+}
+
+var metafieldCodecs = map[string]metafieldCodec{
+	shopify.SingleLineTextFieldMetaFieldType: singleLineTextCodec,
+	shopify.MultiLineTextFieldMetaFieldType:  multiLineTextCodec,
+	shopify.NumberIntegerMetaFieldType:       integerCodec,
+	shopify.NumberDecimalMetaFieldType:       decimalCodec,
+	shopify.BooleanMetaFieldType:             booleanCodec,
+	shopify.JSONMetaFieldType:                jsonCodec,
+	shopify.DateMetaFieldType:                dateCodec,
+	shopify.DateTimeMetaFieldType:            dateTimeCodec,
+	MoneyMetaFieldType:                       moneyCodec,
+	shopify.DimensionMetaFieldType:           measurementCodec(dimensionUnits),
+	shopify.WeightMetaFieldType:              measurementCodec(weightUnits),
+	shopify.VolumeMetaFieldType:              measurementCodec(volumeUnits),
+	shopify.RatingMetaFieldType:              ratingCodec,
+	shopify.URLMetaFieldType:                 urlCodec,
+	shopify.ColorMetaFieldType:               colorCodec,
+}
+
+func formatString(value string) (string, error) {
+	return value, nil
+}
+
+var singleLineTextCodec = scalarCodec[string]{
+	parse: func(value string) (string, error) {
+		if strings.ContainsAny(value, "\r\n") {
+			return "", fmt.Errorf("must be a single line")
+		}
+
+		return value, nil
+	},
+	format: formatString,
+	quoted: true,
+}
+
+var multiLineTextCodec = scalarCodec[string]{
+	parse:  formatString,
+	format: formatString,
+	quoted: true,
+}
+
+// maxMetafieldInteger is the largest magnitude of a number_integer metafield
+const maxMetafieldInteger = 9007199254740991
+
+var integerCodec = scalarCodec[int64]{
+	parse: func(value string) (int64, error) {
+		integer, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("must be a whole number")
+		}
+
+		if integer > maxMetafieldInteger || integer < -maxMetafieldInteger {
+			return 0, fmt.Errorf("must be in the range of +/-%v", maxMetafieldInteger)
+		}
+
+		return integer, nil
+	},
+	format: func(value int64) (string, error) {
+		return strconv.FormatInt(value, 10), nil
+	},
+	convert: func(value interface{}) (int64, bool) {
+		switch integer := value.(type) {
+		case int:
+			return int64(integer), true
+		case int32:
+			return int64(integer), true
+		case json.Number:
+			converted, err := integer.Int64()
+			return converted, err == nil
+		}
+
+		return 0, false
+	},
+}
+
+var decimalPattern = regexp.MustCompile(`^-?\d{1,13}(\.\d{1,9})?$`)
+
+var decimalCodec = scalarCodec[json.Number]{
+	parse: func(value string) (json.Number, error) {
+		if !decimalPattern.MatchString(value) {
+			return "", fmt.Errorf("must be a decimal in the range of +/-9999999999999.999999999")
+		}
+
+		return json.Number(value), nil
+	},
+	format: func(value json.Number) (string, error) {
+		return value.String(), nil
+	},
+	convert: func(value interface{}) (json.Number, bool) {
+		switch decimal := value.(type) {
+		case float64:
+			return json.Number(strconv.FormatFloat(decimal, 'f', -1, 64)), true
+		case int64:
+			return json.Number(strconv.FormatInt(decimal, 10)), true
+		case int:
+			return json.Number(strconv.Itoa(decimal)), true
+		}
+
+		return "", false
+	},
+	quoted: true,
+}
+
+var booleanCodec = scalarCodec[bool]{
+	parse: func(value string) (bool, error) {
+		switch value {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+
+		return false, fmt.Errorf("must be true or false")
+	},
+	format: func(value bool) (string, error) {
+		return strconv.FormatBool(value), nil
+	},
+}
+
+var jsonCodec = scalarCodec[json.RawMessage]{
+	parse: func(value string) (json.RawMessage, error) {
+		if !json.Valid([]byte(value)) {
+			return nil, fmt.Errorf("must be valid JSON")
+		}
+
+		return json.RawMessage(value), nil
+	},
+	format: func(value json.RawMessage) (string, error) {
+		return string(value), nil
+	},
+	convert: func(value interface{}) (json.RawMessage, bool) {
+		encoded, err := json.Marshal(value)
+		return encoded, err == nil
+	},
+}
+
+// metafieldDateLayout is the layout of a date metafield
+const metafieldDateLayout = "2006-01-02"
+
+var dateCodec = scalarCodec[time.Time]{
+	parse: func(value string) (time.Time, error) {
+		date, err := time.Parse(metafieldDateLayout, value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("must be a date in the format YYYY-MM-DD")
+		}
+
+		return date, nil
+	},
+	format: func(value time.Time) (string, error) {
+		return value.Format(metafieldDateLayout), nil
+	},
+	quoted: true,
+}
+
+// metafieldDateTimeLayout is the layout of a date and time metafield without a timezone
+const metafieldDateTimeLayout = "2006-01-02T15:04:05"
+
+var dateTimeCodec = scalarCodec[time.Time]{
+	parse: func(value string) (time.Time, error) {
+		dateTime, err := time.Parse(time.RFC3339, value)
+		if err == nil {
+			return dateTime, nil
+		}
+
+		dateTime, err = time.Parse(metafieldDateTimeLayout, value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("must be a date and time in ISO 8601 format")
+		}
+
+		return dateTime, nil
+	},
+	format: func(value time.Time) (string, error) {
+		return value.Format(time.RFC3339), nil
+	},
+	quoted: true,
+}
+
+var currencyCodePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+var moneyCodec = scalarCodec[shopify.Money]{
+	parse: func(value string) (shopify.Money, error) {
+		var money MoneyDTO
+		err := json.Unmarshal([]byte(value), &money)
+		if err != nil {
+			return shopify.Money{}, fmt.Errorf("must be an object with an amount and currency_code")
+		}
+
+		if !decimalPattern.MatchString(money.Amount) {
+			return shopify.Money{}, fmt.Errorf("amount must be a decimal")
+		}
+
+		if !currencyCodePattern.MatchString(money.CurrencyCode) {
+			return shopify.Money{}, fmt.Errorf("currency_code must be a 3-letter currency code")
+		}
+
+		return money.ToShopify(), nil
+	},
+	format: func(value shopify.Money) (string, error) {
+		encoded, err := json.Marshal(BuildMoneyDTO(value))
+		return string(encoded), err
+	},
+}
+
+var (
+	dimensionUnits = []string{"in", "ft", "yd", "mm", "cm", "m"}
+	weightUnits    = []string{"oz", "lb", "g", "kg"}
+	volumeUnits    = []string{"ml", "cl", "l", "m3", "us_fl_oz", "us_pt", "us_qt", "us_gal", "imp_fl_oz", "imp_pt", "imp_qt", "imp_gal"}
+)
+
+func measurementCodec(units []string) scalarCodec[Measurement] {
+	return scalarCodec[Measurement]{
+		parse: func(value string) (Measurement, error) {
+			var measurement Measurement
+			err := json.Unmarshal([]byte(value), &measurement)
+			if err != nil {
+				return Measurement{}, fmt.Errorf("must be an object with a value and unit")
+			}
+
+			for _, unit := range units {
+				if measurement.Unit == unit {
+					return measurement, nil
+				}
+			}
+
+			return Measurement{}, fmt.Errorf("unit must be one of %v", strings.Join(units, ", "))
+		},
+		format: func(value Measurement) (string, error) {
+			encoded, err := json.Marshal(value)
+			return string(encoded), err
+		},
+	}
+}
+
+var ratingCodec = scalarCodec[Rating]{
+	parse: func(value string) (Rating, error) {
+		var rating Rating
+		err := json.Unmarshal([]byte(value), &rating)
+		if err != nil {
+			return Rating{}, fmt.Errorf("must be an object with a value, scale_min and scale_max")
+		}
+
+		if rating.Value < rating.ScaleMin || rating.Value > rating.ScaleMax {
+			return Rating{}, fmt.Errorf("value must be between scale_min and scale_max")
+		}
+
+		return rating, nil
+	},
+	format: func(value Rating) (string, error) {
+		encoded, err := json.Marshal(value)
+		return string(encoded), err
+	},
+}
+
+// urlSchemes are the schemes allowed in a url metafield
+var urlSchemes = []string{"https", "http", "mailto", "sms", "tel"}
+
+var urlCodec = scalarCodec[*url.URL]{
+	parse: func(value string) (*url.URL, error) {
+		parsed, err := url.Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("must be a URL")
+		}
+
+		for _, scheme := range urlSchemes {
+			if parsed.Scheme == scheme {
+				return parsed, nil
+			}
+		}
+
+		return nil, fmt.Errorf("scheme must be one of %v", strings.Join(urlSchemes, ", "))
+	},
+	format: func(value *url.URL) (string, error) {
+		if value == nil {
+			return "", fmt.Errorf("must be a URL")
+		}
+
+		return value.String(), nil
+	},
+	quoted: true,
+}
+
+var colorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+var colorCodec = scalarCodec[string]{
+	parse: func(value string) (string, error) {
+		if !colorPattern.MatchString(value) {
+			return "", fmt.Errorf("must be a hexadecimal color code such as #fff123")
+		}
+
+		return value, nil
+	},
+	format: formatString,
+	quoted: true,
+}
+
+var referenceCodec = scalarCodec[string]{
+	parse: func(value string) (string, error) {
+		if !strings.HasPrefix(value, "gid://shopify/") {
+			return "", fmt.Errorf("must be a global ID such as gid://shopify/Product/1")
+		}
+
+		return value, nil
+	},
+	format: formatString,
+	quoted: true,
+}
+
+// ErrMetafieldValueInvalid is thrown when a value does not match its metafield type
+type ErrMetafieldValueInvalid struct {
+	// Type is the metafield type that the value was checked against
+	Type string
+	// Value is the invalid value
+	Value interface{}
+	// Reason describes why the value is invalid
+	Reason string
+}
+
+func (err ErrMetafieldValueInvalid) Error() string {
+	return fmt.Sprintf("invalid %v metafield value %v: %v", err.Type, err.Value, err.Reason)
+}
+
+// NewErrMetafieldValueInvalid builds the error
+func NewErrMetafieldValueInvalid(metafieldType string, value interface{}, reason string) ErrMetafieldValueInvalid {
+	return ErrMetafieldValueInvalid{
+		Type:   metafieldType,
+		Value:  value,
+		Reason: reason,
+	}
+}
@@ -0,0 +1,137 @@
+package httpshopify
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/MOHC-LTD/httpshopify/v2/internal/assertions"
+	"github.com/MOHC-LTD/shopify/v2"
+)
+
+// Tests that wire values are decoded into the Go type of their metafield type
+func TestDecodeMetafieldValue(t *testing.T) {
+	cases := []struct {
+		metafieldType string
+		value         string
+		expected      interface{}
+	}{
+		{shopify.SingleLineTextFieldMetaFieldType, "summer", "summer"},
+		{shopify.NumberIntegerMetaFieldType, "9007199254740991", int64(9007199254740991)},
+		{shopify.NumberDecimalMetaFieldType, "10.123456789", json.Number("10.123456789")},
+		{shopify.BooleanMetaFieldType, "true", true},
+		{shopify.JSONMetaFieldType, `{"a":[1,2]}`, json.RawMessage(`{"a":[1,2]}`)},
+		{shopify.DateMetaFieldType, "2024-03-01", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{MoneyMetaFieldType, `{"amount":"5.99","currency_code":"GBP"}`, shopify.Money{Amount: "5.99", CurrencyCode: "GBP"}},
+		{shopify.WeightMetaFieldType, `{"value":2.5,"unit":"kg"}`, Measurement{Value: 2.5, Unit: "kg"}},
+		{shopify.RatingMetaFieldType, `{"value":"3.5","scale_min":"1.0","scale_max":"5.0"}`, Rating{Value: 3.5, ScaleMin: 1, ScaleMax: 5}},
+		{shopify.ProductReferenceMetaFieldType, "gid://shopify/Product/1", "gid://shopify/Product/1"},
+		{"list.number_integer", "[1,2,3]", []int64{1, 2, 3}},
+		{"list.single_line_text_field", `["a","b"]`, []string{"a", "b"}},
+		{"list.weight", `[{"value":1,"unit":"g"}]`, []Measurement{{Value: 1, Unit: "g"}}},
+		{"unknown_type", "anything", "anything"},
+	}
+
+	for _, c := range cases {
+		actual, err := DecodeMetafieldValue(c.metafieldType, c.value)
+		if err != nil {
+			assertions.ErrAssertionFailure(t, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(c.expected, actual) {
+			assertions.ValueAssertionFailure(t, c.expected, actual)
+		}
+	}
+}
+
+// Tests that values which do not match their metafield type are rejected
+func TestDecodeMetafieldValueInvalid(t *testing.T) {
+	cases := map[string]string{
+		shopify.SingleLineTextFieldMetaFieldType: "two\nlines",
+		shopify.NumberIntegerMetaFieldType:       "1.5",
+		shopify.BooleanMetaFieldType:             "yes",
+		shopify.ColorMetaFieldType:               "red",
+		shopify.URLMetaFieldType:                 "ftp://example.com",
+		shopify.DimensionMetaFieldType:           `{"value":1,"unit":"kg"}`,
+		"list.date":                              `["2024-13-01"]`,
+	}
+
+	for metafieldType, value := range cases {
+		_, err := DecodeMetafieldValue(metafieldType, value)
+
+		if _, ok := err.(ErrMetafieldValueInvalid); !ok {
+			assertions.TypeAssertionFailure(t, ErrMetafieldValueInvalid{}, err)
+		}
+	}
+}
+
+// Tests that Go values are encoded into the wire format of their metafield type
+func TestEncodeMetafieldValue(t *testing.T) {
+	cases := []struct {
+		metafieldType string
+		value         interface{}
+		expected      string
+	}{
+		{shopify.NumberIntegerMetaFieldType, 42, "42"},
+		{shopify.NumberDecimalMetaFieldType, 1.25, "1.25"},
+		{shopify.BooleanMetaFieldType, false, "false"},
+		{shopify.JSONMetaFieldType, map[string]int{"a": 1}, `{"a":1}`},
+		{shopify.DateTimeMetaFieldType, time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), "2024-03-01T12:00:00Z"},
+		{MoneyMetaFieldType, shopify.Money{Amount: "5.99", CurrencyCode: "GBP"}, `{"amount":"5.99","currency_code":"GBP"}`},
+		{shopify.VolumeMetaFieldType, Measurement{Value: 330, Unit: "ml"}, `{"value":330,"unit":"ml"}`},
+		{"list.date", []time.Time{time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}, `["2024-03-01"]`},
+		{"list.number_integer", []int64{1, 2}, "[1,2]"},
+		{shopify.SingleLineTextFieldMetaFieldType, "already encoded", "already encoded"},
+	}
+
+	for _, c := range cases {
+		actual, err := EncodeMetafieldValue(c.metafieldType, c.value)
+		if err != nil {
+			assertions.ErrAssertionFailure(t, err)
+			continue
+		}
+
+		if actual != c.expected {
+			assertions.ValueAssertionFailure(t, c.expected, actual)
+		}
+	}
+}
+
+// Tests that a Go value of the wrong type is rejected
+func TestEncodeMetafieldValueInvalid(t *testing.T) {
+	_, err := EncodeMetafieldValue(shopify.BooleanMetaFieldType, 1)
+
+	if _, ok := err.(ErrMetafieldValueInvalid); !ok {
+		assertions.TypeAssertionFailure(t, ErrMetafieldValueInvalid{}, err)
+	}
+}
+
+// Tests that metafield values that are not sent as strings keep their wire format
+func TestMetafieldDTO_UnmarshalJSON(t *testing.T) {
+	cases := map[string]string{
+		`{"id":1,"type":"json","value":{"a":{"b":1}}}`:                    `{"a":{"b":1}}`,
+		`{"id":1,"type":"number_integer","value":9007199254740991}`:       "9007199254740991",
+		`{"id":1,"type":"single_line_text_field","value":"plain string"}`: "plain string",
+	}
+
+	for body, expected := range cases {
+		var dto metafieldDTO
+		err := json.Unmarshal([]byte(body), &dto)
+		if err != nil {
+			assertions.ErrAssertionFailure(t, err)
+			continue
+		}
+
+		metafield := dto.toShopify()
+
+		if metafield.Value != expected {
+			assertions.ValueAssertionFailure(t, expected, metafield.Value)
+		}
+
+		if metafield.ID != 1 {
+			assertions.ValueAssertionFailure(t, 1, metafield.ID)
+		}
+	}
+}
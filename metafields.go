@@ -3,6 +3,7 @@ package httpshopify
 import (
 	"encoding/json"
 	"fmt"
+	httpCode "net/http"
 	"net/url"
 	"strconv"
 	"time"
@@ -11,6 +12,43 @@ import (
 	"github.com/MOHC-LTD/shopify/v2"
 )
 
+// MetafieldQuery are properties that can be used to filter the metafields of a resource
+type MetafieldQuery struct {
+	// Limit is the number of metafields returned per page. Maximum is 250.
+	Limit int
+	// Namespace restricts the metafields to those in the namespace
+	Namespace string
+	// Key restricts the metafields to those with the key
+	Key string
+	// Type restricts the metafields to those of the type
+	Type string
+	// UpdatedAtMin restricts the metafields to those last updated after the date
+	UpdatedAtMin time.Time
+	// UpdatedAtMax restricts the metafields to those last updated before the date
+	UpdatedAtMax time.Time
+}
+
+// MetafieldRepository maintains the metafields of a shop.
+/*
+	The repository returned by Shop.Metafields also implements this interface.
+	Each method is addressed by the resource that owns the metafields, which can be one of
+	the article, blog, collection, customer, draft order, location, order, page, product,
+	product variant or shop resources.
+*/
+type MetafieldRepository interface {
+	shopify.MetafieldRepository
+	// ListByResource retrieves all the metafields of a resource
+	ListByResource(resource shopify.MetafieldResource, query MetafieldQuery) (shopify.Metafields, error)
+	// Get retrieves a single metafield of a resource
+	Get(resource shopify.MetafieldResource, id int64) (shopify.Metafield, error)
+	// Create creates a new metafield on a resource
+	Create(resource shopify.MetafieldResource, metafield shopify.Metafield) (shopify.Metafield, error)
+	// Update updates an existing metafield on a resource
+	Update(resource shopify.MetafieldResource, metafield shopify.Metafield) (shopify.Metafield, error)
+	// Delete deletes a metafield from a resource
+	Delete(resource shopify.MetafieldResource, id int64) error
+}
+
 type metafieldRepository struct {
 	client    http.Client
 	createURL func(endpoint string) string
@@ -21,23 +59,257 @@ func newMetafieldRepository(client http.Client, createURL func(endpoint string)
 }
 
 func (repository metafieldRepository) List(query shopify.MetafieldQuery) (shopify.Metafields, error) {
+	return repository.list(repository.createURL(fmt.Sprintf("metafields.json?%s", parseMetafieldQuery(query))))
+}
 
-	url := repository.createURL(fmt.Sprintf("metafields.json?%s", parseMetafieldQuery(query)))
-
-	body, _, err := repository.client.Get(url, nil)
+func (repository metafieldRepository) ListByResource(resource shopify.MetafieldResource, query MetafieldQuery) (shopify.Metafields, error) {
+	path, err := metafieldResourcePath(resource)
 	if err != nil {
 		return shopify.Metafields{}, err
 	}
 
+	return repository.list(repository.createURL(fmt.Sprintf("%smetafields.json%s", path, parseMetafieldResourceQuery(query))))
+}
+
+func (repository metafieldRepository) list(url string) (shopify.Metafields, error) {
+	metafields := make(shopify.Metafields, 0)
+
+	client, span := repository.client.StartOperation("metafields.List", url)
+
+	for {
+		body, headers, err := client.Get(url, nil)
+		if err != nil {
+			span.End(err)
+			return shopify.Metafields{}, err
+		}
+
+		var response struct {
+			Metafields metafieldsDTO `json:"metafields"`
+		}
+		err = json.Unmarshal(body, &response)
+		if err != nil {
+			span.End(err)
+			return shopify.Metafields{}, err
+		}
+
+		metafields = append(metafields, response.Metafields.toShopify()...)
+
+		links := ParseLinkHeader(headers.Get("Link"))
+
+		if !links.HasNext() {
+			break
+		}
+
+		url = links.Next
+	}
+
+	span.SetAttribute(http.AttributeRecords, len(metafields))
+	span.End(nil)
+
+	return metafields, nil
+}
+
+func (repository metafieldRepository) Get(resource shopify.MetafieldResource, id int64) (shopify.Metafield, error) {
+	path, err := metafieldResourcePath(resource)
+	if err != nil {
+		return shopify.Metafield{}, err
+	}
+
+	url := repository.createURL(fmt.Sprintf("%smetafields/%d.json", path, id))
+
+	body, _, err := repository.client.WithOperation("metafields.Get").Get(url, nil)
+	if err != nil {
+		if httpErr, ok := err.(http.ErrHTTP); ok && httpErr.Code == httpCode.StatusNotFound {
+			return shopify.Metafield{}, NewErrMetafieldNotFound(id)
+		}
+
+		return shopify.Metafield{}, err
+	}
+
 	var response struct {
-		Metafields metafieldsDTO `json:"metafields"`
+		Metafield metafieldDTO `json:"metafield"`
 	}
 	err = json.Unmarshal(body, &response)
 	if err != nil {
-		return shopify.Metafields{}, err
+		return shopify.Metafield{}, err
+	}
+
+	if response.Metafield.ID == 0 {
+		return shopify.Metafield{}, NewErrMetafieldNotFound(id)
+	}
+
+	return response.Metafield.toShopify(), nil
+}
+
+func (repository metafieldRepository) Create(resource shopify.MetafieldResource, metafield shopify.Metafield) (shopify.Metafield, error) {
+	path, err := metafieldResourcePath(resource)
+	if err != nil {
+		return shopify.Metafield{}, err
+	}
+
+	value, err := EncodeMetafieldValue(metafield.Type, metafield.Value)
+	if err != nil {
+		return shopify.Metafield{}, err
+	}
+
+	bodyData := struct {
+		Metafield metafieldDTO `json:"metafield"`
+	}{
+		Metafield: metafieldDTO{
+			Description: metafield.Description,
+			Key:         metafield.Key,
+			Namespace:   metafield.Namespace,
+			Value:       value,
+			Type:        metafield.Type,
+		},
+	}
+
+	body, err := json.Marshal(&bodyData)
+	if err != nil {
+		return shopify.Metafield{}, err
+	}
+
+	url := repository.createURL(fmt.Sprintf("%smetafields.json", path))
+
+	responseBody, _, err := repository.client.WithOperation("metafields.Create").Post(url, body, nil)
+	if err != nil {
+		return shopify.Metafield{}, err
+	}
+
+	var response struct {
+		Metafield metafieldDTO `json:"metafield"`
+	}
+	err = json.Unmarshal(responseBody, &response)
+	if err != nil {
+		return shopify.Metafield{}, err
+	}
+
+	return response.Metafield.toShopify(), nil
+}
+
+func (repository metafieldRepository) Update(resource shopify.MetafieldResource, metafield shopify.Metafield) (shopify.Metafield, error) {
+	path, err := metafieldResourcePath(resource)
+	if err != nil {
+		return shopify.Metafield{}, err
+	}
+
+	value, err := EncodeMetafieldValue(metafield.Type, metafield.Value)
+	if err != nil {
+		return shopify.Metafield{}, err
+	}
+
+	bodyData := struct {
+		Metafield metafieldDTO `json:"metafield"`
+	}{
+		Metafield: metafieldDTO{
+			ID:          metafield.ID,
+			Description: metafield.Description,
+			Key:         metafield.Key,
+			Namespace:   metafield.Namespace,
+			Value:       value,
+			Type:        metafield.Type,
+		},
+	}
+
+	body, err := json.Marshal(&bodyData)
+	if err != nil {
+		return shopify.Metafield{}, err
+	}
+
+	url := repository.createURL(fmt.Sprintf("%smetafields/%d.json", path, metafield.ID))
+
+	responseBody, _, err := repository.client.WithOperation("metafields.Update").Put(url, body, nil)
+	if err != nil {
+		return shopify.Metafield{}, err
+	}
+
+	var response struct {
+		Metafield metafieldDTO `json:"metafield"`
+	}
+	err = json.Unmarshal(responseBody, &response)
+	if err != nil {
+		return shopify.Metafield{}, err
+	}
+
+	return response.Metafield.toShopify(), nil
+}
+
+func (repository metafieldRepository) Delete(resource shopify.MetafieldResource, id int64) error {
+	path, err := metafieldResourcePath(resource)
+	if err != nil {
+		return err
+	}
+
+	url := repository.createURL(fmt.Sprintf("%smetafields/%d.json", path, id))
+
+	_, _, err = repository.client.WithOperation("metafields.Delete").Delete(url, nil)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// metafieldResourcePaths maps the owner resource of a metafield to the endpoint that holds its metafields
+var metafieldResourcePaths = map[string]string{
+	shopify.ArticleResource:        "articles",
+	shopify.BlogResource:           "blogs",
+	shopify.CollectionResource:     "collections",
+	shopify.CustomerResource:       "customers",
+	shopify.DraftOrderResource:     "draft_orders",
+	shopify.LocationResource:       "locations",
+	shopify.OrderResource:          "orders",
+	shopify.PageResource:           "pages",
+	shopify.ProductResource:        "products",
+	shopify.ProductVariantResource: "variants",
+}
+
+// metafieldResourcePath builds the path prefix of the metafield endpoints of a resource
+func metafieldResourcePath(resource shopify.MetafieldResource) (string, error) {
+	if resource.OwnerResource == shopify.ShopResource {
+		return "", nil
+	}
+
+	path, ok := metafieldResourcePaths[resource.OwnerResource]
+	if !ok || resource.OwnerID == 0 {
+		return "", NewErrMetafieldResourceUnsupported(resource)
 	}
 
-	return response.Metafields.toShopify(), nil
+	return fmt.Sprintf("%s/%d/", path, resource.OwnerID), nil
+}
+
+func parseMetafieldResourceQuery(query MetafieldQuery) string {
+	params := url.Values{}
+
+	if query.Limit != 0 && query.Limit <= 250 {
+		params.Add("limit", strconv.Itoa(query.Limit))
+	}
+
+	if query.Namespace != "" {
+		params.Add("namespace", query.Namespace)
+	}
+
+	if query.Key != "" {
+		params.Add("key", query.Key)
+	}
+
+	if query.Type != "" {
+		params.Add("type", query.Type)
+	}
+
+	if !query.UpdatedAtMin.IsZero() {
+		params.Add("updated_at_min", query.UpdatedAtMin.Format(time.RFC3339))
+	}
+
+	if !query.UpdatedAtMax.IsZero() {
+		params.Add("updated_at_max", query.UpdatedAtMax.Format(time.RFC3339))
+	}
+
+	if len(params) == 0 {
+		return ""
+	}
+
+	return "?" + params.Encode()
 }
 
 func parseMetafieldQuery(query shopify.MetafieldQuery) string {
@@ -101,7 +373,7 @@ type metafieldDTO struct {
 	Key         string `json:"key"`
 	Namespace   string `json:"namespace"`
 	OwnerID     int64  `json:"owner_id,omitempty"`
-	// TODO This is better in a later version as it is always a string. Consider versioning this package with a new Shopify version.
+	// Value is the value in the wire format of the type when read from a response
 	Value         interface{} `json:"value"`
 	Type          string      `json:"type"`
 	OwnerResource string      `json:"owner_resource,omitempty"`
@@ -109,6 +381,36 @@ type metafieldDTO struct {
 	UpdatedAt     *time.Time  `json:"updated_at,omitempty"`
 }
 
+// UnmarshalJSON keeps the value of the metafield in its wire format.
+/*
+	Older API versions send some values as JSON numbers or objects rather than strings, which would otherwise lose
+	precision or structure when decoded into an interface.
+*/
+func (dto *metafieldDTO) UnmarshalJSON(data []byte) error {
+	type metafieldAlias metafieldDTO
+
+	response := struct {
+		*metafieldAlias
+		Value json.RawMessage `json:"value"`
+	}{
+		metafieldAlias: (*metafieldAlias)(dto),
+	}
+
+	err := json.Unmarshal(data, &response)
+	if err != nil {
+		return err
+	}
+
+	var value string
+	if json.Unmarshal(response.Value, &value) != nil {
+		value = string(response.Value)
+	}
+
+	dto.Value = value
+
+	return nil
+}
+
 func (dto metafieldDTO) toShopify() shopify.Metafield {
 	var createdAt time.Time
 	if dto.CreatedAt != nil {
@@ -129,9 +431,41 @@ func (dto metafieldDTO) toShopify() shopify.Metafield {
 			OwnerID:       dto.OwnerID,
 			OwnerResource: dto.OwnerResource,
 		},
-		Value:     fmt.Sprintf("%v", dto.Value),
+		Value:     dto.Value,
 		Type:      dto.Type,
 		CreatedAt: createdAt,
 		UpdatedAt: updatedAt,
 	}
 }
+
+// ErrMetafieldNotFound is thrown when no metafield is found with the id
+type ErrMetafieldNotFound struct {
+	id int64
+}
+
+func (err ErrMetafieldNotFound) Error() string {
+	return fmt.Sprintf("metafield %v not found", err.id)
+}
+
+// NewErrMetafieldNotFound builds the error
+func NewErrMetafieldNotFound(id int64) ErrMetafieldNotFound {
+	return ErrMetafieldNotFound{
+		id,
+	}
+}
+
+// ErrMetafieldResourceUnsupported is thrown when the metafields of a resource cannot be addressed
+type ErrMetafieldResourceUnsupported struct {
+	resource shopify.MetafieldResource
+}
+
+func (err ErrMetafieldResourceUnsupported) Error() string {
+	return fmt.Sprintf("metafields of %v %v are not supported", err.resource.OwnerResource, err.resource.OwnerID)
+}
+
+// NewErrMetafieldResourceUnsupported builds the error
+func NewErrMetafieldResourceUnsupported(resource shopify.MetafieldResource) ErrMetafieldResourceUnsupported {
+	return ErrMetafieldResourceUnsupported{
+		resource,
+	}
+}
@@ -0,0 +1,56 @@
+package httpshopify
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MOHC-LTD/httpshopify/v2/internal/assertions"
+	"github.com/MOHC-LTD/shopify/v2"
+)
+
+// Tests that the metafield endpoints of each resource are built correctly
+func TestMetafieldResourcePath(t *testing.T) {
+	cases := map[string]shopify.MetafieldResource{
+		"products/1/":  {OwnerID: 1, OwnerResource: shopify.ProductResource},
+		"variants/2/":  {OwnerID: 2, OwnerResource: shopify.ProductVariantResource},
+		"customers/3/": {OwnerID: 3, OwnerResource: shopify.CustomerResource},
+		"":             {OwnerResource: shopify.ShopResource},
+	}
+
+	for expected, resource := range cases {
+		actual, err := metafieldResourcePath(resource)
+		if err != nil {
+			assertions.ErrAssertionFailure(t, err)
+		}
+
+		if actual != expected {
+			assertions.ValueAssertionFailure(t, expected, actual)
+		}
+	}
+}
+
+// Tests that a resource without metafield endpoints is rejected
+func TestMetafieldResourcePathUnsupported(t *testing.T) {
+	_, err := metafieldResourcePath(shopify.MetafieldResource{OwnerID: 1, OwnerResource: shopify.ProductImageResource})
+
+	if _, ok := err.(ErrMetafieldResourceUnsupported); !ok {
+		assertions.TypeAssertionFailure(t, ErrMetafieldResourceUnsupported{}, err)
+	}
+}
+
+// Tests that the metafield filters are encoded in the query
+func TestParseMetafieldResourceQuery(t *testing.T) {
+	updatedAtMin := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	actual := parseMetafieldResourceQuery(MetafieldQuery{
+		Namespace:    "custom",
+		Key:          "season",
+		UpdatedAtMin: updatedAtMin,
+	})
+
+	expected := "?key=season&namespace=custom&updated_at_min=2024-01-02T03%3A04%3A05Z"
+
+	if actual != expected {
+		assertions.ValueAssertionFailure(t, expected, actual)
+	}
+}
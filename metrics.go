@@ -0,0 +1,343 @@
+package httpshopify
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMetricsBuckets are the upper bounds in seconds of the buckets of the histograms kept by MemoryMetrics
+var DefaultMetricsBuckets = []float64{0.005, 0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// MetricsSink is told about the requests made by a shop, for example to count them in Prometheus.
+/*
+	Endpoints are templates of the requested path with IDs replaced, such as orders/{id}.json, so that they can be
+	used as labels. Implementations must be safe for concurrent use.
+*/
+type MetricsSink interface {
+	// ObserveRequest is called for each attempt of a request, with a status of zero when no response was received
+	ObserveRequest(method string, endpoint string, status int, duration time.Duration, sentBytes int)
+	// ObserveReceived is called with the size of the body of each response that is read
+	ObserveReceived(method string, endpoint string, receivedBytes int)
+	// ObserveRetry is called each time an attempt is retried, with a status of zero when no response was received
+	ObserveRetry(method string, endpoint string, status int)
+	// ObserveLimiterWait is called with how long each request waited for the rate limiter
+	ObserveLimiterWait(method string, endpoint string, wait time.Duration)
+	// ObserveBucket is called with the fill level of the shop's bucket reported by Shopify
+	ObserveBucket(shop string, used int, size int)
+}
+
+// WithMetrics configures the shop to report its requests to the sink.
+/*
+	A sink can be shared between shops, such as by passing it to NewShopManager, to see the usage of all of them.
+*/
+func WithMetrics(sink MetricsSink) OptionFunc {
+	return func(o *Options) {
+		o.metrics = sink
+	}
+}
+
+// EndpointTemplate returns the path of the URL after the API version with IDs replaced by {id}, e.g. orders/{id}/fulfillments.json
+func EndpointTemplate(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+
+	path := strings.Trim(parsed.Path, "/")
+	if index := strings.Index(path, "/api/"); index != -1 {
+		// Skip past the version e.g. admin/api/2024-01/orders.json
+		_, path, _ = strings.Cut(path[index+len("/api/"):], "/")
+	} else {
+		path = strings.TrimPrefix(path, "admin/")
+	}
+
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		id, extension, _ := strings.Cut(segment, ".")
+		if _, err := strconv.ParseInt(id, 10, 64); err != nil {
+			continue
+		}
+
+		segments[i] = "{id}"
+		if extension != "" {
+			segments[i] += "." + extension
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// StatusClass returns the class of the status e.g. 2xx, or error when no response was received
+func StatusClass(status int) string {
+	if status == 0 {
+		return "error"
+	}
+
+	return strconv.Itoa(status/100) + "xx"
+}
+
+// metricsReporter reports the requests of a client to a sink
+type metricsReporter struct {
+	sink MetricsSink
+}
+
+func (reporter metricsReporter) Request(method string, url string, status int, duration time.Duration, sent int) {
+	reporter.sink.ObserveRequest(method, EndpointTemplate(url), status, duration, sent)
+}
+
+func (reporter metricsReporter) Received(method string, url string, size int) {
+	reporter.sink.ObserveReceived(method, EndpointTemplate(url), size)
+}
+
+func (reporter metricsReporter) Retry(method string, url string, status int) {
+	reporter.sink.ObserveRetry(method, EndpointTemplate(url), status)
+}
+
+func (reporter metricsReporter) Wait(method string, url string, wait time.Duration) {
+	reporter.sink.ObserveLimiterWait(method, EndpointTemplate(url), wait)
+}
+
+func (reporter metricsReporter) Bucket(rawURL string, used int, size int) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return
+	}
+
+	reporter.sink.ObserveBucket(parsed.Host, used, size)
+}
+
+// Histogram counts observations into buckets
+type Histogram struct {
+	// Bounds are the upper bounds of the buckets in seconds
+	Bounds []float64
+	// Counts are the number of observations at or below each bound, with a final count of every observation
+	Counts []int
+	// Sum is the total of every observation in seconds
+	Sum float64
+}
+
+func newHistogram(bounds []float64) Histogram {
+	return Histogram{
+		Bounds: bounds,
+		Counts: make([]int, len(bounds)+1),
+	}
+}
+
+func (histogram *Histogram) observe(duration time.Duration) {
+	seconds := duration.Seconds()
+
+	for i, bound := range histogram.Bounds {
+		if seconds <= bound {
+			histogram.Counts[i]++
+		}
+	}
+
+	histogram.Counts[len(histogram.Bounds)]++
+	histogram.Sum += seconds
+}
+
+// Count returns the number of observations
+func (histogram Histogram) Count() int {
+	return histogram.Counts[len(histogram.Counts)-1]
+}
+
+func (histogram Histogram) copy() Histogram {
+	histogram.Counts = append([]int{}, histogram.Counts...)
+
+	return histogram
+}
+
+// Endpoint is a request method and endpoint template
+type Endpoint struct {
+	Method   string
+	Template string
+}
+
+// EndpointMetrics are the metrics of the requests to an endpoint
+type EndpointMetrics struct {
+	// Requests is the number of attempts by status class e.g. 2xx
+	Requests map[string]int
+	// Retries is the number of attempts that were retried
+	Retries int
+	// Throttled is the number of attempts that Shopify responded to with a 429
+	Throttled int
+	// Duration is how long attempts took
+	Duration Histogram
+	// LimiterWait is how long requests waited for the rate limiter
+	LimiterWait Histogram
+	// SentBytes is the size of every request body sent
+	SentBytes int64
+	// ReceivedBytes is the size of every response body read
+	ReceivedBytes int64
+}
+
+func (metrics EndpointMetrics) copy() EndpointMetrics {
+	requests := make(map[string]int, len(metrics.Requests))
+	for class, count := range metrics.Requests {
+		requests[class] = count
+	}
+
+	metrics.Requests = requests
+	metrics.Duration = metrics.Duration.copy()
+	metrics.LimiterWait = metrics.LimiterWait.copy()
+
+	return metrics
+}
+
+// BucketLevel is the fill level of a shop's bucket when it was last reported
+type BucketLevel struct {
+	Used       int
+	Size       int
+	ObservedAt time.Time
+}
+
+// MetricsSnapshot is a copy of the metrics collected by MemoryMetrics
+type MetricsSnapshot struct {
+	Endpoints map[Endpoint]EndpointMetrics
+	Buckets   map[string]BucketLevel
+}
+
+// Sorted returns the endpoints in order of the most requested first
+func (snapshot MetricsSnapshot) Sorted() []Endpoint {
+	endpoints := make([]Endpoint, 0, len(snapshot.Endpoints))
+	for endpoint := range snapshot.Endpoints {
+		endpoints = append(endpoints, endpoint)
+	}
+
+	total := func(endpoint Endpoint) int {
+		return snapshot.Endpoints[endpoint].Duration.Count()
+	}
+
+	sort.Slice(endpoints, func(i, j int) bool {
+		if total(endpoints[i]) != total(endpoints[j]) {
+			return total(endpoints[i]) > total(endpoints[j])
+		}
+
+		if endpoints[i].Template != endpoints[j].Template {
+			return endpoints[i].Template < endpoints[j].Template
+		}
+
+		return endpoints[i].Method < endpoints[j].Method
+	})
+
+	return endpoints
+}
+
+// MemoryMetrics collects metrics in memory to be read with Snapshot, for example when scraped
+type MemoryMetrics struct {
+	bounds    []float64
+	mutex     sync.Mutex
+	endpoints map[Endpoint]*EndpointMetrics
+	buckets   map[string]BucketLevel
+}
+
+// NewMemoryMetrics builds an empty collector with histograms using DefaultMetricsBuckets
+func NewMemoryMetrics() *MemoryMetrics {
+	return &MemoryMetrics{
+		bounds:    DefaultMetricsBuckets,
+		endpoints: make(map[Endpoint]*EndpointMetrics),
+		buckets:   make(map[string]BucketLevel),
+	}
+}
+
+// ObserveRequest counts the attempt
+func (metrics *MemoryMetrics) ObserveRequest(method string, endpoint string, status int, duration time.Duration, sentBytes int) {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	m := metrics.endpoint(method, endpoint)
+	m.Requests[StatusClass(status)]++
+	m.Duration.observe(duration)
+	m.SentBytes += int64(sentBytes)
+
+	if status == 429 {
+		m.Throttled++
+	}
+}
+
+// ObserveReceived counts the size of the response
+func (metrics *MemoryMetrics) ObserveReceived(method string, endpoint string, receivedBytes int) {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	metrics.endpoint(method, endpoint).ReceivedBytes += int64(receivedBytes)
+}
+
+// ObserveRetry counts the retry
+func (metrics *MemoryMetrics) ObserveRetry(method string, endpoint string, status int) {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	metrics.endpoint(method, endpoint).Retries++
+}
+
+// ObserveLimiterWait counts the time spent waiting for the rate limiter
+func (metrics *MemoryMetrics) ObserveLimiterWait(method string, endpoint string, wait time.Duration) {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	metrics.endpoint(method, endpoint).LimiterWait.observe(wait)
+}
+
+// ObserveBucket records the fill level of the shop's bucket
+func (metrics *MemoryMetrics) ObserveBucket(shop string, used int, size int) {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	metrics.buckets[shop] = BucketLevel{
+		Used:       used,
+		Size:       size,
+		ObservedAt: time.Now(),
+	}
+}
+
+// Snapshot returns a copy of the metrics collected so far
+func (metrics *MemoryMetrics) Snapshot() MetricsSnapshot {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	snapshot := MetricsSnapshot{
+		Endpoints: make(map[Endpoint]EndpointMetrics, len(metrics.endpoints)),
+		Buckets:   make(map[string]BucketLevel, len(metrics.buckets)),
+	}
+
+	for endpoint, m := range metrics.endpoints {
+		snapshot.Endpoints[endpoint] = m.copy()
+	}
+
+	for shop, level := range metrics.buckets {
+		snapshot.Buckets[shop] = level
+	}
+
+	return snapshot
+}
+
+// Reset clears the metrics collected so far
+func (metrics *MemoryMetrics) Reset() {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	metrics.endpoints = make(map[Endpoint]*EndpointMetrics)
+	metrics.buckets = make(map[string]BucketLevel)
+}
+
+// endpoint returns the metrics of the endpoint, creating them when needed. The mutex must be held.
+func (metrics *MemoryMetrics) endpoint(method string, template string) *EndpointMetrics {
+	key := Endpoint{method, template}
+
+	m, ok := metrics.endpoints[key]
+	if !ok {
+		m = &EndpointMetrics{
+			Requests:    make(map[string]int),
+			Duration:    newHistogram(metrics.bounds),
+			LimiterWait: newHistogram(metrics.bounds),
+		}
+		metrics.endpoints[key] = m
+	}
+
+	return m
+}
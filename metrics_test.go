@@ -0,0 +1,89 @@
+package httpshopify
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MOHC-LTD/httpshopify/v2/internal/assertions"
+)
+
+// Tests that IDs are replaced in endpoint templates
+func TestEndpointTemplate(t *testing.T) {
+	cases := map[string]string{
+		"https://shop.myshopify.com/admin/api/2024-01/orders.json?status=any":       "orders.json",
+		"https://shop.myshopify.com/admin/api/2024-01/orders/123.json":              "orders/{id}.json",
+		"https://shop.myshopify.com/admin/api/2024-01/orders/123/fulfillments.json": "orders/{id}/fulfillments.json",
+		"https://shop.myshopify.com/admin/oauth/access_scopes.json":                 "oauth/access_scopes.json",
+	}
+
+	for url, expected := range cases {
+		if template := EndpointTemplate(url); template != expected {
+			assertions.ValueAssertionFailure(t, expected, template)
+		}
+	}
+}
+
+// Tests that the requests of a shop are counted by endpoint along with its retries, throttles, bytes and bucket level
+func TestWithMetrics(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		w.Header().Set("X-Shopify-Shop-Api-Call-Limit", "12/40")
+		w.Write([]byte(`{"order":{"id":123}}`))
+	}))
+	defer server.Close()
+
+	metrics := NewMemoryMetrics()
+	shop := NewCustomShop(server.URL+"/admin/api/2024-01", "token", IsDefault, WithExponentialBackoff(1, time.Millisecond, time.Millisecond), WithMetrics(metrics))
+
+	_, err := shop.Orders().Get(123)
+	if err != nil {
+		assertions.ErrAssertionFailure(t, err)
+	}
+
+	snapshot := metrics.Snapshot()
+
+	endpoint, ok := snapshot.Endpoints[Endpoint{http.MethodGet, "orders/{id}.json"}]
+	if !ok {
+		assertions.ValueAssertionFailure(t, "orders/{id}.json", snapshot.Sorted())
+		return
+	}
+
+	if endpoint.Requests["2xx"] != 1 || endpoint.Requests["4xx"] != 1 {
+		assertions.ValueAssertionFailure(t, map[string]int{"2xx": 1, "4xx": 1}, endpoint.Requests)
+	}
+
+	if endpoint.Retries != 1 {
+		assertions.ValueAssertionFailure(t, 1, endpoint.Retries)
+	}
+
+	if endpoint.Throttled != 1 {
+		assertions.ValueAssertionFailure(t, 1, endpoint.Throttled)
+	}
+
+	if endpoint.Duration.Count() != 2 {
+		assertions.ValueAssertionFailure(t, 2, endpoint.Duration.Count())
+	}
+
+	if endpoint.LimiterWait.Count() != 1 {
+		assertions.ValueAssertionFailure(t, 1, endpoint.LimiterWait.Count())
+	}
+
+	if endpoint.ReceivedBytes != int64(len(`{"order":{"id":123}}`)) {
+		assertions.ValueAssertionFailure(t, len(`{"order":{"id":123}}`), endpoint.ReceivedBytes)
+	}
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	if level := snapshot.Buckets[host]; level.Used != 12 || level.Size != 40 {
+		assertions.ValueAssertionFailure(t, BucketLevel{Used: 12, Size: 40}, level)
+	}
+}
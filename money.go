@@ -45,3 +45,47 @@ func BuildMoneyDTO(money shopify.Money) MoneyDTO {
 		CurrencyCode: money.CurrencyCode,
 	}
 }
+
+// PresentmentPrices is a collection of presentment prices
+type PresentmentPrices []PresentmentPrice
+
+// PresentmentPrice is a price and compare-at price in a presentment currency
+type PresentmentPrice struct {
+	// Price is the price in the presentment currency
+	Price shopify.Money
+	// CompareAtPrice is the compare-at price in the presentment currency
+	CompareAtPrice shopify.Money
+}
+
+// PresentmentPriceDTOs represents presentment prices in HTTP requests and responses
+type PresentmentPriceDTOs []PresentmentPriceDTO
+
+// ToShopify converts the DTOs to the Shopify equivalent
+func (dtos PresentmentPriceDTOs) ToShopify() PresentmentPrices {
+	prices := make(PresentmentPrices, 0, len(dtos))
+
+	for _, dto := range dtos {
+		prices = append(prices, dto.ToShopify())
+	}
+
+	return prices
+}
+
+// PresentmentPriceDTO represents a presentment price in HTTP requests and responses
+type PresentmentPriceDTO struct {
+	Price          MoneyDTO  `json:"price,omitempty"`
+	CompareAtPrice *MoneyDTO `json:"compare_at_price,omitempty"`
+}
+
+// ToShopify converts the DTO to the Shopify equivalent
+func (dto PresentmentPriceDTO) ToShopify() PresentmentPrice {
+	var compareAtPrice shopify.Money
+	if dto.CompareAtPrice != nil {
+		compareAtPrice = dto.CompareAtPrice.ToShopify()
+	}
+
+	return PresentmentPrice{
+		Price:          dto.Price.ToShopify(),
+		CompareAtPrice: compareAtPrice,
+	}
+}
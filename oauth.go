@@ -0,0 +1,419 @@
+package httpshopify
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/MOHC-LTD/httpshopify/v2/internal/http"
+)
+
+var (
+	// ErrOAuthInvalidShop is thrown when the shop is not a myshopify.com domain
+	ErrOAuthInvalidShop = errors.New("oauth shop is not a valid myshopify.com domain")
+	// ErrOAuthInvalidHMAC is thrown when the hmac of an OAuth callback does not match its parameters
+	ErrOAuthInvalidHMAC = errors.New("oauth callback hmac could not be verified")
+	// ErrOAuthInvalidState is thrown when the state of an OAuth callback does not match the state sent to Shopify
+	ErrOAuthInvalidState = errors.New("oauth callback state does not match")
+)
+
+var shopDomainPattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9\-]*\.myshopify\.com$`)
+
+// IsValidShopDomain returns whether the shop is a myshopify.com domain e.g. my-shop.myshopify.com
+func IsValidShopDomain(shop string) bool {
+	return shopDomainPattern.MatchString(shop)
+}
+
+// OAuthConfig are the settings of a public app used to install it on a shop
+type OAuthConfig struct {
+	// ClientID is the API key of the app.
+	ClientID string
+	// ClientSecret is the API secret key of the app.
+	ClientSecret string
+	// Scopes are the access scopes the app requests e.g. read_orders.
+	Scopes []string
+	// RedirectURL is where Shopify sends the merchant once they approve the install. It must be allowed in the app settings.
+	RedirectURL string
+	// Online requests an online access token, which is tied to the user that installed the app and expires.
+	// An offline access token, which does not expire, is requested by default.
+	Online bool
+	// Version is the Admin API version the installed shop uses e.g. 2024-01.
+	Version string
+}
+
+// OAuthOption allows the OAuth flow to be configured
+type OAuthOption func(*OAuth)
+
+// WithOAuthShopURL sets how the base URL of a shop is built from its domain, which defaults to https://{shop}.
+/*
+	This allows the flow to be run against a local stand-in for Shopify.
+*/
+func WithOAuthShopURL(shopURL func(shop string) string) OAuthOption {
+	return func(oauth *OAuth) {
+		oauth.shopURL = shopURL
+	}
+}
+
+// OAuth runs the authorization code flow used to install a public app on a shop.
+/*
+	Example:
+	oauth := httpshopify.NewOAuth(httpshopify.OAuthConfig{ClientID: "key", ClientSecret: "secret", Scopes: []string{"read_orders"}, RedirectURL: "https://example.com/callback", Version: "2024-01"})
+	state, err := httpshopify.NewOAuthState()
+	authorizeURL, err := oauth.AuthorizeURL("my-shop.myshopify.com", state)
+	// Redirect to authorizeURL, keeping state in the session. Then on the callback:
+	shop, token, err := oauth.Complete(request.URL.Query(), state)
+*/
+type OAuth struct {
+	config  OAuthConfig
+	client  http.Client
+	shopURL func(shop string) string
+}
+
+// NewOAuth builds the OAuth flow for the app
+func NewOAuth(config OAuthConfig, options ...OAuthOption) OAuth {
+	oauth := OAuth{
+		config: config,
+		client: http.NewClient(
+			http.WithDefaultHeader("Content-Type", "application/json"),
+			http.WithDefaultHeader("Accept", "application/json"),
+		),
+		shopURL: func(shop string) string {
+			return fmt.Sprintf("https://%v", shop)
+		},
+	}
+
+	for _, option := range options {
+		option(&oauth)
+	}
+
+	return oauth
+}
+
+// NewOAuthState builds a random nonce to send as the state of an authorization request
+func NewOAuthState() (string, error) {
+	nonce := make([]byte, 16)
+
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(nonce), nil
+}
+
+// AuthorizeURL builds the URL to send the merchant to so that they can approve the install of the app.
+/*
+	The state must be kept, for example in the session, and passed to VerifyCallback when Shopify redirects back.
+*/
+func (oauth OAuth) AuthorizeURL(shop string, state string) (string, error) {
+	if !IsValidShopDomain(shop) {
+		return "", ErrOAuthInvalidShop
+	}
+
+	params := url.Values{}
+
+	params.Add("client_id", oauth.config.ClientID)
+	params.Add("scope", strings.Join(oauth.config.Scopes, ","))
+	params.Add("redirect_uri", oauth.config.RedirectURL)
+	params.Add("state", state)
+
+	if oauth.config.Online {
+		params.Add("grant_options[]", "per-user")
+	}
+
+	return fmt.Sprintf("%v/admin/oauth/authorize?%v", oauth.shopURL(shop), params.Encode()), nil
+}
+
+// VerifyCallback checks that the query of the callback was sent by Shopify for the shop and the state
+func (oauth OAuth) VerifyCallback(query url.Values, state string) error {
+	if !IsValidShopDomain(query.Get("shop")) {
+		return ErrOAuthInvalidShop
+	}
+
+	if !verifyQueryHMAC(oauth.config.ClientSecret, query) {
+		return ErrOAuthInvalidHMAC
+	}
+
+	if state == "" || !hmac.Equal([]byte(query.Get("state")), []byte(state)) {
+		return ErrOAuthInvalidState
+	}
+
+	return nil
+}
+
+// Exchange swaps the authorization code of the callback for an access token
+func (oauth OAuth) Exchange(shop string, code string) (AccessToken, error) {
+	if !IsValidShopDomain(shop) {
+		return AccessToken{}, ErrOAuthInvalidShop
+	}
+
+	request := struct {
+		ClientID     string `json:"client_id"`
+		ClientSecret string `json:"client_secret"`
+		Code         string `json:"code"`
+	}{
+		ClientID:     oauth.config.ClientID,
+		ClientSecret: oauth.config.ClientSecret,
+		Code:         code,
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return AccessToken{}, err
+	}
+
+	url := fmt.Sprintf("%v/admin/oauth/access_token", oauth.shopURL(shop))
+
+	respBody, _, err := oauth.client.Post(url, body, nil)
+	if err != nil {
+		return AccessToken{}, err
+	}
+
+	var response AccessTokenDTO
+	err = json.Unmarshal(respBody, &response)
+	if err != nil {
+		return AccessToken{}, err
+	}
+
+	return response.ToShopify(time.Now()), nil
+}
+
+// Complete verifies the callback, exchanges its code for an access token and builds the shop the app was installed on
+func (oauth OAuth) Complete(query url.Values, state string, optionsFns ...OptionFunc) (Shop, AccessToken, error) {
+	err := oauth.VerifyCallback(query, state)
+	if err != nil {
+		return Shop{}, AccessToken{}, err
+	}
+
+	shop := query.Get("shop")
+
+	token, err := oauth.Exchange(shop, query.Get("code"))
+	if err != nil {
+		return Shop{}, AccessToken{}, err
+	}
+
+	return oauth.Shop(shop, token, optionsFns...), token, nil
+}
+
+// Token exchange grant used to swap a session token for an access token
+const (
+	tokenExchangeGrantType        = "urn:ietf:params:oauth:grant-type:token-exchange"
+	tokenExchangeSubjectTokenType = "urn:ietf:params:oauth:token-type:id_token"
+	onlineAccessTokenType         = "urn:shopify:params:oauth:token-type:online-access-token"
+	offlineAccessTokenType        = "urn:shopify:params:oauth:token-type:offline-access-token"
+)
+
+// ExchangeSessionToken verifies the session token of an embedded app and swaps it for an access token,
+// building the shop the session is for.
+/*
+	An online access token is requested when the config is online, otherwise an offline access token is requested.
+	Example:
+	shop, token, err := oauth.ExchangeSessionToken(strings.TrimPrefix(request.Header.Get("Authorization"), "Bearer "))
+*/
+func (oauth OAuth) ExchangeSessionToken(sessionToken string, optionsFns ...OptionFunc) (Shop, AccessToken, error) {
+	session, err := NewSessionTokenVerifier(oauth.config.ClientID, oauth.config.ClientSecret).Verify(sessionToken)
+	if err != nil {
+		return Shop{}, AccessToken{}, err
+	}
+
+	requestedTokenType := offlineAccessTokenType
+	if oauth.config.Online {
+		requestedTokenType = onlineAccessTokenType
+	}
+
+	request := struct {
+		ClientID           string `json:"client_id"`
+		ClientSecret       string `json:"client_secret"`
+		GrantType          string `json:"grant_type"`
+		SubjectToken       string `json:"subject_token"`
+		SubjectTokenType   string `json:"subject_token_type"`
+		RequestedTokenType string `json:"requested_token_type"`
+	}{
+		ClientID:           oauth.config.ClientID,
+		ClientSecret:       oauth.config.ClientSecret,
+		GrantType:          tokenExchangeGrantType,
+		SubjectToken:       session.Raw,
+		SubjectTokenType:   tokenExchangeSubjectTokenType,
+		RequestedTokenType: requestedTokenType,
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return Shop{}, AccessToken{}, err
+	}
+
+	url := fmt.Sprintf("%v/admin/oauth/access_token", oauth.shopURL(session.Shop))
+
+	respBody, _, err := oauth.client.Post(url, body, nil)
+	if err != nil {
+		return Shop{}, AccessToken{}, err
+	}
+
+	var response AccessTokenDTO
+	err = json.Unmarshal(respBody, &response)
+	if err != nil {
+		return Shop{}, AccessToken{}, err
+	}
+
+	token := response.ToShopify(time.Now())
+
+	return oauth.Shop(session.Shop, token, optionsFns...), token, nil
+}
+
+// Shop builds the shop the access token was granted for.
+/*
+	The options are applied to the shop. It uses the default rate limit unless WithPlusRateLimit or WithRateLimiter
+	is passed.
+*/
+func (oauth OAuth) Shop(shop string, token AccessToken, optionsFns ...OptionFunc) Shop {
+	return NewCustomShop(
+		fmt.Sprintf("%v/admin/api/%v", oauth.shopURL(shop), oauth.config.Version),
+		token.Token,
+		IsDefault,
+		optionsFns...,
+	)
+}
+
+// verifyQueryHMAC checks the hex encoded hmac parameter against the other parameters sorted by name
+func verifyQueryHMAC(secret string, query url.Values) bool {
+	expected, err := hex.DecodeString(query.Get("hmac"))
+	if err != nil || len(expected) == 0 {
+		return false
+	}
+
+	names := make([]string, 0, len(query))
+	for name := range query {
+		if name != "hmac" && name != "signature" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, fmt.Sprintf("%v=%v", name, strings.Join(query[name], ",")))
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strings.Join(pairs, "&")))
+
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// AccessToken is an access token granted to an app by a shop
+type AccessToken struct {
+	// Token is the access token sent in the X-Shopify-Access-Token header.
+	Token string
+	// Scopes are the access scopes granted to the app.
+	Scopes []string
+	// ExpiresAt is when an online access token expires. Zero for offline access tokens.
+	ExpiresAt time.Time
+	// AssociatedUserScopes are the access scopes granted to the app for the user of an online access token.
+	AssociatedUserScopes []string
+	// AssociatedUser is the user an online access token was granted by. Nil for offline access tokens.
+	AssociatedUser *OnlineUser
+}
+
+// IsOnline returns whether the token is tied to a user
+func (token AccessToken) IsOnline() bool {
+	return token.AssociatedUser != nil
+}
+
+// OnlineUser is the staff member that granted an online access token
+type OnlineUser struct {
+	// ID is the ID of the user.
+	ID int64
+	// FirstName is the first name of the user.
+	FirstName string
+	// LastName is the last name of the user.
+	LastName string
+	// Email is the email address of the user.
+	Email string
+	// EmailVerified is whether the user has verified their email address.
+	EmailVerified bool
+	// AccountOwner is whether the user owns the shop.
+	AccountOwner bool
+	// Locale is the locale of the user e.g. en.
+	Locale string
+	// Collaborator is whether the user is a collaborator rather than staff.
+	Collaborator bool
+}
+
+// AccessTokenDTO represents the response of the access token endpoint
+type AccessTokenDTO struct {
+	AccessToken         string         `json:"access_token"`
+	Scope               string         `json:"scope"`
+	ExpiresIn           int64          `json:"expires_in,omitempty"`
+	AssociatedUserScope string         `json:"associated_user_scope,omitempty"`
+	AssociatedUser      *OnlineUserDTO `json:"associated_user,omitempty"`
+}
+
+// ToShopify converts the DTO to the Shopify equivalent, working out the expiry from when the token was granted
+func (dto AccessTokenDTO) ToShopify(grantedAt time.Time) AccessToken {
+	var expiresAt time.Time
+	if dto.ExpiresIn > 0 {
+		expiresAt = grantedAt.Add(time.Duration(dto.ExpiresIn) * time.Second)
+	}
+
+	var associatedUser *OnlineUser
+	if dto.AssociatedUser != nil {
+		user := dto.AssociatedUser.ToShopify()
+		associatedUser = &user
+	}
+
+	return AccessToken{
+		Token:                dto.AccessToken,
+		Scopes:               parseScopes(dto.Scope),
+		ExpiresAt:            expiresAt,
+		AssociatedUserScopes: parseScopes(dto.AssociatedUserScope),
+		AssociatedUser:       associatedUser,
+	}
+}
+
+// OnlineUserDTO represents the user of an online access token
+type OnlineUserDTO struct {
+	ID            int64  `json:"id"`
+	FirstName     string `json:"first_name"`
+	LastName      string `json:"last_name"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	AccountOwner  bool   `json:"account_owner"`
+	Locale        string `json:"locale"`
+	Collaborator  bool   `json:"collaborator"`
+}
+
+// ToShopify converts the DTO to the Shopify equivalent
+func (dto OnlineUserDTO) ToShopify() OnlineUser {
+	return OnlineUser{
+		ID:            dto.ID,
+		FirstName:     dto.FirstName,
+		LastName:      dto.LastName,
+		Email:         dto.Email,
+		EmailVerified: dto.EmailVerified,
+		AccountOwner:  dto.AccountOwner,
+		Locale:        dto.Locale,
+		Collaborator:  dto.Collaborator,
+	}
+}
+
+// parseScopes splits a comma separated list of scopes
+func parseScopes(scope string) []string {
+	scopes := make([]string, 0)
+
+	for _, s := range strings.Split(scope, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			scopes = append(scopes, s)
+		}
+	}
+
+	return scopes
+}
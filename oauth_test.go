@@ -0,0 +1,192 @@
+package httpshopify
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/MOHC-LTD/httpshopify/v2/internal/assertions"
+)
+
+const testOAuthSecret = "hush"
+
+// countingTransport counts the requests sent through it
+type countingTransport struct {
+	requests int
+}
+
+func (transport *countingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	transport.requests++
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func signOAuthQuery(query url.Values) url.Values {
+	message := query.Encode()
+
+	mac := hmac.New(sha256.New, []byte(testOAuthSecret))
+	mac.Write([]byte(message))
+
+	query.Set("hmac", hex.EncodeToString(mac.Sum(nil)))
+
+	return query
+}
+
+func newTestOAuth(server *httptest.Server) OAuth {
+	return NewOAuth(
+		OAuthConfig{
+			ClientID:     "key",
+			ClientSecret: testOAuthSecret,
+			Scopes:       []string{"read_orders", "write_products"},
+			RedirectURL:  "https://example.com/callback",
+			Version:      "2024-01",
+		},
+		WithOAuthShopURL(func(shop string) string {
+			return server.URL
+		}),
+	)
+}
+
+// Tests that the authorize URL requests the scopes, redirect and state
+func TestOAuth_AuthorizeURL(t *testing.T) {
+	oauth := NewOAuth(OAuthConfig{ClientID: "key", Scopes: []string{"read_orders", "write_products"}, RedirectURL: "https://example.com/callback", Online: true})
+
+	authorizeURL, err := oauth.AuthorizeURL("my-shop.myshopify.com", "nonce")
+	if err != nil {
+		assertions.ErrAssertionFailure(t, err)
+	}
+
+	parsed, _ := url.Parse(authorizeURL)
+	query := parsed.Query()
+
+	if parsed.Host != "my-shop.myshopify.com" || parsed.Path != "/admin/oauth/authorize" {
+		assertions.ValueAssertionFailure(t, "https://my-shop.myshopify.com/admin/oauth/authorize", authorizeURL)
+	}
+
+	if query.Get("scope") != "read_orders,write_products" {
+		assertions.ValueAssertionFailure(t, "read_orders,write_products", query.Get("scope"))
+	}
+
+	if query.Get("state") != "nonce" {
+		assertions.ValueAssertionFailure(t, "nonce", query.Get("state"))
+	}
+
+	if query.Get("grant_options[]") != "per-user" {
+		assertions.ValueAssertionFailure(t, "per-user", query.Get("grant_options[]"))
+	}
+}
+
+// Tests that a shop that is not a myshopify.com domain is rejected
+func TestOAuth_AuthorizeURLInvalidShop(t *testing.T) {
+	oauth := NewOAuth(OAuthConfig{})
+
+	for _, shop := range []string{"evil.com", "my-shop.myshopify.com.evil.com", "https://my-shop.myshopify.com", ""} {
+		_, err := oauth.AuthorizeURL(shop, "nonce")
+		if !errors.Is(err, ErrOAuthInvalidShop) {
+			assertions.ValueAssertionFailure(t, ErrOAuthInvalidShop, err)
+		}
+	}
+}
+
+// Tests that a callback with a tampered hmac or a different state is rejected
+func TestOAuth_VerifyCallback(t *testing.T) {
+	oauth := NewOAuth(OAuthConfig{ClientSecret: testOAuthSecret})
+
+	query := signOAuthQuery(url.Values{
+		"code":      {"0907a61c0c8d55e99db179b68161bc00"},
+		"shop":      {"my-shop.myshopify.com"},
+		"state":     {"nonce"},
+		"timestamp": {"1337178173"},
+	})
+
+	err := oauth.VerifyCallback(query, "nonce")
+	if err != nil {
+		assertions.ErrAssertionFailure(t, err)
+	}
+
+	err = oauth.VerifyCallback(query, "other")
+	if !errors.Is(err, ErrOAuthInvalidState) {
+		assertions.ValueAssertionFailure(t, ErrOAuthInvalidState, err)
+	}
+
+	query.Set("code", "tampered")
+	err = oauth.VerifyCallback(query, "nonce")
+	if !errors.Is(err, ErrOAuthInvalidHMAC) {
+		assertions.ValueAssertionFailure(t, ErrOAuthInvalidHMAC, err)
+	}
+}
+
+// Tests that the callback code is exchanged for a token and a shop that uses it is returned
+func TestOAuth_Complete(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/admin/oauth/access_token":
+			var request struct {
+				Code string `json:"code"`
+			}
+			json.NewDecoder(r.Body).Decode(&request)
+
+			if request.Code != "code" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			w.Write([]byte(`{"access_token":"token","scope":"read_orders,write_products","expires_in":86399,"associated_user_scope":"read_orders","associated_user":{"id":902541635,"email":"john@example.com"}}`))
+		case "/admin/api/2024-01/shop.json":
+			if r.Header.Get("X-Shopify-Access-Token") != "token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			w.Write([]byte(`{"shop":{"id":1,"currency":"GBP"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	oauth := newTestOAuth(server)
+
+	query := signOAuthQuery(url.Values{
+		"code":  {"code"},
+		"shop":  {"my-shop.myshopify.com"},
+		"state": {"nonce"},
+	})
+
+	transport := &countingTransport{}
+
+	shop, token, err := oauth.Complete(query, "nonce", WithPlusRateLimit(), WithTransport(transport))
+	if err != nil {
+		assertions.ErrAssertionFailure(t, err)
+	}
+
+	if len(token.Scopes) != 2 {
+		assertions.ValueAssertionFailure(t, 2, len(token.Scopes))
+	}
+
+	if !token.IsOnline() || token.AssociatedUser.ID != 902541635 {
+		assertions.AssertionFailure(t, "expected the token to be online for user 902541635")
+	}
+
+	if token.ExpiresAt.IsZero() {
+		assertions.AssertionFailure(t, "expected the online token to expire")
+	}
+
+	info, err := shop.Info().Get()
+	if err != nil {
+		assertions.ErrAssertionFailure(t, err)
+	}
+
+	if info.Currency != "GBP" {
+		assertions.ValueAssertionFailure(t, "GBP", info.Currency)
+	}
+
+	if transport.requests != 1 {
+		assertions.ValueAssertionFailure(t, 1, transport.requests)
+	}
+}
@@ -1,11 +1,23 @@
 package httpshopify
 
-import "time"
+import (
+	httpCode "net/http"
+	"time"
+)
 
 type Options struct {
 	retryCount        int
 	retryBaseDuration time.Duration
 	retryMaxDuration  time.Duration
+	tokenSource       TokenSource
+	transport         httpCode.RoundTripper
+	rateLimiter       RateLimiter
+	plus              bool
+	priorityReserve   *int
+	circuitBreaker    *CircuitBreaker
+	metrics           MetricsSink
+	tracer            Tracer
+	versionMonitor    *VersionMonitor
 }
 
 // OptionFunc is a function that sets options on the Options struct
@@ -21,3 +33,11 @@ func WithExponentialBackoff(retryCount int, retryBaseDuration time.Duration, ret
 		o.retryMaxDuration = retryMaxDuration
 	}
 }
+
+// WithTransport configures the client to send requests with the transport.
+// Sharing a transport between shops lets them share a pool of connections.
+func WithTransport(transport httpCode.RoundTripper) OptionFunc {
+	return func(o *Options) {
+		o.transport = transport
+	}
+}
@@ -29,9 +29,12 @@ func (repository orderRepository) List(query shopify.OrderQuery) (shopify.Orders
 
 	url := repository.createURL(fmt.Sprintf("orders.json%v", parseOrderQuery(query)))
 
+	client, span := repository.client.StartOperation("orders.List", url)
+
 	for {
-		body, headers, err := repository.client.Get(url, nil)
+		body, headers, err := client.Get(url, nil)
 		if err != nil {
+			span.End(err)
 			return nil, err
 		}
 
@@ -53,13 +56,16 @@ func (repository orderRepository) List(query shopify.OrderQuery) (shopify.Orders
 		url = links.Next
 	}
 
+	span.SetAttribute(http.AttributeRecords, len(orders))
+	span.End(nil)
+
 	return orders, nil
 }
 
 func (repository orderRepository) Get(id int64) (shopify.Order, error) {
 	url := repository.createURL(fmt.Sprintf("orders/%v.json", id))
 
-	body, _, err := repository.client.Get(url, nil)
+	body, _, err := repository.client.WithOperation("orders.Get").Get(url, nil)
 	if err != nil {
 		return shopify.Order{}, err
 	}
@@ -82,7 +88,7 @@ func (repository orderRepository) Get(id int64) (shopify.Order, error) {
 func (repository orderRepository) Open(id int64) (shopify.Order, error) {
 	url := repository.createURL(fmt.Sprintf("orders/%v/open.json", id))
 
-	body, _, err := repository.client.Post(url, nil, nil)
+	body, _, err := repository.client.WithOperation("orders.Open").Post(url, nil, nil)
 	if err != nil {
 		return shopify.Order{}, err
 	}
@@ -101,7 +107,7 @@ func (repository orderRepository) Open(id int64) (shopify.Order, error) {
 func (repository orderRepository) Close(id int64) error {
 	url := repository.createURL(fmt.Sprintf("orders/%v/close.json", id))
 
-	_, _, err := repository.client.Post(url, nil, nil)
+	_, _, err := repository.client.WithOperation("orders.Close").Post(url, nil, nil)
 	if err != nil {
 		return err
 	}
@@ -111,6 +117,11 @@ func (repository orderRepository) Close(id int64) error {
 
 func (repository orderRepository) UpdateMetafield(orderID int64, metafield shopify.Metafield) (shopify.Metafield, error) {
 
+	value, err := EncodeMetafieldValue(metafield.Type, metafield.Value)
+	if err != nil {
+		return shopify.Metafield{}, err
+	}
+
 	bodyData := struct {
 		Metafield metafieldDTO `json:"metafield"`
 	}{
@@ -120,7 +131,7 @@ func (repository orderRepository) UpdateMetafield(orderID int64, metafield shopi
 			Key:           metafield.Key,
 			Namespace:     metafield.Namespace,
 			OwnerID:       metafield.Resource.OwnerID,
-			Value:         metafield.Value,
+			Value:         value,
 			Type:          metafield.Type,
 			OwnerResource: metafield.Resource.OwnerResource,
 		},
@@ -133,7 +144,7 @@ func (repository orderRepository) UpdateMetafield(orderID int64, metafield shopi
 
 	url := repository.createURL(fmt.Sprintf("orders/%d/metafields/%d.json", orderID, metafield.ID))
 
-	responseBody, _, err := repository.client.Put(url, body, nil)
+	responseBody, _, err := repository.client.WithOperation("orders.UpdateMetafield").Put(url, body, nil)
 	if err != nil {
 		return shopify.Metafield{}, err
 	}
@@ -151,13 +162,18 @@ func (repository orderRepository) UpdateMetafield(orderID int64, metafield shopi
 
 func (repository orderRepository) CreateMetafield(orderID int64, metafield shopify.Metafield) (shopify.Metafield, error) {
 
+	value, err := EncodeMetafieldValue(metafield.Type, metafield.Value)
+	if err != nil {
+		return shopify.Metafield{}, err
+	}
+
 	bodyData := struct {
 		Metafield metafieldDTO `json:"metafield"`
 	}{
 		Metafield: metafieldDTO{
 			Key:       metafield.Key,
 			Namespace: metafield.Namespace,
-			Value:     metafield.Value,
+			Value:     value,
 			Type:      metafield.Type,
 		},
 	}
@@ -169,7 +185,7 @@ func (repository orderRepository) CreateMetafield(orderID int64, metafield shopi
 
 	url := repository.createURL(fmt.Sprintf("orders/%d/metafields.json", orderID))
 
-	responseBody, _, err := repository.client.Post(url, body, nil)
+	responseBody, _, err := repository.client.WithOperation("orders.CreateMetafield").Post(url, body, nil)
 	if err != nil {
 		return shopify.Metafield{}, err
 	}
@@ -199,7 +215,7 @@ func (repository orderRepository) Create(order shopify.Order) (shopify.Order, er
 		return shopify.Order{}, err
 	}
 
-	responseBody, _, err := repository.client.Post(url, body, nil)
+	responseBody, _, err := repository.client.WithOperation("orders.Create").Post(url, body, nil)
 	if err != nil {
 		return shopify.Order{}, err
 	}
@@ -233,7 +249,7 @@ func (repository orderRepository) Update(order shopify.Order) (shopify.Order, er
 
 	url := repository.createURL(fmt.Sprintf("orders/%d.json", updateOrderDTO.ID))
 
-	respBody, _, err := repository.client.Put(url, body, nil)
+	respBody, _, err := repository.client.WithOperation("orders.Update").Put(url, body, nil)
 	if err != nil {
 		return shopify.Order{}, err
 	}
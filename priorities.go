@@ -0,0 +1,29 @@
+package httpshopify
+
+import "github.com/MOHC-LTD/httpshopify/v2/internal/http"
+
+// Priority is the priority class of the requests of a shop. Higher priorities are sent first when requests are waiting for the rate limit.
+type Priority int
+
+const (
+	// PriorityLow is for background work such as bulk jobs
+	PriorityLow = Priority(http.PriorityLow)
+	// PriorityNormal is the default priority
+	PriorityNormal = Priority(http.PriorityNormal)
+	// PriorityHigh is for interactive work that a person is waiting on, such as a support agent looking up an order
+	PriorityHigh = Priority(http.PriorityHigh)
+)
+
+// QueueDepth is the number of requests waiting for the rate limit in each priority class
+type QueueDepth map[Priority]int
+
+// WithPriorityReserve configures how often a lower priority request is sent while higher priority requests are waiting.
+/*
+	One in every reserve requests goes to the lowest priority that has a request waiting, so that background work
+	keeps making progress while the shop is busy. The default is one in five. Zero serves strictly by priority.
+*/
+func WithPriorityReserve(reserve int) OptionFunc {
+	return func(o *Options) {
+		o.priorityReserve = &reserve
+	}
+}
@@ -0,0 +1,139 @@
+package httpshopify
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MOHC-LTD/httpshopify/v2/internal/assertions"
+)
+
+// gateRateLimiter lets one request through each time it is opened
+type gateRateLimiter struct {
+	gate chan struct{}
+}
+
+func (limiter gateRateLimiter) Wait(ctx context.Context) error {
+	<-limiter.gate
+	return nil
+}
+
+func (limiter gateRateLimiter) Observe(used int, size int, retryAfter time.Duration) {}
+
+func waitFor(t *testing.T, condition func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// Tests that requests waiting for the rate limit are sent highest priority first
+func TestShop_WithPriority(t *testing.T) {
+	var mutex sync.Mutex
+	paths := make([]string, 0)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		paths = append(paths, r.URL.Path)
+		mutex.Unlock()
+
+		w.Write([]byte(`{"shop":{},"access_scopes":[]}`))
+	}))
+	defer server.Close()
+
+	sent := func() int {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return len(paths)
+	}
+
+	limiter := gateRateLimiter{make(chan struct{})}
+	shop := NewCustomShop(server.URL+"/admin/api/2024-01", "token", IsDefault, WithRateLimiter(limiter))
+
+	low := shop.WithPriority(PriorityLow)
+	high := shop.WithPriority(PriorityHigh)
+
+	var wait sync.WaitGroup
+	run := func(fn func()) {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			fn()
+		}()
+	}
+
+	// The first request holds the turn while the rest queue behind it
+	run(func() { low.AccessScopes().List() })
+	waitFor(t, func() bool { return shop.QueueDepth()[PriorityLow] == 0 })
+
+	run(func() { low.AccessScopes().List() })
+	run(func() { low.AccessScopes().List() })
+	waitFor(t, func() bool { return shop.QueueDepth()[PriorityLow] == 2 })
+
+	run(func() { high.Info().Get() })
+	run(func() { high.Info().Get() })
+	waitFor(t, func() bool { return shop.QueueDepth()[PriorityHigh] == 2 })
+
+	for i := 1; i <= 5; i++ {
+		limiter.gate <- struct{}{}
+		waitFor(t, func() bool { return sent() == i })
+	}
+
+	wait.Wait()
+
+	expected := []string{
+		"/admin/oauth/access_scopes.json",
+		"/admin/api/2024-01/shop.json",
+		"/admin/api/2024-01/shop.json",
+		"/admin/oauth/access_scopes.json",
+		"/admin/oauth/access_scopes.json",
+	}
+
+	for i := range expected {
+		if paths[i] != expected[i] {
+			assertions.ValueAssertionFailure(t, expected, paths)
+			return
+		}
+	}
+}
+
+// Tests that requests with a priority outside the classes are queued in the closest class rather than never sent
+func TestShop_WithPriority_OutOfRange(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"shop":{}}`))
+	}))
+	defer server.Close()
+
+	limiter := gateRateLimiter{make(chan struct{})}
+	shop := NewCustomShop(server.URL+"/admin/api/2024-01", "token", IsDefault, WithRateLimiter(limiter))
+
+	done := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := shop.WithPriority(Priority(5)).Info().Get()
+			done <- err
+		}()
+	}
+
+	// One request holds the turn while the other queues behind it
+	waitFor(t, func() bool { return shop.QueueDepth()[PriorityHigh] == 1 })
+
+	for i := 0; i < 2; i++ {
+		limiter.gate <- struct{}{}
+
+		select {
+		case err := <-done:
+			if err != nil {
+				assertions.ErrAssertionFailure(t, err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the request")
+		}
+	}
+}
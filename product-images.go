@@ -1,8 +1,11 @@
 package httpshopify
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"time"
 
 	"github.com/MOHC-LTD/httpshopify/v2/internal/http"
@@ -10,6 +13,48 @@ import (
 	"github.com/MOHC-LTD/shopify/v2"
 )
 
+// MaxProductImageSize is the largest image, in bytes, that Shopify accepts for a product
+const MaxProductImageSize = 20 * 1024 * 1024
+
+// ProductImageUpdate holds the fields of a product image to change.
+/*
+	Only the fields that are not nil are sent to Shopify, leaving the rest of the image untouched.
+	Setting VariantIDs to an empty slice detaches the image from all variants.
+*/
+type ProductImageUpdate struct {
+	Alt        *string
+	Position   *int
+	VariantIDs *[]int64
+}
+
+// ProductImageRepository maintains the product images for products in the shop.
+/*
+	The repository returned by Shop.ProductImages also implements this interface.
+*/
+type ProductImageRepository interface {
+	shopify.ProductImageRepository
+	// Create creates a product image from the remote image at the SRC of the image
+	Create(productID int64, image shopify.ProductImage) (shopify.ProductImage, error)
+	// Upload creates a product image from the bytes read from the attachment.
+	/*
+		The alt text, position and variant IDs of the image are also applied.
+		The attachment must not be empty or larger than MaxProductImageSize.
+	*/
+	Upload(productID int64, filename string, attachment io.Reader, image shopify.ProductImage) (shopify.ProductImage, error)
+	// Update updates the fields of a product image that are set on the update
+	Update(productID int64, imageID int64, update ProductImageUpdate) (shopify.ProductImage, error)
+	// Delete deletes a product image
+	Delete(productID int64, imageID int64) error
+	// Reorder moves the images of a product into the order of the passed image IDs.
+	/*
+		Images that are left out keep their relative order after the passed ones, as Shopify would otherwise delete them.
+		The reorder is not atomic. The current images are listed before the new order is sent, so an image added to
+		the product in between is deleted by Shopify and an image removed in between may fail the reorder. Avoid editing
+		the images of the product while reordering them.
+	*/
+	Reorder(productID int64, imageIDs []int64) (shopify.ProductImages, error)
+}
+
 type productImagesRepository struct {
 	client    http.Client
 	createURL func(endpoint string) string
@@ -52,9 +97,12 @@ func (repository productImagesRepository) List(productID int64, query shopify.Pr
 
 	url := repository.createURL(fmt.Sprintf("products/%v/images.json%v", productID, parseProductImagesQuery(query)))
 
+	client, span := repository.client.StartOperation("productImages.List", url)
+
 	for {
-		body, headers, err := repository.client.Get(url, nil)
+		body, headers, err := client.Get(url, nil)
 		if err != nil {
+			span.End(err)
 			return nil, err
 		}
 
@@ -76,9 +124,219 @@ func (repository productImagesRepository) List(productID int64, query shopify.Pr
 		url = links.Next
 	}
 
+	span.SetAttribute(http.AttributeRecords, len(productImages))
+	span.End(nil)
+
 	return productImages, nil
 }
 
+func (repository productImagesRepository) Create(productID int64, image shopify.ProductImage) (shopify.ProductImage, error) {
+	createDTO := productImageCreateDTO{
+		SRC:        image.SRC,
+		Alt:        image.Alt,
+		Position:   image.Position,
+		VariantIDs: image.VariantIDs,
+	}
+
+	return repository.create("productImages.Create", productID, createDTO)
+}
+
+func (repository productImagesRepository) Upload(productID int64, filename string, attachment io.Reader, image shopify.ProductImage) (shopify.ProductImage, error) {
+	data, err := io.ReadAll(io.LimitReader(attachment, MaxProductImageSize+1))
+	if err != nil {
+		return shopify.ProductImage{}, err
+	}
+
+	if len(data) == 0 {
+		return shopify.ProductImage{}, ErrProductImageEmpty
+	}
+
+	if len(data) > MaxProductImageSize {
+		return shopify.ProductImage{}, NewErrProductImageTooLarge(MaxProductImageSize)
+	}
+
+	createDTO := productImageCreateDTO{
+		Attachment: base64.StdEncoding.EncodeToString(data),
+		Filename:   filename,
+		Alt:        image.Alt,
+		Position:   image.Position,
+		VariantIDs: image.VariantIDs,
+	}
+
+	return repository.create("productImages.Upload", productID, createDTO)
+}
+
+// create creates the image, tracing the request as the operation
+func (repository productImagesRepository) create(operation string, productID int64, createDTO productImageCreateDTO) (shopify.ProductImage, error) {
+	request := struct {
+		Image productImageCreateDTO `json:"image"`
+	}{
+		Image: createDTO,
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return shopify.ProductImage{}, err
+	}
+
+	url := repository.createURL(fmt.Sprintf("products/%d/images.json", productID))
+
+	respBody, _, err := repository.client.WithOperation(operation).Post(url, body, nil)
+	if err != nil {
+		return shopify.ProductImage{}, err
+	}
+
+	var response struct {
+		Image ProductImageDTO `json:"image"`
+	}
+
+	err = json.Unmarshal(respBody, &response)
+	if err != nil {
+		return shopify.ProductImage{}, err
+	}
+
+	return response.Image.ToShopify(), nil
+}
+
+func (repository productImagesRepository) Update(productID int64, imageID int64, update ProductImageUpdate) (shopify.ProductImage, error) {
+	request := struct {
+		Image productImageUpdateDTO `json:"image"`
+	}{
+		Image: productImageUpdateDTO{
+			ID:         imageID,
+			Alt:        update.Alt,
+			Position:   update.Position,
+			VariantIDs: update.VariantIDs,
+		},
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return shopify.ProductImage{}, err
+	}
+
+	url := repository.createURL(fmt.Sprintf("products/%d/images/%d.json", productID, imageID))
+
+	respBody, _, err := repository.client.WithOperation("productImages.Update").Put(url, body, nil)
+	if err != nil {
+		return shopify.ProductImage{}, err
+	}
+
+	var response struct {
+		Image ProductImageDTO `json:"image"`
+	}
+
+	err = json.Unmarshal(respBody, &response)
+	if err != nil {
+		return shopify.ProductImage{}, err
+	}
+
+	return response.Image.ToShopify(), nil
+}
+
+func (repository productImagesRepository) Delete(productID int64, imageID int64) error {
+	url := repository.createURL(fmt.Sprintf("products/%d/images/%d.json", productID, imageID))
+
+	_, _, err := repository.client.WithOperation("productImages.Delete").Delete(url, nil)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repository productImagesRepository) Reorder(productID int64, imageIDs []int64) (shopify.ProductImages, error) {
+	current, err := repository.List(productID, shopify.ProductImageQuery{})
+	if err != nil {
+		return nil, err
+	}
+
+	onProduct := make(map[int64]bool, len(current))
+	for _, image := range current {
+		onProduct[image.ID] = true
+	}
+
+	// Shopify deletes any image missing from the update, so every current image is sent
+	ordered := make([]int64, 0, len(current))
+	placed := make(map[int64]bool, len(current))
+
+	for _, imageID := range imageIDs {
+		if !onProduct[imageID] {
+			return nil, NewErrProductImageNotFound(productID, imageID)
+		}
+
+		if !placed[imageID] {
+			ordered = append(ordered, imageID)
+			placed[imageID] = true
+		}
+	}
+
+	for _, image := range current {
+		if !placed[image.ID] {
+			ordered = append(ordered, image.ID)
+			placed[image.ID] = true
+		}
+	}
+
+	images := make([]productImageUpdateDTO, 0, len(ordered))
+	for i, imageID := range ordered {
+		position := i + 1
+		images = append(images, productImageUpdateDTO{ID: imageID, Position: &position})
+	}
+
+	request := struct {
+		Product struct {
+			ID     int64                   `json:"id"`
+			Images []productImageUpdateDTO `json:"images"`
+		} `json:"product"`
+	}{}
+	request.Product.ID = productID
+	request.Product.Images = images
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	url := repository.createURL(fmt.Sprintf("products/%d.json", productID))
+
+	respBody, _, err := repository.client.WithOperation("productImages.Reorder").Put(url, body, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Product struct {
+			Images ProductImageDTOs `json:"images"`
+		} `json:"product"`
+	}
+
+	err = json.Unmarshal(respBody, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	return response.Product.Images.ToShopify(), nil
+}
+
+// productImageCreateDTO represents a Shopify product image in HTTP create requests
+type productImageCreateDTO struct {
+	SRC        string  `json:"src,omitempty"`
+	Attachment string  `json:"attachment,omitempty"`
+	Filename   string  `json:"filename,omitempty"`
+	Alt        string  `json:"alt,omitempty"`
+	Position   int     `json:"position,omitempty"`
+	VariantIDs []int64 `json:"variant_ids,omitempty"`
+}
+
+// productImageUpdateDTO represents the changed fields of a Shopify product image in HTTP update requests
+type productImageUpdateDTO struct {
+	ID         int64    `json:"id"`
+	Alt        *string  `json:"alt,omitempty"`
+	Position   *int     `json:"position,omitempty"`
+	VariantIDs *[]int64 `json:"variant_ids,omitempty"`
+}
+
 // ProductImageDTO represents a Shopify product images in HTTP requests and responses
 type ProductImageDTO struct {
 	ImageDTO
@@ -172,3 +430,40 @@ func parseProductImagesQuery(query shopify.ProductImageQuery) string {
 
 	return queryString
 }
+
+// ErrProductImageEmpty is thrown when an image upload has no content
+var ErrProductImageEmpty = errors.New("product image attachment is empty")
+
+// ErrProductImageTooLarge is thrown when an image upload is larger than Shopify accepts
+type ErrProductImageTooLarge struct {
+	maxSize int
+}
+
+func (err ErrProductImageTooLarge) Error() string {
+	return fmt.Sprintf("product image attachment is larger than %v bytes", err.maxSize)
+}
+
+// NewErrProductImageTooLarge builds the error
+func NewErrProductImageTooLarge(maxSize int) ErrProductImageTooLarge {
+	return ErrProductImageTooLarge{
+		maxSize,
+	}
+}
+
+// ErrProductImageNotFound is thrown when an image is not one of the images of a product
+type ErrProductImageNotFound struct {
+	productID int64
+	imageID   int64
+}
+
+func (err ErrProductImageNotFound) Error() string {
+	return fmt.Sprintf("image with id %v is not an image of product %v", err.imageID, err.productID)
+}
+
+// NewErrProductImageNotFound builds the error
+func NewErrProductImageNotFound(productID int64, imageID int64) ErrProductImageNotFound {
+	return ErrProductImageNotFound{
+		productID,
+		imageID,
+	}
+}
@@ -1,10 +1,16 @@
 package httpshopify
 
 import (
+	"bytes"
+	"encoding/json"
+	"io"
+	httpCode "net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/MOHC-LTD/httpshopify/v2/internal/assertions"
+	"github.com/MOHC-LTD/httpshopify/v2/internal/http"
 	"github.com/MOHC-LTD/shopify/v2"
 )
 
@@ -99,3 +105,88 @@ func TestBuildProductImageDTOEmptyTimes(t *testing.T) {
 		assertions.ValueAssertionFailure(t, updatedAt, productImageDTO.UpdatedAt)
 	}
 }
+
+// Tests that an empty image is rejected before it is uploaded
+func TestProductImagesRepository_UploadEmpty(t *testing.T) {
+	repository := newProductImagesRepository(http.NewClient(), func(endpoint string) string { return endpoint })
+
+	_, err := repository.Upload(1, "empty.png", bytes.NewReader(nil), shopify.ProductImage{})
+
+	if err != ErrProductImageEmpty {
+		assertions.ValueAssertionFailure(t, ErrProductImageEmpty, err)
+	}
+}
+
+// Tests that an image larger than Shopify accepts is rejected before it is uploaded
+func TestProductImagesRepository_UploadTooLarge(t *testing.T) {
+	repository := newProductImagesRepository(http.NewClient(), func(endpoint string) string { return endpoint })
+
+	_, err := repository.Upload(1, "large.png", bytes.NewReader(make([]byte, MaxProductImageSize+1)), shopify.ProductImage{})
+
+	if _, ok := err.(ErrProductImageTooLarge); !ok {
+		assertions.TypeAssertionFailure(t, ErrProductImageTooLarge{}, err)
+	}
+}
+
+// Tests that images left out of a reorder are kept after the passed ones rather than deleted
+func TestProductImagesRepository_ReorderOmitted(t *testing.T) {
+	var sent []productImageUpdateDTO
+	server := httptest.NewServer(httpCode.HandlerFunc(func(w httpCode.ResponseWriter, r *httpCode.Request) {
+		if r.Method == httpCode.MethodGet {
+			w.Write([]byte(`{"images":[{"id":10,"position":1},{"id":11,"position":2},{"id":12,"position":3}]}`))
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+
+		var request struct {
+			Product struct {
+				Images []productImageUpdateDTO `json:"images"`
+			} `json:"product"`
+		}
+		json.Unmarshal(body, &request)
+		sent = request.Product.Images
+
+		w.Write([]byte(`{"product":{"images":[]}}`))
+	}))
+	defer server.Close()
+
+	shop := NewCustomShop(server.URL, "token", IsDefault)
+
+	_, err := shop.ExtendedProductImages().Reorder(1, []int64{12, 10})
+	if err != nil {
+		assertions.ErrAssertionFailure(t, err)
+	}
+
+	expected := []int64{12, 10, 11}
+	if len(sent) != len(expected) {
+		assertions.ValueAssertionFailure(t, expected, sent)
+		return
+	}
+
+	for i, image := range sent {
+		if image.ID != expected[i] || image.Position == nil || *image.Position != i+1 {
+			assertions.ValueAssertionFailure(t, expected, sent)
+		}
+	}
+}
+
+// Tests that a reorder with an image that is not on the product is rejected without updating the product
+func TestProductImagesRepository_ReorderUnknown(t *testing.T) {
+	server := httptest.NewServer(httpCode.HandlerFunc(func(w httpCode.ResponseWriter, r *httpCode.Request) {
+		if r.Method != httpCode.MethodGet {
+			assertions.AssertionFailure(t, "expected the product not to be updated")
+		}
+
+		w.Write([]byte(`{"images":[{"id":10,"position":1}]}`))
+	}))
+	defer server.Close()
+
+	shop := NewCustomShop(server.URL, "token", IsDefault)
+
+	_, err := shop.ExtendedProductImages().Reorder(1, []int64{99})
+
+	if _, ok := err.(ErrProductImageNotFound); !ok {
+		assertions.TypeAssertionFailure(t, ErrProductImageNotFound{}, err)
+	}
+}
@@ -55,7 +55,7 @@ func (repository productRepository) Create(product shopify.Product) (shopify.Pro
 
 	url := repository.createURL("products.json")
 
-	respBody, _, err := repository.client.Post(url, body, nil)
+	respBody, _, err := repository.client.WithOperation("products.Create").Post(url, body, nil)
 	if err != nil {
 		return shopify.Product{}, err
 	}
@@ -104,7 +104,7 @@ func (repository productRepository) Update(product shopify.Product) (shopify.Pro
 
 	url := repository.createURL(fmt.Sprintf("products/%d.json", updateDTO.ID))
 
-	respBody, _, err := repository.client.Put(url, body, nil)
+	respBody, _, err := repository.client.WithOperation("products.Update").Put(url, body, nil)
 	if err != nil {
 		return shopify.Product{}, err
 	}
@@ -124,7 +124,7 @@ func (repository productRepository) Update(product shopify.Product) (shopify.Pro
 func (repository productRepository) Get(id int64) (shopify.Product, error) {
 	url := repository.createURL(fmt.Sprintf("products/%v.json", id))
 
-	body, _, err := repository.client.Get(url, nil)
+	body, _, err := repository.client.WithOperation("products.Get").Get(url, nil)
 	if err != nil {
 		return shopify.Product{}, err
 	}
@@ -149,9 +149,12 @@ func (repository productRepository) List(query shopify.ProductQuery) (shopify.Pr
 
 	url := repository.createURL(fmt.Sprintf("products.json%v", parseProductQuery(query)))
 
+	client, span := repository.client.StartOperation("products.List", url)
+
 	for {
-		body, headers, err := repository.client.Get(url, nil)
+		body, headers, err := client.Get(url, nil)
 		if err != nil {
+			span.End(err)
 			return nil, err
 		}
 
@@ -173,13 +176,16 @@ func (repository productRepository) List(query shopify.ProductQuery) (shopify.Pr
 		url = links.Next
 	}
 
+	span.SetAttribute(http.AttributeRecords, len(products))
+	span.End(nil)
+
 	return products, nil
 }
 
 func (repository productRepository) Delete(productID int64) error {
 	url := repository.createURL(fmt.Sprintf("products/%v.json", productID))
 
-	_, _, err := repository.client.Delete(url, nil)
+	_, _, err := repository.client.WithOperation("products.Delete").Delete(url, nil)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,237 @@
+package httpshopify
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimiter decides when a shop may send a request.
+/*
+	Wait is called before every request. Observe is called with the bucket reported by Shopify in the
+	X-Shopify-Shop-Api-Call-Limit header of every response, and with the Retry-After of throttled responses,
+	so that the limiter can correct its view of the bucket. Implementations must be safe for concurrent use.
+*/
+type RateLimiter interface {
+	// Wait blocks until a request may be sent
+	Wait(ctx context.Context) error
+	// Observe reports the state of the bucket seen on a response. Used and size are zero when it was not reported,
+	// and retryAfter is zero unless the request was throttled.
+	Observe(used int, size int, retryAfter time.Duration)
+}
+
+// WithRateLimiter configures the shop to limit its requests with the limiter instead of the default rate limit.
+/*
+	Example:
+	limiter := httpshopify.NewSharedRateLimiter(store, "my-shop", httpshopify.DefaultLeakRate, httpshopify.DefaultBucketSize)
+	shop := httpshopify.NewShop("my-shop", "shppy_21u92h2184ho912h29r01", "2024-01", httpshopify.WithRateLimiter(limiter))
+*/
+func WithRateLimiter(limiter RateLimiter) OptionFunc {
+	return func(o *Options) {
+		o.rateLimiter = limiter
+	}
+}
+
+// Sizes of the REST API leaky bucket. Source: https://shopify.dev/api/usage/rate-limits.
+const (
+	// DefaultLeakRate is the number of requests per second that leak from the bucket of a shop
+	DefaultLeakRate = 2
+	// DefaultBucketSize is the number of requests the bucket of a shop holds
+	DefaultBucketSize = 40
+	// PlusLeakRate is the number of requests per second that leak from the bucket of a Shopify Plus shop
+	PlusLeakRate = 20
+	// PlusBucketSize is the number of requests the bucket of a Shopify Plus shop holds
+	PlusBucketSize = 400
+)
+
+// RateLimitStore holds leaky buckets that are shared by every process that uses them, for example in Redis or Postgres.
+/*
+	Each method must be atomic for a key, as many processes call it at once. MemoryRateLimitStore implements the
+	same rules in memory and can be used as a reference and in tests.
+*/
+type RateLimitStore interface {
+	// Take adds a request to the bucket if there is room after leaking, returning zero.
+	// When the bucket is full or paused it is left unchanged, and the time to wait before trying again is returned.
+	Take(ctx context.Context, key string, leakRate float64, size int) (time.Duration, error)
+	// Sync raises the level of the bucket to the level Shopify reported.
+	// Implementations must leak the bucket first and then only ever raise it to the larger of its level and used,
+	// as a report from a response that was sent earlier or arrived out of order does not count the requests taken
+	// since, and lowering the bucket to it would let too many requests through.
+	Sync(ctx context.Context, key string, used int, leakRate float64) error
+	// Pause stops requests from being taken from the bucket until the time
+	Pause(ctx context.Context, key string, until time.Time) error
+}
+
+// SharedRateLimiter is a RateLimiter whose bucket is held in a store, so that it is shared between processes
+type SharedRateLimiter struct {
+	store    RateLimitStore
+	key      string
+	leakRate float64
+	size     int
+	now      func() time.Time
+}
+
+// NewSharedRateLimiter builds a limiter that keeps the bucket under the key of the store.
+/*
+	Every process that sends requests to the same shop with the same app must use the same key.
+*/
+func NewSharedRateLimiter(store RateLimitStore, key string, leakRate float64, size int) SharedRateLimiter {
+	return SharedRateLimiter{
+		store:    store,
+		key:      key,
+		leakRate: leakRate,
+		size:     size,
+		now:      time.Now,
+	}
+}
+
+// Wait blocks until there is room in the shared bucket
+func (limiter SharedRateLimiter) Wait(ctx context.Context) error {
+	for {
+		wait, err := limiter.store.Take(ctx, limiter.key, limiter.leakRate, limiter.size)
+		if err != nil {
+			return err
+		}
+
+		if wait <= 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(wait)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Observe raises the shared bucket to the level reported by Shopify, pausing it when a request was throttled.
+/*
+	Errors from the store are ignored, as the bucket is corrected again by the next response.
+*/
+func (limiter SharedRateLimiter) Observe(used int, size int, retryAfter time.Duration) {
+	ctx := context.Background()
+
+	if size > 0 {
+		limiter.store.Sync(ctx, limiter.key, used, limiter.leakRate)
+	}
+
+	if retryAfter > 0 {
+		limiter.store.Pause(ctx, limiter.key, limiter.now().Add(retryAfter))
+	}
+}
+
+// LocalRateLimiter is a RateLimiter whose bucket is held in this process.
+/*
+	It behaves like the shared limiter but without a store, so it suits a single process that still wants its
+	bucket corrected by the levels Shopify reports.
+*/
+type LocalRateLimiter struct {
+	SharedRateLimiter
+}
+
+// NewLocalRateLimiter builds a limiter with its own bucket
+func NewLocalRateLimiter(leakRate float64, size int) LocalRateLimiter {
+	return LocalRateLimiter{
+		NewSharedRateLimiter(NewMemoryRateLimitStore(), "local", leakRate, size),
+	}
+}
+
+type leakyBucket struct {
+	level       float64
+	updatedAt   time.Time
+	pausedUntil time.Time
+}
+
+// MemoryRateLimitStore is a RateLimitStore held in memory.
+/*
+	It is only shared within a process, so it is intended for tests and for the local limiter.
+*/
+type MemoryRateLimitStore struct {
+	mutex   sync.Mutex
+	buckets map[string]*leakyBucket
+	now     func() time.Time
+}
+
+// NewMemoryRateLimitStore builds an empty in-memory store
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		buckets: make(map[string]*leakyBucket),
+		now:     time.Now,
+	}
+}
+
+// Take adds a request to the bucket if there is room after leaking, otherwise returning how long to wait
+func (store *MemoryRateLimitStore) Take(ctx context.Context, key string, leakRate float64, size int) (time.Duration, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	now := store.now()
+	bucket := store.leak(key, leakRate, now)
+
+	if now.Before(bucket.pausedUntil) {
+		return bucket.pausedUntil.Sub(now), nil
+	}
+
+	if bucket.level+1 > float64(size) {
+		overflow := bucket.level + 1 - float64(size)
+		return time.Duration(overflow / leakRate * float64(time.Second)), nil
+	}
+
+	bucket.level++
+
+	return 0, nil
+}
+
+// Sync raises the level of the bucket to the level Shopify reported, after leaking it
+func (store *MemoryRateLimitStore) Sync(ctx context.Context, key string, used int, leakRate float64) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	bucket := store.leak(key, leakRate, store.now())
+	bucket.level = math.Max(bucket.level, float64(used))
+
+	return nil
+}
+
+// Pause stops requests from being taken from the bucket until the time
+func (store *MemoryRateLimitStore) Pause(ctx context.Context, key string, until time.Time) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	bucket := store.bucket(key)
+	if until.After(bucket.pausedUntil) {
+		bucket.pausedUntil = until
+	}
+
+	return nil
+}
+
+// bucket returns the bucket of the key, creating it when needed. The mutex must be held.
+func (store *MemoryRateLimitStore) bucket(key string) *leakyBucket {
+	bucket, ok := store.buckets[key]
+	if !ok {
+		bucket = &leakyBucket{updatedAt: store.now()}
+		store.buckets[key] = bucket
+	}
+
+	return bucket
+}
+
+// leak empties the bucket by the requests that have leaked since it was last updated. The mutex must be held.
+func (store *MemoryRateLimitStore) leak(key string, leakRate float64, now time.Time) *leakyBucket {
+	bucket := store.bucket(key)
+
+	leaked := now.Sub(bucket.updatedAt).Seconds() * leakRate
+	bucket.level -= leaked
+	if bucket.level < 0 {
+		bucket.level = 0
+	}
+	bucket.updatedAt = now
+
+	return bucket
+}
@@ -0,0 +1,134 @@
+package httpshopify
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MOHC-LTD/httpshopify/v2/internal/assertions"
+)
+
+func newTestRateLimitStore() (*MemoryRateLimitStore, *time.Time) {
+	store := NewMemoryRateLimitStore()
+
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	return store, &now
+}
+
+// Tests that the bucket fills up, asks to wait while full and has room again once requests have leaked
+func TestMemoryRateLimitStore_Take(t *testing.T) {
+	store, now := newTestRateLimitStore()
+	ctx := context.Background()
+
+	for i := 0; i < 4; i++ {
+		wait, _ := store.Take(ctx, "shop", 2, 4)
+		if wait != 0 {
+			assertions.ValueAssertionFailure(t, time.Duration(0), wait)
+		}
+	}
+
+	wait, _ := store.Take(ctx, "shop", 2, 4)
+	if wait != 500*time.Millisecond {
+		assertions.ValueAssertionFailure(t, 500*time.Millisecond, wait)
+	}
+
+	*now = now.Add(500 * time.Millisecond)
+
+	wait, _ = store.Take(ctx, "shop", 2, 4)
+	if wait != 0 {
+		assertions.ValueAssertionFailure(t, time.Duration(0), wait)
+	}
+}
+
+// Tests that processes sharing a store share one bucket
+func TestSharedRateLimiter_SharesBucket(t *testing.T) {
+	store, _ := newTestRateLimitStore()
+	ctx := context.Background()
+
+	first := NewSharedRateLimiter(store, "shop", 2, 2)
+	second := NewSharedRateLimiter(store, "shop", 2, 2)
+
+	first.Wait(ctx)
+	second.Wait(ctx)
+
+	wait, _ := store.Take(ctx, "shop", 2, 2)
+	if wait == 0 {
+		assertions.AssertionFailure(t, "expected the shared bucket to be full")
+	}
+}
+
+// Tests that the level reported by Shopify replaces the level of the bucket and a throttle pauses it
+func TestSharedRateLimiter_Observe(t *testing.T) {
+	store, now := newTestRateLimitStore()
+	ctx := context.Background()
+
+	limiter := NewSharedRateLimiter(store, "shop", 2, 40)
+	limiter.now = store.now
+
+	limiter.Observe(40, 40, 0)
+
+	wait, _ := store.Take(ctx, "shop", 2, 40)
+	if wait == 0 {
+		assertions.AssertionFailure(t, "expected the observed level to fill the bucket")
+	}
+
+	limiter.Observe(0, 40, 2*time.Second)
+
+	wait, _ = store.Take(ctx, "shop", 2, 40)
+	if wait != 2*time.Second {
+		assertions.ValueAssertionFailure(t, 2*time.Second, wait)
+	}
+
+	*now = now.Add(2 * time.Second)
+
+	wait, _ = store.Take(ctx, "shop", 2, 40)
+	if wait != 0 {
+		assertions.ValueAssertionFailure(t, time.Duration(0), wait)
+	}
+}
+
+// Tests that an older report of a lower level does not lower the bucket below the requests already taken
+func TestMemoryRateLimitStore_SyncStale(t *testing.T) {
+	store, _ := newTestRateLimitStore()
+	ctx := context.Background()
+
+	for i := 0; i < 30; i++ {
+		store.Take(ctx, "shop", 2, 40)
+	}
+
+	store.Sync(ctx, "shop", 35, 2)
+	store.Sync(ctx, "shop", 10, 2)
+
+	level := store.buckets["shop"].level
+	if level != 35 {
+		assertions.ValueAssertionFailure(t, float64(35), level)
+	}
+}
+
+// Tests that the shop reports the call limit header of each response to its rate limiter
+func TestWithRateLimiter_ObservesCallLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Shopify-Shop-Api-Call-Limit", "39/40")
+		w.Write([]byte(`{"shop":{"id":1}}`))
+	}))
+	defer server.Close()
+
+	store, _ := newTestRateLimitStore()
+	limiter := NewSharedRateLimiter(store, "shop", 2, 40)
+
+	shop := NewCustomShop(server.URL, "token", IsDefault, WithRateLimiter(limiter))
+
+	_, err := shop.Info().Get()
+	if err != nil {
+		assertions.ErrAssertionFailure(t, err)
+	}
+
+	level := store.buckets["shop"].level
+	if level != 39 {
+		assertions.ValueAssertionFailure(t, float64(39), level)
+	}
+}
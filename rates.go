@@ -32,6 +32,17 @@ func RateLimitPlus() http.Option {
 	)
 }
 
+// WithPlusRateLimit configures the shop to use the rate limit of a Shopify plus store.
+/*
+	This is for building shops where whether the shop is a plus store is not passed, such as with OAuth.Complete.
+	It has no effect when a rate limiter is configured with WithRateLimiter.
+*/
+func WithPlusRateLimit() OptionFunc {
+	return func(o *Options) {
+		o.plus = true
+	}
+}
+
 const (
 	// IsPlus represents a shop being a plus store
 	IsPlus = true
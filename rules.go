@@ -31,3 +31,23 @@ func (dto RuleDTO) ToShopify() shopify.Rule {
 		Condition: dto.Condition,
 	}
 }
+
+// BuildRuleDTOs builds the DTOs from the Shopify equivalent
+func BuildRuleDTOs(rules shopify.Rules) RuleDTOs {
+	dtos := make(RuleDTOs, 0, len(rules))
+
+	for _, rule := range rules {
+		dtos = append(dtos, BuildRuleDTO(rule))
+	}
+
+	return dtos
+}
+
+// BuildRuleDTO builds the DTO from the Shopify equivalent
+func BuildRuleDTO(rule shopify.Rule) RuleDTO {
+	return RuleDTO{
+		Column:    rule.Column,
+		Relation:  rule.Relation,
+		Condition: rule.Condition,
+	}
+}
@@ -0,0 +1,229 @@
+package httpshopify
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultSessionTokenLeeway is the clock skew allowed by default when checking the times of a session token
+const DefaultSessionTokenLeeway = 10 * time.Second
+
+// SessionToken is a verified App Bridge session token sent by an embedded app
+type SessionToken struct {
+	// Shop is the myshopify.com domain of the shop the session is for.
+	Shop string
+	// UserID is the ID of the staff member using the app. Zero when the token is not for a user.
+	UserID int64
+	// SessionID is the ID of the session.
+	SessionID string
+	// Issuer is the admin URL of the shop that issued the token.
+	Issuer string
+	// Destination is the URL of the shop the token is for.
+	Destination string
+	// Audience is the API key of the app the token is for.
+	Audience string
+	// ExpiresAt is when the token expires.
+	ExpiresAt time.Time
+	// NotBefore is when the token becomes valid.
+	NotBefore time.Time
+	// IssuedAt is when the token was issued.
+	IssuedAt time.Time
+	// ID is the unique ID of the token.
+	ID string
+	// Raw is the encoded token, which can be exchanged for an access token.
+	Raw string
+}
+
+// SessionTokenOption allows the verifier to be configured
+type SessionTokenOption func(*SessionTokenVerifier)
+
+// WithSessionTokenLeeway sets the clock skew allowed when checking when a token expires and becomes valid
+func WithSessionTokenLeeway(leeway time.Duration) SessionTokenOption {
+	return func(verifier *SessionTokenVerifier) {
+		verifier.leeway = leeway
+	}
+}
+
+// SessionTokenVerifier verifies the session tokens that App Bridge sends from an embedded app.
+/*
+	Example:
+	verifier := httpshopify.NewSessionTokenVerifier("api-key", "api-secret")
+	token, err := verifier.Verify(strings.TrimPrefix(request.Header.Get("Authorization"), "Bearer "))
+*/
+type SessionTokenVerifier struct {
+	apiKey    string
+	apiSecret string
+	leeway    time.Duration
+	now       func() time.Time
+}
+
+// NewSessionTokenVerifier builds a verifier for the session tokens of the app with the API key and secret
+func NewSessionTokenVerifier(apiKey string, apiSecret string, options ...SessionTokenOption) SessionTokenVerifier {
+	verifier := SessionTokenVerifier{
+		apiKey:    apiKey,
+		apiSecret: apiSecret,
+		leeway:    DefaultSessionTokenLeeway,
+		now:       time.Now,
+	}
+
+	for _, option := range options {
+		option(&verifier)
+	}
+
+	return verifier
+}
+
+// Verify checks the signature, times, issuer and audience of the token
+func (verifier SessionTokenVerifier) Verify(token string) (SessionToken, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return SessionToken{}, NewErrSessionTokenInvalid("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+
+	err := decodeSessionTokenSegment(parts[0], &header)
+	if err != nil {
+		return SessionToken{}, NewErrSessionTokenInvalid("malformed header")
+	}
+
+	if header.Alg != "HS256" {
+		return SessionToken{}, NewErrSessionTokenInvalid(fmt.Sprintf("unexpected algorithm %v", header.Alg))
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return SessionToken{}, NewErrSessionTokenInvalid("malformed signature")
+	}
+
+	mac := hmac.New(sha256.New, []byte(verifier.apiSecret))
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+
+	if !hmac.Equal(mac.Sum(nil), signature) {
+		return SessionToken{}, NewErrSessionTokenInvalid("signature does not match")
+	}
+
+	var claims SessionTokenClaimsDTO
+	err = decodeSessionTokenSegment(parts[1], &claims)
+	if err != nil {
+		return SessionToken{}, NewErrSessionTokenInvalid("malformed claims")
+	}
+
+	now := verifier.now()
+
+	if claims.Exp == 0 || now.After(time.Unix(claims.Exp, 0).Add(verifier.leeway)) {
+		return SessionToken{}, NewErrSessionTokenInvalid("token has expired")
+	}
+
+	if claims.Nbf != 0 && now.Add(verifier.leeway).Before(time.Unix(claims.Nbf, 0)) {
+		return SessionToken{}, NewErrSessionTokenInvalid("token is not valid yet")
+	}
+
+	if claims.Aud != verifier.apiKey {
+		return SessionToken{}, NewErrSessionTokenInvalid("token is for another app")
+	}
+
+	shop, err := sessionTokenShop(claims.Iss, claims.Dest)
+	if err != nil {
+		return SessionToken{}, err
+	}
+
+	return claims.ToShopify(shop, token), nil
+}
+
+// sessionTokenShop checks that the issuer and destination are the same shop, returning its domain
+func sessionTokenShop(issuer string, destination string) (string, error) {
+	issuerURL, err := url.Parse(issuer)
+	if err != nil {
+		return "", NewErrSessionTokenInvalid("malformed issuer")
+	}
+
+	destinationURL, err := url.Parse(destination)
+	if err != nil {
+		return "", NewErrSessionTokenInvalid("malformed destination")
+	}
+
+	if issuerURL.Host != destinationURL.Host {
+		return "", NewErrSessionTokenInvalid("issuer and destination are different shops")
+	}
+
+	if !IsValidShopDomain(destinationURL.Host) {
+		return "", NewErrSessionTokenInvalid("destination is not a myshopify.com domain")
+	}
+
+	return destinationURL.Host, nil
+}
+
+func decodeSessionTokenSegment(segment string, value interface{}) error {
+	body, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(body, value)
+}
+
+// SessionTokenClaimsDTO represents the claims of a session token
+type SessionTokenClaimsDTO struct {
+	Iss  string `json:"iss"`
+	Dest string `json:"dest"`
+	Aud  string `json:"aud"`
+	Sub  string `json:"sub"`
+	Exp  int64  `json:"exp"`
+	Nbf  int64  `json:"nbf"`
+	Iat  int64  `json:"iat"`
+	Jti  string `json:"jti"`
+	Sid  string `json:"sid"`
+}
+
+// ToShopify converts the DTO to the Shopify equivalent
+func (dto SessionTokenClaimsDTO) ToShopify(shop string, raw string) SessionToken {
+	userID, _ := strconv.ParseInt(dto.Sub, 10, 64)
+
+	return SessionToken{
+		Shop:        shop,
+		UserID:      userID,
+		SessionID:   dto.Sid,
+		Issuer:      dto.Iss,
+		Destination: dto.Dest,
+		Audience:    dto.Aud,
+		ExpiresAt:   unixTime(dto.Exp),
+		NotBefore:   unixTime(dto.Nbf),
+		IssuedAt:    unixTime(dto.Iat),
+		ID:          dto.Jti,
+		Raw:         raw,
+	}
+}
+
+func unixTime(seconds int64) time.Time {
+	if seconds == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(seconds, 0)
+}
+
+// ErrSessionTokenInvalid is thrown when a session token fails verification
+type ErrSessionTokenInvalid struct {
+	reason string
+}
+
+func (err ErrSessionTokenInvalid) Error() string {
+	return fmt.Sprintf("session token is invalid: %v", err.reason)
+}
+
+// NewErrSessionTokenInvalid builds the error
+func NewErrSessionTokenInvalid(reason string) ErrSessionTokenInvalid {
+	return ErrSessionTokenInvalid{
+		reason,
+	}
+}
@@ -0,0 +1,143 @@
+package httpshopify
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MOHC-LTD/httpshopify/v2/internal/assertions"
+)
+
+func signSessionToken(secret string, claims SessionTokenClaimsDTO) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	body, _ := json.Marshal(claims)
+	payload := base64.RawURLEncoding.EncodeToString(body)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(header + "." + payload))
+
+	return header + "." + payload + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func validSessionTokenClaims(now time.Time) SessionTokenClaimsDTO {
+	return SessionTokenClaimsDTO{
+		Iss:  "https://my-shop.myshopify.com/admin",
+		Dest: "https://my-shop.myshopify.com",
+		Aud:  "api-key",
+		Sub:  "42",
+		Exp:  now.Add(time.Minute).Unix(),
+		Nbf:  now.Unix(),
+		Iat:  now.Unix(),
+		Jti:  "f8912129-1af6-4cad-9ca3-76b0f7621087",
+		Sid:  "aaea182f2732d44c23057c0fea584021a4485b2bd25d3eb7fd349313ad24c685",
+	}
+}
+
+// Tests that a valid session token returns the shop and user it is for
+func TestSessionTokenVerifier_Verify(t *testing.T) {
+	verifier := NewSessionTokenVerifier("api-key", "api-secret")
+
+	token, err := verifier.Verify(signSessionToken("api-secret", validSessionTokenClaims(time.Now())))
+	if err != nil {
+		assertions.ErrAssertionFailure(t, err)
+	}
+
+	if token.Shop != "my-shop.myshopify.com" {
+		assertions.ValueAssertionFailure(t, "my-shop.myshopify.com", token.Shop)
+	}
+
+	if token.UserID != 42 {
+		assertions.ValueAssertionFailure(t, int64(42), token.UserID)
+	}
+}
+
+// Tests that tokens that are tampered, expired, early, for another app or for mismatched shops are rejected
+func TestSessionTokenVerifier_VerifyInvalid(t *testing.T) {
+	verifier := NewSessionTokenVerifier("api-key", "api-secret", WithSessionTokenLeeway(5*time.Second))
+	now := time.Now()
+
+	expired := validSessionTokenClaims(now)
+	expired.Exp = now.Add(-10 * time.Second).Unix()
+
+	early := validSessionTokenClaims(now)
+	early.Nbf = now.Add(10 * time.Second).Unix()
+
+	otherApp := validSessionTokenClaims(now)
+	otherApp.Aud = "other-key"
+
+	otherShop := validSessionTokenClaims(now)
+	otherShop.Iss = "https://other-shop.myshopify.com/admin"
+
+	tokens := map[string]string{
+		"wrong secret": signSessionToken("other-secret", validSessionTokenClaims(now)),
+		"expired":      signSessionToken("api-secret", expired),
+		"early":        signSessionToken("api-secret", early),
+		"other app":    signSessionToken("api-secret", otherApp),
+		"other shop":   signSessionToken("api-secret", otherShop),
+		"malformed":    "not-a-token",
+	}
+
+	for name, token := range tokens {
+		_, err := verifier.Verify(token)
+
+		var invalid ErrSessionTokenInvalid
+		if !errors.As(err, &invalid) {
+			assertions.AssertionFailure(t, name+" token should be invalid")
+		}
+	}
+}
+
+// Tests that a token within the clock skew allowance is accepted
+func TestSessionTokenVerifier_VerifyLeeway(t *testing.T) {
+	verifier := NewSessionTokenVerifier("api-key", "api-secret")
+	now := time.Now()
+
+	claims := validSessionTokenClaims(now)
+	claims.Exp = now.Add(-2 * time.Second).Unix()
+
+	_, err := verifier.Verify(signSessionToken("api-secret", claims))
+	if err != nil {
+		assertions.ErrAssertionFailure(t, err)
+	}
+}
+
+// Tests that a session token is exchanged for an access token for the shop of the session
+func TestOAuth_ExchangeSessionToken(t *testing.T) {
+	var grantType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			GrantType string `json:"grant_type"`
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		grantType = request.GrantType
+
+		w.Write([]byte(`{"access_token":"token","scope":"read_orders"}`))
+	}))
+	defer server.Close()
+
+	oauth := NewOAuth(
+		OAuthConfig{ClientID: "api-key", ClientSecret: "api-secret", Version: "2024-01"},
+		WithOAuthShopURL(func(shop string) string {
+			return server.URL
+		}),
+	)
+
+	_, token, err := oauth.ExchangeSessionToken(signSessionToken("api-secret", validSessionTokenClaims(time.Now())))
+	if err != nil {
+		assertions.ErrAssertionFailure(t, err)
+	}
+
+	if token.Token != "token" {
+		assertions.ValueAssertionFailure(t, "token", token.Token)
+	}
+
+	if grantType != tokenExchangeGrantType {
+		assertions.ValueAssertionFailure(t, tokenExchangeGrantType, grantType)
+	}
+}
@@ -0,0 +1,151 @@
+package httpshopify
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/MOHC-LTD/httpshopify/v2/internal/http"
+)
+
+// ShopInfo holds the settings of a shop
+type ShopInfo struct {
+	// ID is the ID of the shop.
+	ID int64
+	// Name is the name of the shop.
+	Name string
+	// Email is the contact email address of the shop.
+	Email string
+	// Domain is the primary domain of the shop.
+	Domain string
+	// MyshopifyDomain is the myshopify.com domain of the shop.
+	MyshopifyDomain string
+	// CountryCode is the two-letter code of the country the shop is in.
+	CountryCode string
+	// Currency is the three-letter code of the default currency of the shop.
+	Currency string
+	// EnabledPresentmentCurrencies are the currencies the shop presents prices in.
+	EnabledPresentmentCurrencies []string
+	// MoneyFormat is the format used to display amounts of money e.g. ${{amount}}.
+	MoneyFormat string
+	// IANATimezone is the IANA name of the timezone of the shop e.g. Europe/London.
+	IANATimezone string
+	// Timezone is the display name of the timezone of the shop e.g. (GMT+00:00) Europe/London.
+	Timezone string
+	// PlanName is the name of the Shopify plan of the shop e.g. shopify_plus.
+	PlanName string
+	// PlanDisplayName is the display name of the Shopify plan of the shop e.g. Shopify Plus.
+	PlanDisplayName string
+	// PrimaryLocale is the locale of the shop's storefront e.g. en.
+	PrimaryLocale string
+	// WeightUnit is the default unit of weight of the shop e.g. kg.
+	WeightUnit string
+	// TaxesIncluded is whether prices include taxes.
+	TaxesIncluded bool
+	// TaxShipping is whether taxes are charged on shipping.
+	TaxShipping bool
+	// CreatedAt is the date and time when the shop was created.
+	CreatedAt time.Time
+	// UpdatedAt is the date and time when the settings of the shop were last updated.
+	UpdatedAt time.Time
+}
+
+// Location loads the timezone of the shop
+func (info ShopInfo) Location() (*time.Location, error) {
+	return time.LoadLocation(info.IANATimezone)
+}
+
+// ShopInfoRepository retrieves the settings of a shop
+type ShopInfoRepository interface {
+	// Get retrieves the settings of the shop
+	Get() (ShopInfo, error)
+}
+
+type shopInfoRepository struct {
+	client    http.Client
+	createURL func(endpoint string) string
+}
+
+func newShopInfoRepository(client http.Client, createURL func(endpoint string) string) shopInfoRepository {
+	return shopInfoRepository{
+		client,
+		createURL,
+	}
+}
+
+func (repository shopInfoRepository) Get() (ShopInfo, error) {
+	url := repository.createURL("shop.json")
+
+	body, _, err := repository.client.WithOperation("shopInfo.Get").Get(url, nil)
+	if err != nil {
+		return ShopInfo{}, err
+	}
+
+	var response struct {
+		Shop ShopInfoDTO `json:"shop"`
+	}
+
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return ShopInfo{}, err
+	}
+
+	return response.Shop.ToShopify(), nil
+}
+
+// ShopInfoDTO represents the settings of a Shopify shop in HTTP requests and responses
+type ShopInfoDTO struct {
+	ID                           int64      `json:"id"`
+	Name                         string     `json:"name"`
+	Email                        string     `json:"email"`
+	Domain                       string     `json:"domain"`
+	MyshopifyDomain              string     `json:"myshopify_domain"`
+	CountryCode                  string     `json:"country_code"`
+	Currency                     string     `json:"currency"`
+	EnabledPresentmentCurrencies []string   `json:"enabled_presentment_currencies"`
+	MoneyFormat                  string     `json:"money_format"`
+	IANATimezone                 string     `json:"iana_timezone"`
+	Timezone                     string     `json:"timezone"`
+	PlanName                     string     `json:"plan_name"`
+	PlanDisplayName              string     `json:"plan_display_name"`
+	PrimaryLocale                string     `json:"primary_locale"`
+	WeightUnit                   string     `json:"weight_unit"`
+	TaxesIncluded                *bool      `json:"taxes_included"`
+	TaxShipping                  *bool      `json:"tax_shipping"`
+	CreatedAt                    *time.Time `json:"created_at,omitempty"`
+	UpdatedAt                    *time.Time `json:"updated_at,omitempty"`
+}
+
+// ToShopify converts the DTO to the Shopify equivalent
+func (dto ShopInfoDTO) ToShopify() ShopInfo {
+	var createdAt time.Time
+	if dto.CreatedAt != nil {
+		createdAt = *dto.CreatedAt
+	}
+
+	var updatedAt time.Time
+	if dto.UpdatedAt != nil {
+		updatedAt = *dto.UpdatedAt
+	}
+
+	return ShopInfo{
+		ID:                           dto.ID,
+		Name:                         dto.Name,
+		Email:                        dto.Email,
+		Domain:                       dto.Domain,
+		MyshopifyDomain:              dto.MyshopifyDomain,
+		CountryCode:                  dto.CountryCode,
+		Currency:                     dto.Currency,
+		EnabledPresentmentCurrencies: dto.EnabledPresentmentCurrencies,
+		MoneyFormat:                  dto.MoneyFormat,
+		IANATimezone:                 dto.IANATimezone,
+		Timezone:                     dto.Timezone,
+		PlanName:                     dto.PlanName,
+		PlanDisplayName:              dto.PlanDisplayName,
+		PrimaryLocale:                dto.PrimaryLocale,
+		WeightUnit:                   dto.WeightUnit,
+		TaxesIncluded:                dto.TaxesIncluded != nil && *dto.TaxesIncluded,
+		TaxShipping:                  dto.TaxShipping != nil && *dto.TaxShipping,
+		CreatedAt:                    createdAt,
+		UpdatedAt:                    updatedAt,
+	}
+}
@@ -0,0 +1,45 @@
+package httpshopify
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/MOHC-LTD/httpshopify/v2/internal/assertions"
+)
+
+// Tests that the settings of a shop are decoded from the shop.json response
+func TestShopInfoDTO_ToShopify(t *testing.T) {
+	body := []byte(`{"id":548380009,"currency":"GBP","iana_timezone":"Europe/London","plan_name":"shopify_plus","primary_locale":"en","enabled_presentment_currencies":["GBP","EUR"],"weight_unit":"kg","taxes_included":true}`)
+
+	var dto ShopInfoDTO
+	err := json.Unmarshal(body, &dto)
+	if err != nil {
+		assertions.ErrAssertionFailure(t, err)
+	}
+
+	info := dto.ToShopify()
+
+	if info.Currency != "GBP" {
+		assertions.ValueAssertionFailure(t, "GBP", info.Currency)
+	}
+
+	if info.IANATimezone != "Europe/London" {
+		assertions.ValueAssertionFailure(t, "Europe/London", info.IANATimezone)
+	}
+
+	if len(info.EnabledPresentmentCurrencies) != 2 {
+		assertions.ValueAssertionFailure(t, 2, len(info.EnabledPresentmentCurrencies))
+	}
+
+	if !info.TaxesIncluded {
+		assertions.ValueAssertionFailure(t, true, info.TaxesIncluded)
+	}
+
+	if info.TaxShipping {
+		assertions.ValueAssertionFailure(t, false, info.TaxShipping)
+	}
+
+	if !info.CreatedAt.IsZero() {
+		assertions.ValueAssertionFailure(t, "zero time", info.CreatedAt)
+	}
+}
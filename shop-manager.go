@@ -0,0 +1,212 @@
+package httpshopify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	httpCode "net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ShopCredentials are what is needed to build a shop
+type ShopCredentials struct {
+	// AccessToken is the access token of the shop. Ignored when TokenSource is set.
+	AccessToken string
+	// TokenSource provides the access token of the shop for every request.
+	TokenSource TokenSource
+	// Version is the Admin API version to use e.g. 2024-01.
+	Version string
+	// IsPlus is whether the shop is a Shopify Plus shop, which has higher rate limits.
+	IsPlus bool
+	// RateLimiter limits the requests of the shop, for example to share its bucket between processes.
+	RateLimiter RateLimiter
+	// URL overrides the URL of the Admin API, which is otherwise built from the shop name and version.
+	URL string
+}
+
+// CredentialProvider looks up the credentials of a shop
+type CredentialProvider interface {
+	// Credentials returns the credentials of the shop
+	Credentials(shop string) (ShopCredentials, error)
+}
+
+// CredentialProviderFunc allows a function to be used as a CredentialProvider
+type CredentialProviderFunc func(shop string) (ShopCredentials, error)
+
+// Credentials calls the function
+func (fn CredentialProviderFunc) Credentials(shop string) (ShopCredentials, error) {
+	return fn(shop)
+}
+
+// ShopManager builds and caches the shops of an app that works with many shops.
+/*
+	Shopify rate limits each app per shop, so every shop is built once and keeps its own rate limit for the life of
+	the manager. All of the shops share one transport, and so one pool of connections.
+	Example:
+	manager := httpshopify.NewShopManager(httpshopify.CredentialProviderFunc(func(shop string) (httpshopify.ShopCredentials, error) {
+		return httpshopify.ShopCredentials{AccessToken: tokens[shop], Version: "2024-01"}, nil
+	}))
+	err := manager.ForEach(ctx, []string{"shop-a", "shop-b"}, 4, func(ctx context.Context, name string, shop httpshopify.Shop) error {
+		_, err := shop.Orders().List(shopify.OrderQuery{})
+		return err
+	})
+*/
+type ShopManager struct {
+	provider   CredentialProvider
+	optionsFns []OptionFunc
+	mutex      sync.Mutex
+	shops      map[string]Shop
+}
+
+// NewShopManager builds a manager that looks up the credentials of shops with the provider.
+/*
+	The options are applied to every shop. A shared transport is used unless one is passed with WithTransport.
+	A breaker passed with WithCircuitBreaker is used as a template, and each shop gets its own breaker with the
+	same settings so that an outage of one shop does not fail the requests of the others.
+*/
+func NewShopManager(provider CredentialProvider, optionsFns ...OptionFunc) *ShopManager {
+	transport := httpCode.DefaultTransport.(*httpCode.Transport).Clone()
+	transport.MaxIdleConnsPerHost = 10
+
+	return &ShopManager{
+		provider:   provider,
+		optionsFns: append([]OptionFunc{WithTransport(transport)}, optionsFns...),
+		shops:      make(map[string]Shop),
+	}
+}
+
+// Get returns the shop, building it the first time it is asked for.
+/*
+	The shop can be passed as its name e.g. my-shop, or as its myshopify.com domain.
+*/
+func (manager *ShopManager) Get(shop string) (Shop, error) {
+	name := shopName(shop)
+
+	manager.mutex.Lock()
+	cached, ok := manager.shops[name]
+	manager.mutex.Unlock()
+
+	if ok {
+		return cached, nil
+	}
+
+	credentials, err := manager.provider.Credentials(name)
+	if err != nil {
+		return Shop{}, err
+	}
+
+	built := manager.build(name, credentials)
+
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	// Another caller may have built the shop while the credentials were looked up
+	if cached, ok := manager.shops[name]; ok {
+		return cached, nil
+	}
+
+	manager.shops[name] = built
+
+	return built, nil
+}
+
+// Forget removes the shop from the cache so that it is built again with fresh credentials the next time it is asked for
+func (manager *ShopManager) Forget(shop string) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	delete(manager.shops, shopName(shop))
+}
+
+// Shops returns the names of the shops that have been built, in alphabetical order
+func (manager *ShopManager) Shops() []string {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	names := make([]string, 0, len(manager.shops))
+	for name := range manager.shops {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// ForEach runs the function against each of the shops, running at most concurrency at a time.
+/*
+	Every shop is run even when some fail. The errors of the shops that failed are joined, each prefixed with the
+	name of its shop. Shops that have not started when the context is cancelled are skipped with the error of the context.
+*/
+func (manager *ShopManager) ForEach(ctx context.Context, shops []string, concurrency int, fn func(ctx context.Context, name string, shop Shop) error) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	semaphore := make(chan struct{}, concurrency)
+	errs := make([]error, len(shops))
+
+	var wait sync.WaitGroup
+
+	for i, name := range shops {
+		select {
+		case semaphore <- struct{}{}:
+		case <-ctx.Done():
+			errs[i] = fmt.Errorf("%v: %w", name, ctx.Err())
+			continue
+		}
+
+		wait.Add(1)
+		go func(i int, name string) {
+			defer wait.Done()
+			defer func() { <-semaphore }()
+
+			shop, err := manager.Get(name)
+			if err == nil {
+				err = fn(ctx, shopName(name), shop)
+			}
+
+			if err != nil {
+				errs[i] = fmt.Errorf("%v: %w", name, err)
+			}
+		}(i, name)
+	}
+
+	wait.Wait()
+
+	return errors.Join(errs...)
+}
+
+func (manager *ShopManager) build(name string, credentials ShopCredentials) Shop {
+	optionsFns := append([]OptionFunc{}, manager.optionsFns...)
+
+	if credentials.TokenSource != nil {
+		optionsFns = append(optionsFns, WithTokenSource(credentials.TokenSource))
+	}
+
+	if credentials.RateLimiter != nil {
+		optionsFns = append(optionsFns, WithRateLimiter(credentials.RateLimiter))
+	}
+
+	options := Options{}
+	for _, fn := range optionsFns {
+		fn(&options)
+	}
+
+	if options.circuitBreaker != nil {
+		optionsFns = append(optionsFns, WithCircuitBreaker(options.circuitBreaker.clone()))
+	}
+
+	url := credentials.URL
+	if url == "" {
+		url = fmt.Sprintf("https://%v.myshopify.com/admin/api/%v", name, credentials.Version)
+	}
+
+	return NewCustomShop(url, credentials.AccessToken, credentials.IsPlus, optionsFns...)
+}
+
+// shopName returns the name of the shop from either its name or its myshopify.com domain
+func shopName(shop string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(shop)), ".myshopify.com")
+}
@@ -0,0 +1,111 @@
+package httpshopify
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/MOHC-LTD/httpshopify/v2/internal/assertions"
+)
+
+// Tests that a shop is only built once and is found by either its name or domain
+func TestShopManager_GetCaches(t *testing.T) {
+	lookups := 0
+	manager := NewShopManager(CredentialProviderFunc(func(shop string) (ShopCredentials, error) {
+		lookups++
+		return ShopCredentials{AccessToken: "token", Version: "2024-01"}, nil
+	}))
+
+	manager.Get("my-shop")
+	manager.Get("my-shop.myshopify.com")
+
+	if lookups != 1 {
+		assertions.ValueAssertionFailure(t, 1, lookups)
+	}
+
+	manager.Forget("my-shop")
+	manager.Get("my-shop")
+
+	if lookups != 2 {
+		assertions.ValueAssertionFailure(t, 2, lookups)
+	}
+}
+
+// Tests that the function is run against every shop without exceeding the concurrency, and failures are joined
+func TestShopManager_ForEach(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"shop":{"id":1}}`))
+	}))
+	defer server.Close()
+
+	manager := NewShopManager(CredentialProviderFunc(func(shop string) (ShopCredentials, error) {
+		if shop == "missing" {
+			return ShopCredentials{}, errors.New("no credentials")
+		}
+
+		return ShopCredentials{AccessToken: "token", URL: server.URL}, nil
+	}))
+
+	var running, maxRunning int32
+	var mutex sync.Mutex
+	visited := make(map[string]bool)
+
+	err := manager.ForEach(context.Background(), []string{"a", "b", "c", "d", "missing"}, 2, func(ctx context.Context, name string, shop Shop) error {
+		current := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+
+		mutex.Lock()
+		visited[name] = true
+		if current > maxRunning {
+			maxRunning = current
+		}
+		mutex.Unlock()
+
+		_, err := shop.Info().Get()
+		return err
+	})
+
+	if err == nil {
+		assertions.AssertionFailure(t, "expected the shop without credentials to fail")
+	}
+
+	if len(visited) != 4 {
+		assertions.ValueAssertionFailure(t, 4, len(visited))
+	}
+
+	if maxRunning > 2 {
+		assertions.ValueAssertionFailure(t, 2, maxRunning)
+	}
+}
+
+// Tests that each shop gets its own circuit breaker, so a failing shop does not open the circuit of the others
+func TestShopManager_CircuitBreakerPerShop(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/failing/shop.json" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Write([]byte(`{"shop":{}}`))
+	}))
+	defer server.Close()
+
+	breaker := NewCircuitBreaker(CircuitBreakerSettings{FailureThreshold: 1})
+	manager := NewShopManager(CredentialProviderFunc(func(shop string) (ShopCredentials, error) {
+		return ShopCredentials{AccessToken: "token", URL: server.URL + "/" + shop}, nil
+	}), WithCircuitBreaker(breaker))
+
+	failing, _ := manager.Get("failing")
+	failing.Info().Get()
+
+	healthy, _ := manager.Get("healthy")
+
+	_, err := healthy.Info().Get()
+	if err != nil {
+		assertions.ErrAssertionFailure(t, err)
+	}
+}
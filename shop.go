@@ -19,6 +19,9 @@ type Shop struct {
 	inventoryLevels   inventoryLevelRepository
 	inventoryItems    inventoryItemRepository
 	collections       collectionRepository
+	customCollections customCollectionRepository
+	smartCollections  smartCollectionRepository
+	collects          collectRepository
 	productImages     productImagesRepository
 	metafields        metafieldRepository
 	customers         customerRepository
@@ -27,6 +30,11 @@ type Shop struct {
 	articles          articleRepository
 	webhooks          webhookRepository
 	transactions      transactionRepository
+	info              shopInfoRepository
+	accessScopes      accessScopeRepository
+	graphQL           GraphQLClient
+	client            http.Client
+	url               string
 }
 
 // NewShop builds a shopify shop based on the shopify admin REST API
@@ -79,30 +87,73 @@ func NewPlusShop(shop string, accessToken string, version string, optionsFns ...
 	For the full shopify admin REST API documentation see https://shopify.dev/docs/admin-api/rest/reference
 */
 func NewCustomShop(url string, accessToken string, isPlus bool, optionsFns ...OptionFunc) Shop {
+	// Apply OptionFuncs to Options
+	options := Options{}
+	for _, fn := range optionsFns {
+		fn(&options)
+	}
+
 	var rateLimitOption http.Option
-	if isPlus {
+	if options.rateLimiter != nil {
+		rateLimitOption = http.WithLimiter(options.rateLimiter)
+	} else if isPlus || options.plus {
 		rateLimitOption = RateLimitPlus()
 	} else {
 		rateLimitOption = RateLimitDefault()
 	}
 
-	// Apply OptionFuncs to Options
-	options := Options{}
-	for _, fn := range optionsFns {
-		fn(&options)
+	tokenSource := options.tokenSource
+	if tokenSource == nil {
+		tokenSource = StaticTokenSource(accessToken)
 	}
 
-	client := http.NewClient(
-		http.WithDefaultHeader("X-Shopify-Access-Token", accessToken),
+	clientOptions := []http.Option{
+		http.WithToken("X-Shopify-Access-Token", tokenSource.Token, tokenSource.Refresh),
 		http.WithDefaultHeader("Content-Type", "application/json"),
 		rateLimitOption,
 		http.WithBackoffOptions(options.retryCount, options.retryBaseDuration, options.retryMaxDuration),
-	)
+		http.WithErrorMapper(mapScopeError),
+	}
+
+	if options.transport != nil {
+		clientOptions = append(clientOptions, http.WithTransport(options.transport))
+	}
+
+	if options.circuitBreaker != nil {
+		clientOptions = append(clientOptions, http.WithBreaker(options.circuitBreaker))
+	}
+
+	if options.metrics != nil {
+		clientOptions = append(clientOptions, http.WithMetrics(metricsReporter{options.metrics}))
+	}
+
+	if options.tracer != nil {
+		clientOptions = append(clientOptions, http.WithTracer(tracerAdapter{options.tracer}))
+	}
+
+	if options.versionMonitor != nil {
+		clientOptions = append(clientOptions, http.WithResponseCheck(options.versionMonitor.Check))
+	}
+
+	if options.priorityReserve != nil {
+		clientOptions = append(clientOptions, http.WithPriorityReserve(*options.priorityReserve))
+	}
+
+	client := http.NewClient(clientOptions...)
+
+	return newShop(client, url)
+}
 
+// newShop builds the repositories of the shop at the URL on the client
+func newShop(client http.Client, url string) Shop {
 	createURL := func(endpoint string) string {
 		return fmt.Sprintf("%v/%v", url, endpoint)
 	}
 
+	createAdminURL := func(endpoint string) string {
+		return fmt.Sprintf("%v/%v", adminRootURL(url), endpoint)
+	}
+
 	return Shop{
 		orders:            newOrderRepository(client, createURL),
 		fulfillments:      newFulfillmentRepository(client, createURL),
@@ -113,6 +164,9 @@ func NewCustomShop(url string, accessToken string, isPlus bool, optionsFns ...Op
 		inventoryLevels:   newInventoryLevelRepository(client, createURL),
 		inventoryItems:    newInventoryItemRepository(client, createURL),
 		collections:       newCollectionRepository(client, createURL),
+		customCollections: newCustomCollectionRepository(client, createURL),
+		smartCollections:  newSmartCollectionRepository(client, createURL),
+		collects:          newCollectRepository(client, createURL),
 		productImages:     newProductImagesRepository(client, createURL),
 		metafields:        newMetafieldRepository(client, createURL),
 		customers:         newCustomerRepository(client, createURL),
@@ -121,6 +175,11 @@ func NewCustomShop(url string, accessToken string, isPlus bool, optionsFns ...Op
 		articles:          newArticleRepository(client, createURL),
 		webhooks:          newWebhookRepository(client, createURL),
 		transactions:      newTransactionRepository(client, createURL),
+		info:              newShopInfoRepository(client, createURL),
+		accessScopes:      newAccessScopeRepository(client, createAdminURL),
+		graphQL:           newGraphQLClient(client, createURL, newGraphQLThrottle()),
+		client:            client,
+		url:               url,
 	}
 }
 
@@ -145,10 +204,22 @@ func (shop Shop) FulfillmentOrders() shopify.FulfillmentOrderRepository {
 }
 
 // Variants returns an HTTP implementation of a Shopify variant repository
+/*
+	The returned repository is the one returned by ExtendedVariants, typed as the Shopify repository so that the
+	shop implements shopify.Shop.
+*/
 func (shop Shop) Variants() shopify.VariantRepository {
 	return shop.variants
 }
 
+// ExtendedVariants returns an HTTP implementation of a variant repository
+/*
+	It adds getting variants with all of their fields, updating, listing and counting them to the Shopify repository.
+*/
+func (shop Shop) ExtendedVariants() VariantRepository {
+	return shop.variants
+}
+
 // Products returns an HTTP implementation of a Shopify product repository
 func (shop Shop) Products() shopify.ProductRepository {
 	return shop.products
@@ -169,16 +240,55 @@ func (shop Shop) Collections() shopify.CollectionRepository {
 	return shop.collections
 }
 
+// CustomCollections returns an HTTP implementation of a custom collection repository
+func (shop Shop) CustomCollections() CustomCollectionRepository {
+	return shop.customCollections
+}
+
+// SmartCollections returns an HTTP implementation of a smart collection repository
+func (shop Shop) SmartCollections() SmartCollectionRepository {
+	return shop.smartCollections
+}
+
+// Collects returns an HTTP implementation of a collect repository
+func (shop Shop) Collects() CollectRepository {
+	return shop.collects
+}
+
 // ProductImages returns an HTTP implementation of a Shopify product images repository
+/*
+	The returned repository is the one returned by ExtendedProductImages, typed as the Shopify repository so that
+	the shop implements shopify.Shop.
+*/
 func (shop Shop) ProductImages() shopify.ProductImageRepository {
 	return shop.productImages
 }
 
+// ExtendedProductImages returns an HTTP implementation of a product images repository
+/*
+	It adds creating, uploading, updating, deleting and reordering images to the Shopify repository.
+*/
+func (shop Shop) ExtendedProductImages() ProductImageRepository {
+	return shop.productImages
+}
+
 // Metafields returns an HTTP implementation of a Shopify metafield repository
+/*
+	The returned repository is the one returned by ExtendedMetafields, typed as the Shopify repository so that the
+	shop implements shopify.Shop.
+*/
 func (shop Shop) Metafields() shopify.MetafieldRepository {
 	return shop.metafields
 }
 
+// ExtendedMetafields returns an HTTP implementation of a metafield repository
+/*
+	It adds listing, getting, creating, updating and deleting the metafields of any resource to the Shopify repository.
+*/
+func (shop Shop) ExtendedMetafields() MetafieldRepository {
+	return shop.metafields
+}
+
 // Customers returns an HTTP implementation of a Shopify customers repository
 func (shop Shop) Customers() shopify.CustomerRepository {
 	return shop.customers
@@ -200,11 +310,74 @@ func (shop Shop) Articles() shopify.ArticleRepository {
 }
 
 // Webhooks returns an HTTP implementation of a Shopify webhook repository
+/*
+	The returned repository is the one returned by ExtendedWebhooks, typed as the Shopify repository so that the
+	shop implements shopify.Shop.
+*/
 func (shop Shop) Webhooks() shopify.WebhookRepository {
 	return shop.webhooks
 }
 
+// ExtendedWebhooks returns an HTTP implementation of a webhook repository
+/*
+	It adds filtering, getting, updating, deleting and counting webhook subscriptions to the Shopify repository.
+*/
+func (shop Shop) ExtendedWebhooks() WebhookRepository {
+	return shop.webhooks
+}
+
 // Transactions returns an HTTP implementation of a Shopify transaction repository
 func (shop Shop) Transactions() shopify.TransactionRepository {
 	return shop.transactions
 }
+
+// Info returns an HTTP implementation of a shop info repository
+func (shop Shop) Info() ShopInfoRepository {
+	return shop.info
+}
+
+// GraphQL returns a client for the GraphQL Admin API of the shop
+func (shop Shop) GraphQL() GraphQLClient {
+	return shop.graphQL
+}
+
+// AccessScopes returns an HTTP implementation of an access scope repository
+func (shop Shop) AccessScopes() AccessScopeRepository {
+	return shop.accessScopes
+}
+
+// CheckScopes returns ErrMissingScopes when any of the scopes the app needs have not been granted by the shop.
+/*
+	Call this on startup to fail fast rather than on the first request that needs a missing scope.
+	Example:
+	err := shop.CheckScopes("read_orders", "write_products")
+*/
+func (shop Shop) CheckScopes(required ...string) error {
+	return shop.accessScopes.Check(required)
+}
+
+// WithPriority returns a copy of the shop that sends its requests with the priority.
+/*
+	The copy shares the rate limit of the shop, so while requests are waiting for the rate limit higher priority
+	requests are sent first. Some capacity is kept for lower priority requests, see WithPriorityReserve. Priorities
+	above PriorityHigh or below PriorityLow are treated as PriorityHigh or PriorityLow. GraphQL queries are not
+	prioritised, see GraphQLClient.
+	Example:
+	order, err := shop.WithPriority(httpshopify.PriorityHigh).Orders().Get(id)
+*/
+func (shop Shop) WithPriority(priority Priority) Shop {
+	prioritised := newShop(shop.client.WithPriority(http.Priority(priority)), shop.url)
+	prioritised.graphQL.throttle = shop.graphQL.throttle
+
+	return prioritised
+}
+
+// QueueDepth returns the number of requests of the shop waiting for the rate limit in each priority class
+func (shop Shop) QueueDepth() QueueDepth {
+	depth := make(QueueDepth)
+	for priority, waiting := range shop.client.QueueDepth() {
+		depth[Priority(priority)] = waiting
+	}
+
+	return depth
+}
@@ -4,9 +4,16 @@ import (
 	"testing"
 
 	"github.com/MOHC-LTD/httpshopify/v2"
+	"github.com/MOHC-LTD/httpshopify/v2/internal/assertions"
 	"github.com/MOHC-LTD/shopify/v2"
 )
 
 func Test_ShopImplementsShopify(t *testing.T) {
 	var _ shopify.Shop = new(httpshopify.Shop)
 }
+
+func Test_ShopVariantsImplementsVariantRepository(t *testing.T) {
+	if _, ok := httpshopify.NewShop("shop", "token", "2024-01").Variants().(httpshopify.VariantRepository); !ok {
+		assertions.AssertionFailure(t, "variant repository does not implement httpshopify.VariantRepository")
+	}
+}
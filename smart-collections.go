@@ -0,0 +1,170 @@
+package httpshopify
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/MOHC-LTD/httpshopify/v2/internal/http"
+	"github.com/MOHC-LTD/shopify/v2"
+)
+
+// SmartCollectionRepository maintains the smart collections of a shop.
+type SmartCollectionRepository interface {
+	// Create creates a new smart collection
+	Create(collection shopify.SmartCollection) (shopify.SmartCollection, error)
+	// Update updates an existing smart collection
+	Update(collection shopify.SmartCollection) (shopify.SmartCollection, error)
+	// Delete deletes a smart collection
+	Delete(id int64) error
+	// Order sets the manual sort order of the products in a smart collection.
+	/*
+		The products are positioned in the order that their IDs are passed in and the
+		sort order of the collection is set to manual.
+	*/
+	Order(id int64, productIDs []int64) error
+}
+
+type smartCollectionRepository struct {
+	client    http.Client
+	createURL func(endpoint string) string
+}
+
+func newSmartCollectionRepository(client http.Client, createURL func(endpoint string) string) smartCollectionRepository {
+	return smartCollectionRepository{
+		client,
+		createURL,
+	}
+}
+
+func (repository smartCollectionRepository) Create(collection shopify.SmartCollection) (shopify.SmartCollection, error) {
+	request := struct {
+		SmartCollection SmartCollectionDTO `json:"smart_collection"`
+	}{
+		SmartCollection: BuildSmartCollectionDTO(collection),
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return shopify.SmartCollection{}, err
+	}
+
+	url := repository.createURL("smart_collections.json")
+
+	respBody, _, err := repository.client.WithOperation("smartCollections.Create").Post(url, body, nil)
+	if err != nil {
+		return shopify.SmartCollection{}, err
+	}
+
+	return parseSmartCollectionResponse(respBody)
+}
+
+func (repository smartCollectionRepository) Update(collection shopify.SmartCollection) (shopify.SmartCollection, error) {
+	request := struct {
+		SmartCollection SmartCollectionDTO `json:"smart_collection"`
+	}{
+		SmartCollection: BuildSmartCollectionDTO(collection),
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return shopify.SmartCollection{}, err
+	}
+
+	url := repository.createURL(fmt.Sprintf("smart_collections/%d.json", collection.ID()))
+
+	respBody, _, err := repository.client.WithOperation("smartCollections.Update").Put(url, body, nil)
+	if err != nil {
+		return shopify.SmartCollection{}, err
+	}
+
+	return parseSmartCollectionResponse(respBody)
+}
+
+func (repository smartCollectionRepository) Delete(id int64) error {
+	url := repository.createURL(fmt.Sprintf("smart_collections/%d.json", id))
+
+	_, _, err := repository.client.WithOperation("smartCollections.Delete").Delete(url, nil)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repository smartCollectionRepository) Order(id int64, productIDs []int64) error {
+	url := repository.createURL(fmt.Sprintf("smart_collections/%d/order.json?%s", id, parseSmartCollectionOrder(productIDs)))
+
+	_, _, err := repository.client.WithOperation("smartCollections.Order").Put(url, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func parseSmartCollectionOrder(productIDs []int64) string {
+	params := url.Values{}
+
+	params.Add("sort_order", shopify.CollectionSortOrderManual)
+
+	for _, productID := range productIDs {
+		params.Add("products[]", strconv.FormatInt(productID, 10))
+	}
+
+	return params.Encode()
+}
+
+func parseSmartCollectionResponse(body []byte) (shopify.SmartCollection, error) {
+	var response struct {
+		SmartCollection CollectionDTO `json:"smart_collection"`
+	}
+
+	err := json.Unmarshal(body, &response)
+	if err != nil {
+		return shopify.SmartCollection{}, err
+	}
+
+	response.SmartCollection.CollectionType = shopify.CollectionTypeSmart
+
+	return response.SmartCollection.ToShopifySmartCollection(), nil
+}
+
+// SmartCollectionDTO represents a Shopify smart collection in HTTP create and update requests
+type SmartCollectionDTO struct {
+	ID             int64      `json:"id,omitempty"`
+	BodyHTML       string     `json:"body_html,omitempty"`
+	Handle         string     `json:"handle,omitempty"`
+	Image          *ImageDTO  `json:"image,omitempty"`
+	PublishedAt    *time.Time `json:"published_at,omitempty"`
+	PublishedScope string     `json:"published_scope,omitempty"`
+	Rules          RuleDTOs   `json:"rules,omitempty"`
+	Disjunctive    bool       `json:"disjunctive"`
+	SortOrder      string     `json:"sort_order,omitempty"`
+	TemplateSuffix string     `json:"template_suffix,omitempty"`
+	Title          string     `json:"title,omitempty"`
+}
+
+// BuildSmartCollectionDTO builds the DTO from the Shopify equivalent
+func BuildSmartCollectionDTO(collection shopify.SmartCollection) SmartCollectionDTO {
+	var publishedAt *time.Time
+	if published := collection.PublishedAt(); !published.IsZero() {
+		publishedAt = &published
+	}
+
+	return SmartCollectionDTO{
+		ID:             collection.ID(),
+		BodyHTML:       collection.BodyHTML(),
+		Handle:         collection.Handle(),
+		Image:          buildCollectionImageDTO(collection.Image()),
+		PublishedAt:    publishedAt,
+		PublishedScope: collection.PublishedScope(),
+		Rules:          BuildRuleDTOs(collection.Rules),
+		Disjunctive:    collection.Disjunctive,
+		SortOrder:      collection.SortOrder(),
+		TemplateSuffix: collection.TemplateSuffix(),
+		Title:          collection.Title(),
+	}
+}
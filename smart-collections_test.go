@@ -0,0 +1,56 @@
+package httpshopify
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/MOHC-LTD/httpshopify/v2/internal/assertions"
+	"github.com/MOHC-LTD/shopify/v2"
+)
+
+// Tests that a smart collection DTO carries the rules of the collection
+func TestBuildSmartCollectionDTO(t *testing.T) {
+	rules := shopify.Rules{
+		{
+			Column:    shopify.RuleColumnEqualsTag,
+			Relation:  "equals",
+			Condition: "summer",
+		},
+	}
+
+	collection := shopify.NewSmartCollection("", "", "", 1, shopify.Image{}, 0, time.Time{}, "", rules, true, "", "", "Summer", time.Time{})
+
+	dto := BuildSmartCollectionDTO(collection)
+
+	expected := RuleDTOs{
+		{
+			Column:    shopify.RuleColumnEqualsTag,
+			Relation:  "equals",
+			Condition: "summer",
+		},
+	}
+
+	if !reflect.DeepEqual(expected, dto.Rules) {
+		assertions.ValueAssertionFailure(t, expected, dto.Rules)
+	}
+
+	if dto.PublishedAt != nil {
+		assertions.ValueAssertionFailure(t, nil, dto.PublishedAt)
+	}
+
+	if dto.Image != nil {
+		assertions.ValueAssertionFailure(t, nil, dto.Image)
+	}
+}
+
+// Tests that the smart collection order is encoded in the order of the product IDs
+func TestParseSmartCollectionOrder(t *testing.T) {
+	actual := parseSmartCollectionOrder([]int64{3, 1, 2})
+
+	expected := "products%5B%5D=3&products%5B%5D=1&products%5B%5D=2&sort_order=manual"
+
+	if actual != expected {
+		assertions.ValueAssertionFailure(t, expected, actual)
+	}
+}
@@ -0,0 +1,195 @@
+package httpshopify
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrTokenNotRefreshed is thrown when a token source has no newer token than the one that was rejected
+var ErrTokenNotRefreshed = errors.New("access token could not be refreshed")
+
+// TokenSource provides the access token sent with every request made by a shop.
+/*
+	Token is called before every request, so it should be cheap. When Shopify rejects a token with a 401,
+	Refresh is called with the rejected token and the request is retried once with the token it returns.
+	Implementations must be safe for concurrent use.
+*/
+type TokenSource interface {
+	// Token returns the current access token
+	Token() (string, error)
+	// Refresh returns a token to replace the rejected one, or ErrTokenNotRefreshed when there is none
+	Refresh(rejected string) (string, error)
+}
+
+// WithTokenSource configures the shop to fetch its access token from the source for every request.
+/*
+	The access token passed to the shop constructor is ignored.
+	Example:
+	source := httpshopify.NewFileTokenSource("/var/run/secrets/shopify-token")
+	shop := httpshopify.NewShop("my-shop-name", "", "2024-01", httpshopify.WithTokenSource(source))
+*/
+func WithTokenSource(source TokenSource) OptionFunc {
+	return func(o *Options) {
+		o.tokenSource = source
+	}
+}
+
+type staticTokenSource struct {
+	token string
+}
+
+// StaticTokenSource builds a source that always provides the same token
+func StaticTokenSource(token string) TokenSource {
+	return staticTokenSource{token}
+}
+
+func (source staticTokenSource) Token() (string, error) {
+	return source.token, nil
+}
+
+func (source staticTokenSource) Refresh(rejected string) (string, error) {
+	return "", ErrTokenNotRefreshed
+}
+
+// FileTokenSource provides the token held in a file, such as a secret mounted by a secret manager.
+/*
+	The file is read again whenever its modification time changes, so a rotated token is picked up by the next request.
+	Leading and trailing whitespace is ignored.
+*/
+type FileTokenSource struct {
+	path    string
+	mutex   sync.Mutex
+	token   string
+	modTime time.Time
+}
+
+// NewFileTokenSource builds a source that provides the token held in the file at the path
+func NewFileTokenSource(path string) *FileTokenSource {
+	return &FileTokenSource{
+		path: path,
+	}
+}
+
+// Token returns the token held in the file, reading it again if the file has changed
+func (source *FileTokenSource) Token() (string, error) {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	info, err := os.Stat(source.path)
+	if err != nil {
+		return "", err
+	}
+
+	if source.token != "" && info.ModTime().Equal(source.modTime) {
+		return source.token, nil
+	}
+
+	return source.read(info.ModTime())
+}
+
+// Refresh reads the file again, returning ErrTokenNotRefreshed when it still holds the rejected token
+func (source *FileTokenSource) Refresh(rejected string) (string, error) {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	info, err := os.Stat(source.path)
+	if err != nil {
+		return "", err
+	}
+
+	token, err := source.read(info.ModTime())
+	if err != nil {
+		return "", err
+	}
+
+	if token == rejected {
+		return "", ErrTokenNotRefreshed
+	}
+
+	return token, nil
+}
+
+// read reads the token from the file. The mutex must be held.
+func (source *FileTokenSource) read(modTime time.Time) (string, error) {
+	body, err := os.ReadFile(source.path)
+	if err != nil {
+		return "", err
+	}
+
+	source.token = strings.TrimSpace(string(body))
+	source.modTime = modTime
+
+	return source.token, nil
+}
+
+// RefreshingTokenSource provides a token that is replaced by the caller, either by pushing a new token or on demand.
+/*
+	When a token is rejected the refresh function is called to fetch a new one, for example from a secret manager.
+	Concurrent requests that are rejected with the same token share a single refresh.
+	Example:
+	source := httpshopify.NewRefreshingTokenSource(token, func() (string, error) {
+		return secrets.Get("shopify-token")
+	})
+*/
+type RefreshingTokenSource struct {
+	mutex   sync.Mutex
+	token   string
+	refresh func() (string, error)
+}
+
+// NewRefreshingTokenSource builds a source that starts with the token and fetches new ones with the refresh function.
+/*
+	The refresh function may be nil, in which case the token only changes when Set is called.
+*/
+func NewRefreshingTokenSource(token string, refresh func() (string, error)) *RefreshingTokenSource {
+	return &RefreshingTokenSource{
+		token:   token,
+		refresh: refresh,
+	}
+}
+
+// Token returns the current token
+func (source *RefreshingTokenSource) Token() (string, error) {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	return source.token, nil
+}
+
+// Set replaces the current token, for example when a secret manager announces a rotation
+func (source *RefreshingTokenSource) Set(token string) {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	source.token = token
+}
+
+// Refresh fetches a new token, unless the rejected token has already been replaced
+func (source *RefreshingTokenSource) Refresh(rejected string) (string, error) {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	if source.token != rejected {
+		return source.token, nil
+	}
+
+	if source.refresh == nil {
+		return "", ErrTokenNotRefreshed
+	}
+
+	token, err := source.refresh()
+	if err != nil {
+		return "", err
+	}
+
+	if token == rejected {
+		return "", ErrTokenNotRefreshed
+	}
+
+	source.token = token
+
+	return token, nil
+}
@@ -0,0 +1,111 @@
+package httpshopify
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/MOHC-LTD/httpshopify/v2/internal/assertions"
+)
+
+func newTokenServer(valid string, requests *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+
+		if r.Header.Get("X-Shopify-Access-Token") != valid {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.Write([]byte(`{"shop":{"id":1}}`))
+	}))
+}
+
+// Tests that a rejected token is refreshed and the request is retried with the new token
+func TestWithTokenSource_RefreshOnUnauthorized(t *testing.T) {
+	requests := 0
+	server := newTokenServer("rotated", &requests)
+	defer server.Close()
+
+	refreshes := 0
+	source := NewRefreshingTokenSource("expired", func() (string, error) {
+		refreshes++
+		return "rotated", nil
+	})
+
+	shop := NewCustomShop(server.URL, "", IsDefault, WithTokenSource(source))
+
+	_, err := shop.Info().Get()
+	if err != nil {
+		assertions.ErrAssertionFailure(t, err)
+	}
+
+	if refreshes != 1 {
+		assertions.ValueAssertionFailure(t, 1, refreshes)
+	}
+
+	if requests != 2 {
+		assertions.ValueAssertionFailure(t, 2, requests)
+	}
+
+	_, err = shop.Info().Get()
+	if err != nil {
+		assertions.ErrAssertionFailure(t, err)
+	}
+
+	if refreshes != 1 {
+		assertions.ValueAssertionFailure(t, 1, refreshes)
+	}
+}
+
+// Tests that a static token is not retried when it is rejected
+func TestStaticTokenSource_Unauthorized(t *testing.T) {
+	requests := 0
+	server := newTokenServer("valid", &requests)
+	defer server.Close()
+
+	shop := NewCustomShop(server.URL, "invalid", IsDefault)
+
+	_, err := shop.Info().Get()
+	if err == nil {
+		assertions.AssertionFailure(t, "expected the rejected token to fail the request")
+	}
+
+	if requests != 1 {
+		assertions.ValueAssertionFailure(t, 1, requests)
+	}
+}
+
+// Tests that a rotated token file is picked up without rebuilding the source
+func TestFileTokenSource_Rotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+
+	os.WriteFile(path, []byte("first\n"), 0600)
+	source := NewFileTokenSource(path)
+
+	token, err := source.Token()
+	if err != nil {
+		assertions.ErrAssertionFailure(t, err)
+	}
+
+	if token != "first" {
+		assertions.ValueAssertionFailure(t, "first", token)
+	}
+
+	_, err = source.Refresh("first")
+	if !errors.Is(err, ErrTokenNotRefreshed) {
+		assertions.ValueAssertionFailure(t, ErrTokenNotRefreshed, err)
+	}
+
+	os.WriteFile(path, []byte("second"), 0600)
+	os.Chtimes(path, time.Now(), time.Now().Add(time.Minute))
+
+	token, _ = source.Token()
+	if token != "second" {
+		assertions.ValueAssertionFailure(t, "second", token)
+	}
+}
@@ -0,0 +1,94 @@
+package httpshopify
+
+import (
+	"net/url"
+
+	"github.com/MOHC-LTD/httpshopify/v2/internal/http"
+)
+
+const (
+	// SpanOperation is the name of the span of a repository operation made up of several requests, such as listing every page of orders
+	SpanOperation = http.SpanOperation
+	// SpanRequest is the name of the span of a request to Shopify, including its retries
+	SpanRequest = http.SpanRequest
+	// SpanLimiterWait is the name of the span of the time a request waits for the rate limiter
+	SpanLimiterWait = http.SpanLimiterWait
+	// SpanAttempt is the name of the span of each attempt of a request
+	SpanAttempt = http.SpanAttempt
+	// SpanRetrySleep is the name of the span of the time waited before retrying a request
+	SpanRetrySleep = http.SpanRetrySleep
+)
+
+const (
+	// AttributeShop is the host of the shop
+	AttributeShop = "shopify.shop"
+	// AttributeEndpoint is the endpoint template of the request e.g. orders/{id}.json
+	AttributeEndpoint = "shopify.endpoint"
+	// AttributeMethod is the method of the request
+	AttributeMethod = "http.method"
+	// AttributeOperation is the name of the repository operation e.g. orders.List
+	AttributeOperation = http.AttributeOperation
+	// AttributeStatus is the status of the response
+	AttributeStatus = http.AttributeStatus
+	// AttributePage is the page number of a request of an operation, starting at one
+	AttributePage = http.AttributePage
+	// AttributeRecords is the number of records returned by an operation
+	AttributeRecords = http.AttributeRecords
+	// AttributeRetry is the number of the retry being waited for, starting at one
+	AttributeRetry = http.AttributeRetry
+)
+
+// Span is a unit of traced work
+type Span interface {
+	// SetAttribute records the attribute on the span
+	SetAttribute(key string, value any)
+	// End ends the span, with the error it failed with if any
+	End(err error)
+}
+
+// Tracer opens spans for the work of a shop, for example by adapting an OpenTelemetry tracer.
+/*
+	Listing a resource opens a SpanOperation with a SpanRequest child for each page. Other repository operations
+	open a SpanRequest without a parent. Each SpanRequest has children for its SpanLimiterWait, each SpanAttempt
+	and each SpanRetrySleep between attempts.
+
+	Spans are given the AttributeShop and AttributeEndpoint of their request when they are opened, and the other
+	attributes as they become known. Implementations must be safe for concurrent use.
+*/
+type Tracer interface {
+	// Start opens a span as a child of the parent, which is nil for a span without one
+	Start(parent Span, name string) Span
+}
+
+// WithTracer configures the shop to trace its requests with the tracer
+func WithTracer(tracer Tracer) OptionFunc {
+	return func(o *Options) {
+		o.tracer = tracer
+	}
+}
+
+// tracerAdapter opens the spans of a client with a tracer, adding the attributes of their request
+type tracerAdapter struct {
+	tracer Tracer
+}
+
+func (adapter tracerAdapter) Start(parent http.Span, name string, method string, rawURL string) http.Span {
+	var parentSpan Span
+	if parent != nil {
+		parentSpan = parent
+	}
+
+	span := adapter.tracer.Start(parentSpan, name)
+
+	if parsed, err := url.Parse(rawURL); err == nil {
+		span.SetAttribute(AttributeShop, parsed.Host)
+	}
+
+	span.SetAttribute(AttributeEndpoint, EndpointTemplate(rawURL))
+
+	if method != "" {
+		span.SetAttribute(AttributeMethod, method)
+	}
+
+	return span
+}
@@ -0,0 +1,149 @@
+package httpshopify
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/MOHC-LTD/httpshopify/v2/internal/assertions"
+
+	"github.com/MOHC-LTD/shopify/v2"
+)
+
+type testSpan struct {
+	name       string
+	parent     *testSpan
+	attributes map[string]any
+	ended      bool
+}
+
+func (span *testSpan) SetAttribute(key string, value any) {
+	span.attributes[key] = value
+}
+
+func (span *testSpan) End(err error) {
+	span.ended = true
+}
+
+type testTracer struct {
+	mutex sync.Mutex
+	spans []*testSpan
+}
+
+func (tracer *testTracer) Start(parent Span, name string) Span {
+	tracer.mutex.Lock()
+	defer tracer.mutex.Unlock()
+
+	span := &testSpan{name: name, attributes: make(map[string]any)}
+	if parent != nil {
+		span.parent = parent.(*testSpan)
+	}
+
+	tracer.spans = append(tracer.spans, span)
+
+	return span
+}
+
+func (tracer *testTracer) children(parent *testSpan, name string) []*testSpan {
+	children := make([]*testSpan, 0)
+	for _, span := range tracer.spans {
+		if span.parent == parent && span.name == name {
+			children = append(children, span)
+		}
+	}
+
+	return children
+}
+
+// Tests that listing every page of orders is traced as one operation with a request for each page
+func TestWithTracer_ListOrders(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == 0 {
+			page = 1
+		}
+
+		if page < 3 {
+			w.Header().Set("Link", fmt.Sprintf(`<%v/orders.json?page=%v>; rel="next"`, server.URL, page+1))
+		}
+
+		w.Write([]byte(`{"orders":[{"id":1},{"id":2}]}`))
+	}))
+	defer server.Close()
+
+	tracer := &testTracer{}
+	shop := NewCustomShop(server.URL, "token", IsDefault, WithTracer(tracer))
+
+	_, err := shop.Orders().List(shopify.OrderQuery{})
+	if err != nil {
+		assertions.ErrAssertionFailure(t, err)
+	}
+
+	operations := tracer.children(nil, SpanOperation)
+	if len(operations) != 1 {
+		assertions.ValueAssertionFailure(t, 1, len(operations))
+		return
+	}
+
+	operation := operations[0]
+
+	if !operation.ended || operation.attributes[AttributeRecords] != 6 || operation.attributes[AttributeOperation] != "orders.List" {
+		assertions.ValueAssertionFailure(t, map[string]any{AttributeRecords: 6, AttributeOperation: "orders.List"}, operation.attributes)
+	}
+
+	requests := tracer.children(operation, SpanRequest)
+	if len(requests) != 3 {
+		assertions.ValueAssertionFailure(t, 3, len(requests))
+		return
+	}
+
+	for i, request := range requests {
+		if request.attributes[AttributePage] != i+1 {
+			assertions.ValueAssertionFailure(t, i+1, request.attributes[AttributePage])
+		}
+
+		if request.attributes[AttributeEndpoint] != "orders.json" || request.attributes[AttributeStatus] != http.StatusOK {
+			assertions.ValueAssertionFailure(t, "orders.json 200", request.attributes)
+		}
+
+		if len(tracer.children(request, SpanAttempt)) != 1 || len(tracer.children(request, SpanLimiterWait)) != 1 {
+			assertions.AssertionFailure(t, "expected each request to have an attempt and a limiter wait")
+		}
+	}
+}
+
+// Tests that getting an order is traced as an operation with a single request
+func TestWithTracer_GetOrder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"order":{"id":1}}`))
+	}))
+	defer server.Close()
+
+	tracer := &testTracer{}
+	shop := NewCustomShop(server.URL, "token", IsDefault, WithTracer(tracer))
+
+	_, err := shop.Orders().Get(1)
+	if err != nil {
+		assertions.ErrAssertionFailure(t, err)
+	}
+
+	operations := tracer.children(nil, SpanOperation)
+	if len(operations) != 1 {
+		assertions.ValueAssertionFailure(t, 1, len(operations))
+		return
+	}
+
+	operation := operations[0]
+
+	if !operation.ended || operation.attributes[AttributeOperation] != "orders.Get" {
+		assertions.ValueAssertionFailure(t, "orders.Get", operation.attributes[AttributeOperation])
+	}
+
+	if len(tracer.children(operation, SpanRequest)) != 1 {
+		assertions.AssertionFailure(t, "expected the operation to have a single request")
+	}
+}
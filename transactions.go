@@ -3,6 +3,7 @@ package httpshopify
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/MOHC-LTD/httpshopify/v2/internal/http"
 	"github.com/MOHC-LTD/shopify/v2"
@@ -23,7 +24,7 @@ func newTransactionRepository(client http.Client, createURL func(endpoint string
 func (repository transactionRepository) Get(orderID, id int64) (shopify.Transaction, error) {
 	url := repository.createURL(fmt.Sprintf("orders/%v/transactions/%v.json", orderID, id))
 
-	body, _, err := repository.client.Get(url, nil)
+	body, _, err := repository.client.WithOperation("transactions.Get").Get(url, nil)
 	if err != nil {
 		return shopify.Transaction{}, err
 	}
@@ -44,7 +45,7 @@ func (repository transactionRepository) Get(orderID, id int64) (shopify.Transact
 func (repository transactionRepository) List(orderID int64) (shopify.Transactions, error) {
 	url := repository.createURL(fmt.Sprintf("orders/%v/transactions.json", orderID))
 
-	body, _, err := repository.client.Get(url, nil)
+	body, _, err := repository.client.WithOperation("transactions.List").Get(url, nil)
 	if err != nil {
 		return shopify.Transactions{}, err
 	}
@@ -58,6 +59,38 @@ func (repository transactionRepository) List(orderID int64) (shopify.Transaction
 	return resultDTO.Transactions.ToShopify(), nil
 }
 
+// Create creates a transaction on the order, such as capturing or refunding a payment
+func (repository transactionRepository) Create(orderID int64, transaction shopify.Transaction) (shopify.Transaction, error) {
+	request := struct {
+		Transaction TransactionDTO `json:"transaction"`
+	}{
+		Transaction: BuildTransactionDTO(transaction),
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return shopify.Transaction{}, err
+	}
+
+	url := repository.createURL(fmt.Sprintf("orders/%v/transactions.json", orderID))
+
+	respBody, _, err := repository.client.WithOperation("transactions.Create").Post(url, body, nil)
+	if err != nil {
+		return shopify.Transaction{}, err
+	}
+
+	var response struct {
+		Transaction TransactionDTO `json:"transaction"`
+	}
+
+	err = json.Unmarshal(respBody, &response)
+	if err != nil {
+		return shopify.Transaction{}, err
+	}
+
+	return response.Transaction.ToShopify(), nil
+}
+
 // TransactionDTOs represents a list of shopify Transactions in HTTP requests and responses
 type TransactionDTOs []TransactionDTO
 
@@ -81,13 +114,51 @@ type PaymentDetailsDTO struct {
 // TransactionDTO represents a Shopify Transaction in HTTP requests and responses
 type TransactionDTO struct {
 	ID             int64             `json:"id,omitempty"`
+	OrderID        int64             `json:"order_id,omitempty"`
+	Kind           string            `json:"kind,omitempty"`
+	Amount         string            `json:"amount,omitempty"`
+	Currency       string            `json:"currency,omitempty"`
+	Gateway        string            `json:"gateway,omitempty"`
+	Status         string            `json:"status,omitempty"`
+	ParentID       int64             `json:"parent_id,omitempty"`
+	Authorization  string            `json:"authorization,omitempty"`
+	Test           bool              `json:"test,omitempty"`
+	CreatedAt      *time.Time        `json:"created_at,omitempty"`
 	PaymentDetails PaymentDetailsDTO `json:"payment_details,omitempty"`
 }
 
+// BuildTransactionDTO builds the DTO of a transaction to create from the Shopify equivalent
+func BuildTransactionDTO(transaction shopify.Transaction) TransactionDTO {
+	return TransactionDTO{
+		Kind:          transaction.Kind,
+		Amount:        transaction.Amount,
+		Currency:      transaction.Currency,
+		Gateway:       transaction.Gateway,
+		ParentID:      transaction.ParentID,
+		Authorization: transaction.Authorization,
+		Test:          transaction.Test,
+	}
+}
+
 // ToShopify converts the DTO to the Shopify equivalent
 func (dto TransactionDTO) ToShopify() shopify.Transaction {
+	var createdAt time.Time
+	if dto.CreatedAt != nil {
+		createdAt = *dto.CreatedAt
+	}
+
 	return shopify.Transaction{
-		ID: dto.ID,
+		ID:            dto.ID,
+		OrderID:       dto.OrderID,
+		Kind:          dto.Kind,
+		Amount:        dto.Amount,
+		Currency:      dto.Currency,
+		Gateway:       dto.Gateway,
+		Status:        dto.Status,
+		ParentID:      dto.ParentID,
+		Authorization: dto.Authorization,
+		Test:          dto.Test,
+		CreatedAt:     createdAt,
 		PaymentDetails: shopify.PaymentDetails{
 			CreditCardNumber:  dto.PaymentDetails.CreditCardNumber,
 			CreditCardCompany: dto.PaymentDetails.CreditCardCompany,
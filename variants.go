@@ -10,6 +10,82 @@ import (
 	"github.com/MOHC-LTD/shopify/v2"
 )
 
+// Variant is a Shopify variant along with the fields that the shopify package does not model
+type Variant struct {
+	shopify.Variant
+	// Weight is the weight of the product variant in the unit system specified with WeightUnit.
+	Weight float64
+	// WeightUnit is the unit of measurement that applies to the product variant's weight.
+	/*
+		Valid values: g, kg, oz and lb.
+	*/
+	WeightUnit string
+	// Taxable is whether a tax is charged when the product variant is sold.
+	Taxable bool
+	// RequiresShipping is whether a customer needs to provide a shipping address when placing an order for the product variant.
+	RequiresShipping bool
+	// ImageID is the unique numeric identifier for a product's image. The image must be associated to the same product as the variant.
+	ImageID int64
+	// TaxCode is the Avalara tax code for the product variant.
+	TaxCode string
+	// PresentmentPrices are the prices and compare-at prices of the variant in each of the shop's enabled presentment currencies.
+	PresentmentPrices PresentmentPrices
+}
+
+// Variants is a collection of variants
+type Variants []Variant
+
+// VariantUpdate holds the fields of a variant to change.
+/*
+	Only the fields that are not nil are sent to Shopify, leaving the rest of the variant untouched. As a nil field
+	is not sent, the compare-at price is removed with ClearCompareAtPrice instead.
+*/
+type VariantUpdate struct {
+	SKU                 *string
+	Title               *string
+	Option1             *string
+	Option2             *string
+	Option3             *string
+	Position            *int
+	InventoryManagement *string
+	InventoryPolicy     *string
+	Price               *string
+	CompareAtPrice      *string
+	Barcode             *string
+	Weight              *float64
+	WeightUnit          *string
+	Taxable             *bool
+	RequiresShipping    *bool
+	ImageID             *int64
+	TaxCode             *string
+	// ClearCompareAtPrice removes the compare-at price of the variant, taking precedence over CompareAtPrice
+	ClearCompareAtPrice bool
+}
+
+// VariantQuery are properties that can be used to filter the returned variants
+type VariantQuery struct {
+	// Limit is the number of variants returned per page. Maximum is 250.
+	Limit int
+	// SinceID restricts the variants to after the specified ID
+	SinceID int64
+}
+
+// VariantRepository maintains the product variants of a shop.
+/*
+	The repository returned by Shop.Variants also implements this interface.
+*/
+type VariantRepository interface {
+	shopify.VariantRepository
+	// GetVariant retrieves a variant along with the fields that Get leaves out, such as its presentment prices
+	GetVariant(id int64) (Variant, error)
+	// Update updates the fields of a variant that are set on the update
+	Update(id int64, update VariantUpdate) (Variant, error)
+	// List retrieves all of the variants of a product
+	List(productID int64, query VariantQuery) (Variants, error)
+	// Count retrieves the number of variants of a product
+	Count(productID int64) (int, error)
+}
+
 type variantRepository struct {
 	client    http.Client
 	createURL func(endpoint string) string
@@ -111,8 +187,16 @@ type VariantDTO struct {
 	CompareAtPrice      string     `json:"compare_at_price,omitempty"`
 	ProductID           int64      `json:"product_id,omitempty"`
 	Barcode             string     `json:"barcode,omitempty"`
+	Weight              float64    `json:"weight,omitempty"`
+	WeightUnit          string     `json:"weight_unit,omitempty"`
+	Taxable             *bool      `json:"taxable,omitempty"`
+	RequiresShipping    *bool      `json:"requires_shipping,omitempty"`
+	ImageID             int64      `json:"image_id,omitempty"`
+	TaxCode             string     `json:"tax_code,omitempty"`
 	CreatedAt           *time.Time `json:"created_at,omitempty"`
 	UpdatedAt           *time.Time `json:"updated_at,omitempty"`
+
+	PresentmentPrices PresentmentPriceDTOs `json:"presentment_prices,omitempty"`
 }
 
 // ToShopify converts the DTO to the Shopify equivalent
@@ -148,12 +232,50 @@ func (dto VariantDTO) ToShopify() shopify.Variant {
 	}
 }
 
+// ToVariant converts the DTO to a variant including the fields the Shopify equivalent does not hold
+func (dto VariantDTO) ToVariant() Variant {
+	var taxable bool
+	if dto.Taxable != nil {
+		taxable = *dto.Taxable
+	}
+
+	var requiresShipping bool
+	if dto.RequiresShipping != nil {
+		requiresShipping = *dto.RequiresShipping
+	}
+
+	return Variant{
+		Variant:           dto.ToShopify(),
+		Weight:            dto.Weight,
+		WeightUnit:        dto.WeightUnit,
+		Taxable:           taxable,
+		RequiresShipping:  requiresShipping,
+		ImageID:           dto.ImageID,
+		TaxCode:           dto.TaxCode,
+		PresentmentPrices: dto.PresentmentPrices.ToShopify(),
+	}
+}
+
+// presentmentPricesHeaders ask Shopify to include the presentment prices of variants, which it leaves out otherwise
+var presentmentPricesHeaders = http.RequestHeaders{
+	{Name: "X-Shopify-Api-Features", Value: "include-presentment-prices"},
+}
+
 func (repository variantRepository) Get(id int64) (shopify.Variant, error) {
+	variant, err := repository.GetVariant(id)
+	if err != nil {
+		return shopify.Variant{}, err
+	}
+
+	return variant.Variant, nil
+}
+
+func (repository variantRepository) GetVariant(id int64) (Variant, error) {
 	url := repository.createURL(fmt.Sprintf("variants/%v.json", id))
 
-	body, _, err := repository.client.Get(url, nil)
+	body, _, err := repository.client.WithOperation("variants.GetVariant").Get(url, presentmentPricesHeaders)
 	if err != nil {
-		return shopify.Variant{}, err
+		return Variant{}, err
 	}
 
 	var response struct {
@@ -161,14 +283,14 @@ func (repository variantRepository) Get(id int64) (shopify.Variant, error) {
 	}
 	err = json.Unmarshal(body, &response)
 	if err != nil {
-		return shopify.Variant{}, err
+		return Variant{}, err
 	}
 
 	if response.Variant.ID == 0 {
-		return shopify.Variant{}, shopify.NewErrVariantNotFound(id)
+		return Variant{}, shopify.NewErrVariantNotFound(id)
 	}
 
-	return response.Variant.ToShopify(), nil
+	return response.Variant.ToVariant(), nil
 }
 
 func (repository variantRepository) Create(productID int64, variant shopify.Variant) (shopify.Variant, error) {
@@ -203,7 +325,7 @@ func (repository variantRepository) Create(productID int64, variant shopify.Vari
 
 	url := repository.createURL(fmt.Sprintf("products/%d/variants.json", productID))
 
-	respBody, _, err := repository.client.Post(url, body, nil)
+	respBody, _, err := repository.client.WithOperation("variants.Create").Post(url, body, nil)
 	if err != nil {
 		return shopify.Variant{}, err
 	}
@@ -223,10 +345,187 @@ func (repository variantRepository) Create(productID int64, variant shopify.Vari
 func (repository variantRepository) Delete(productID int64, variantID int64) error {
 	url := repository.createURL(fmt.Sprintf("products/%d/variants/%d.json", productID, variantID))
 
-	_, _, err := repository.client.Delete(url, nil)
+	_, _, err := repository.client.WithOperation("variants.Delete").Delete(url, nil)
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+func (repository variantRepository) Update(id int64, update VariantUpdate) (Variant, error) {
+	request := struct {
+		Variant variantUpdateDTO `json:"variant"`
+	}{
+		Variant: buildVariantUpdateDTO(id, update),
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return Variant{}, err
+	}
+
+	url := repository.createURL(fmt.Sprintf("variants/%d.json", id))
+
+	respBody, _, err := repository.client.WithOperation("variants.Update").Put(url, body, nil)
+	if err != nil {
+		return Variant{}, err
+	}
+
+	var response struct {
+		Variant VariantDTO `json:"variant"`
+	}
+
+	err = json.Unmarshal(respBody, &response)
+	if err != nil {
+		return Variant{}, err
+	}
+
+	return response.Variant.ToVariant(), nil
+}
+
+func (repository variantRepository) List(productID int64, query VariantQuery) (Variants, error) {
+	variants := make(Variants, 0)
+
+	url := repository.createURL(fmt.Sprintf("products/%d/variants.json%v", productID, parseVariantQuery(query)))
+
+	client, span := repository.client.StartOperation("variants.List", url)
+
+	for {
+		body, headers, err := client.Get(url, presentmentPricesHeaders)
+		if err != nil {
+			span.End(err)
+			return nil, err
+		}
+
+		var resultDTO struct {
+			Variants VariantDTOs `json:"variants"`
+		}
+
+		err = json.Unmarshal(body, &resultDTO)
+		if err != nil {
+			span.End(err)
+			return nil, err
+		}
+
+		for _, dto := range resultDTO.Variants {
+			variants = append(variants, dto.ToVariant())
+		}
+
+		links := ParseLinkHeader(headers.Get("Link"))
+
+		if !links.HasNext() {
+			break
+		}
+
+		url = links.Next
+	}
+
+	span.SetAttribute(http.AttributeRecords, len(variants))
+	span.End(nil)
+
+	return variants, nil
+}
+
+func (repository variantRepository) Count(productID int64) (int, error) {
+	url := repository.createURL(fmt.Sprintf("products/%d/variants/count.json", productID))
+
+	body, _, err := repository.client.WithOperation("variants.Count").Get(url, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var response struct {
+		Count int `json:"count"`
+	}
+
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return 0, err
+	}
+
+	return response.Count, nil
+}
+
+// variantUpdateDTO represents the changed fields of a Shopify variant in HTTP update requests.
+/*
+	CompareAtPrice is raw JSON so that it can be sent as null to remove it.
+*/
+type variantUpdateDTO struct {
+	ID                  int64           `json:"id"`
+	SKU                 *string         `json:"sku,omitempty"`
+	Title               *string         `json:"title,omitempty"`
+	Option1             *string         `json:"option1,omitempty"`
+	Option2             *string         `json:"option2,omitempty"`
+	Option3             *string         `json:"option3,omitempty"`
+	Position            *int            `json:"position,omitempty"`
+	InventoryManagement *string         `json:"inventory_management,omitempty"`
+	InventoryPolicy     *string         `json:"inventory_policy,omitempty"`
+	Price               *string         `json:"price,omitempty"`
+	CompareAtPrice      json.RawMessage `json:"compare_at_price,omitempty"`
+	Barcode             *string         `json:"barcode,omitempty"`
+	Weight              *float64        `json:"weight,omitempty"`
+	WeightUnit          *string         `json:"weight_unit,omitempty"`
+	Taxable             *bool           `json:"taxable,omitempty"`
+	RequiresShipping    *bool           `json:"requires_shipping,omitempty"`
+	ImageID             *int64          `json:"image_id,omitempty"`
+	TaxCode             *string         `json:"tax_code,omitempty"`
+}
+
+func buildVariantUpdateDTO(id int64, update VariantUpdate) variantUpdateDTO {
+	var compareAtPrice json.RawMessage
+	if update.ClearCompareAtPrice {
+		compareAtPrice = json.RawMessage("null")
+	} else if update.CompareAtPrice != nil {
+		compareAtPrice, _ = json.Marshal(*update.CompareAtPrice)
+	}
+
+	return variantUpdateDTO{
+		ID:                  id,
+		SKU:                 update.SKU,
+		Title:               update.Title,
+		Option1:             update.Option1,
+		Option2:             update.Option2,
+		Option3:             update.Option3,
+		Position:            update.Position,
+		InventoryManagement: update.InventoryManagement,
+		InventoryPolicy:     update.InventoryPolicy,
+		Price:               update.Price,
+		CompareAtPrice:      compareAtPrice,
+		Barcode:             update.Barcode,
+		Weight:              update.Weight,
+		WeightUnit:          update.WeightUnit,
+		Taxable:             update.Taxable,
+		RequiresShipping:    update.RequiresShipping,
+		ImageID:             update.ImageID,
+		TaxCode:             update.TaxCode,
+	}
+}
+
+func parseVariantQuery(query VariantQuery) string {
+	queryStrings := make([]string, 0)
+
+	if query.Limit != 0 && query.Limit <= 250 {
+		queryStrings = append(queryStrings, fmt.Sprintf("limit=%v", query.Limit))
+	}
+
+	if query.SinceID != 0 {
+		queryStrings = append(queryStrings, fmt.Sprintf("since_id=%v", query.SinceID))
+	}
+
+	if len(queryStrings) == 0 {
+		return ""
+	}
+
+	queryString := "?"
+
+	for i, str := range queryStrings {
+		if i != 0 {
+			queryString = queryString + "&"
+		}
+
+		queryString = queryString + str
+	}
+
+	return queryString
+}
@@ -1,6 +1,10 @@
 package httpshopify
 
 import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -95,3 +99,121 @@ func TestBuildVariantDTOsEmptyTimes(t *testing.T) {
 		assertions.ValueAssertionFailure(t, updatedAt, variantDTOs[0].UpdatedAt)
 	}
 }
+
+// Tests that a variant update only sends the fields that are set
+func TestBuildVariantUpdateDTO(t *testing.T) {
+	price := "9.99"
+	taxable := false
+
+	body, err := json.Marshal(buildVariantUpdateDTO(1, VariantUpdate{
+		Price:   &price,
+		Taxable: &taxable,
+	}))
+	if err != nil {
+		assertions.ErrAssertionFailure(t, err)
+	}
+
+	expected := `{"id":1,"price":"9.99","taxable":false}`
+
+	if string(body) != expected {
+		assertions.ValueAssertionFailure(t, expected, string(body))
+	}
+}
+
+// Tests that a variant can be built with the fields the Shopify equivalent does not hold
+func TestVariantDTO_ToVariant(t *testing.T) {
+	requiresShipping := true
+
+	var variantDTO = VariantDTO{
+		ID:               1,
+		Weight:           1.5,
+		WeightUnit:       "kg",
+		RequiresShipping: &requiresShipping,
+		PresentmentPrices: PresentmentPriceDTOs{
+			{
+				Price: MoneyDTO{Amount: "10.00", CurrencyCode: "EUR"},
+			},
+		},
+	}
+
+	variant := variantDTO.ToVariant()
+
+	if variant.ID != 1 {
+		assertions.ValueAssertionFailure(t, 1, variant.ID)
+	}
+
+	if variant.Weight != 1.5 || variant.WeightUnit != "kg" {
+		assertions.ValueAssertionFailure(t, "1.5 kg", fmt.Sprintf("%v %v", variant.Weight, variant.WeightUnit))
+	}
+
+	if variant.Taxable {
+		assertions.ValueAssertionFailure(t, false, variant.Taxable)
+	}
+
+	if !variant.RequiresShipping {
+		assertions.ValueAssertionFailure(t, true, variant.RequiresShipping)
+	}
+
+	expectedPrice := shopify.Money{Amount: "10.00", CurrencyCode: "EUR"}
+
+	if variant.PresentmentPrices[0].Price != expectedPrice {
+		assertions.ValueAssertionFailure(t, expectedPrice, variant.PresentmentPrices[0].Price)
+	}
+}
+
+// Tests that variants are requested with their presentment prices
+func TestVariantRepository_PresentmentPrices(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if features := r.Header.Get("X-Shopify-Api-Features"); features != "include-presentment-prices" {
+			assertions.ValueAssertionFailure(t, "include-presentment-prices", features)
+		}
+
+		variant := `{"id":1,"presentment_prices":[{"price":{"amount":"9.99","currency_code":"EUR"}}]}`
+		if r.URL.Path == "/variants/1.json" {
+			w.Write([]byte(`{"variant":` + variant + `}`))
+			return
+		}
+
+		w.Write([]byte(`{"variants":[` + variant + `]}`))
+	}))
+	defer server.Close()
+
+	shop := NewCustomShop(server.URL, "token", IsDefault)
+
+	variant, err := shop.ExtendedVariants().GetVariant(1)
+	if err != nil {
+		assertions.ErrAssertionFailure(t, err)
+	}
+
+	if variant.ID != 1 || len(variant.PresentmentPrices) != 1 || variant.PresentmentPrices[0].Price.Amount != "9.99" {
+		assertions.ValueAssertionFailure(t, "9.99", variant)
+	}
+
+	variants, err := shop.ExtendedVariants().List(1, VariantQuery{})
+	if err != nil {
+		assertions.ErrAssertionFailure(t, err)
+	}
+
+	if len(variants) != 1 || len(variants[0].PresentmentPrices) != 1 || variants[0].PresentmentPrices[0].Price.CurrencyCode != "EUR" {
+		assertions.ValueAssertionFailure(t, "EUR", variants)
+	}
+}
+
+// Tests that an update sends the compare-at price as null to clear it
+func TestBuildVariantUpdateDTO_ClearCompareAtPrice(t *testing.T) {
+	price := "10.00"
+
+	body, _ := json.Marshal(buildVariantUpdateDTO(1, VariantUpdate{Price: &price, ClearCompareAtPrice: true}))
+
+	expected := `{"id":1,"price":"10.00","compare_at_price":null}`
+	if string(body) != expected {
+		assertions.ValueAssertionFailure(t, expected, string(body))
+	}
+
+	body, _ = json.Marshal(buildVariantUpdateDTO(1, VariantUpdate{CompareAtPrice: &price}))
+
+	expected = `{"id":1,"compare_at_price":"10.00"}`
+	if string(body) != expected {
+		assertions.ValueAssertionFailure(t, expected, string(body))
+	}
+}
@@ -0,0 +1,230 @@
+package httpshopify
+
+import (
+	"fmt"
+	httpCode "net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// HeaderAPIVersion is the header Shopify sets to the API version that served a request
+	HeaderAPIVersion = "X-Shopify-API-Version"
+	// HeaderAPIDeprecatedReason is the header Shopify sets when a request uses a deprecated endpoint, field or version
+	HeaderAPIDeprecatedReason = "X-Shopify-API-Deprecated-Reason"
+)
+
+// VersionNotice describes a response that was served by a different API version than requested or that used something deprecated
+type VersionNotice struct {
+	// Shop is the host of the shop
+	Shop string
+	// Method is the method of the request
+	Method string
+	// Endpoint is the endpoint template of the request e.g. orders/{id}.json
+	Endpoint string
+	// RequestedVersion is the API version in the URL of the request
+	RequestedVersion string
+	// ServedVersion is the API version Shopify served the request with
+	ServedVersion string
+	// DeprecatedReason is the reason Shopify gave for the request being deprecated, empty when it is not
+	DeprecatedReason string
+}
+
+// Drifted returns whether the request was served by a different API version than requested
+func (notice VersionNotice) Drifted() bool {
+	return notice.RequestedVersion != "" && notice.ServedVersion != "" && notice.RequestedVersion != notice.ServedVersion
+}
+
+// DeprecationReport summarises the notices for an endpoint
+type DeprecationReport struct {
+	Endpoint
+	// Shop is the host of the shop
+	Shop string
+	// RequestedVersion is the API version last requested
+	RequestedVersion string
+	// ServedVersion is the API version last served
+	ServedVersion string
+	// DeprecatedReason is the last deprecation reason given, empty when none has been
+	DeprecatedReason string
+	// Count is the number of responses with a notice
+	Count int
+	// FirstSeen is when the first notice was received
+	FirstSeen time.Time
+	// LastSeen is when the last notice was received
+	LastSeen time.Time
+}
+
+// VersionMonitorSettings configure how a version monitor reacts to notices
+type VersionMonitorSettings struct {
+	// OnNotice is called with each notice, for example to log it or raise an alert
+	OnNotice func(notice VersionNotice)
+	// Strict fails GET and HEAD requests that are served by a different API version than requested with ErrVersionMismatch.
+	// Other requests have already been applied by Shopify when their response arrives, so they are recorded and
+	// passed to OnNotice but succeed, rather than losing their result and inviting retries that repeat them.
+	Strict bool
+}
+
+// VersionMonitor watches responses for API version drift and deprecations.
+/*
+	Shopify falls back to another API version when the requested one is no longer supported, and marks requests to
+	deprecated endpoints with a reason. Both happen silently, so the monitor records them in a report by endpoint
+	that can be checked before a quarterly version is sunset.
+
+	A monitor can be shared between shops, such as by passing it to NewShopManager.
+	Example:
+	monitor := httpshopify.NewVersionMonitor(httpshopify.VersionMonitorSettings{OnNotice: func(notice httpshopify.VersionNotice) {
+		log.Printf("%v %v: %v", notice.Method, notice.Endpoint, notice.DeprecatedReason)
+	}})
+	shop := httpshopify.NewShop("my-shop", "shppy_21u92h2184ho912h29r01", "2024-01", httpshopify.WithVersionMonitor(monitor))
+*/
+type VersionMonitor struct {
+	settings VersionMonitorSettings
+	mutex    sync.Mutex
+	reports  map[deprecationKey]*DeprecationReport
+	now      func() time.Time
+}
+
+type deprecationKey struct {
+	shop     string
+	endpoint Endpoint
+}
+
+// NewVersionMonitor builds a monitor with the settings
+func NewVersionMonitor(settings VersionMonitorSettings) *VersionMonitor {
+	return &VersionMonitor{
+		settings: settings,
+		reports:  make(map[deprecationKey]*DeprecationReport),
+		now:      time.Now,
+	}
+}
+
+// WithVersionMonitor configures the shop to report API version drift and deprecations to the monitor
+func WithVersionMonitor(monitor *VersionMonitor) OptionFunc {
+	return func(o *Options) {
+		o.versionMonitor = monitor
+	}
+}
+
+// Check records the notice of the response to the request, if it has one, returning ErrVersionMismatch when strict and the version of a safe request drifted
+func (monitor *VersionMonitor) Check(method string, rawURL string, headers httpCode.Header) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil
+	}
+
+	notice := VersionNotice{
+		Shop:             parsed.Host,
+		Method:           method,
+		Endpoint:         EndpointTemplate(rawURL),
+		RequestedVersion: requestedVersion(parsed.Path),
+		ServedVersion:    headers.Get(HeaderAPIVersion),
+		DeprecatedReason: headers.Get(HeaderAPIDeprecatedReason),
+	}
+
+	if !notice.Drifted() && notice.DeprecatedReason == "" {
+		return nil
+	}
+
+	monitor.record(notice)
+
+	if monitor.settings.OnNotice != nil {
+		monitor.settings.OnNotice(notice)
+	}
+
+	if monitor.settings.Strict && notice.Drifted() && safeMethod(method) {
+		return NewErrVersionMismatch(notice.RequestedVersion, notice.ServedVersion)
+	}
+
+	return nil
+}
+
+// Report returns the notices received so far by endpoint, sorted by shop and endpoint
+func (monitor *VersionMonitor) Report() []DeprecationReport {
+	monitor.mutex.Lock()
+	defer monitor.mutex.Unlock()
+
+	reports := make([]DeprecationReport, 0, len(monitor.reports))
+	for _, report := range monitor.reports {
+		reports = append(reports, *report)
+	}
+
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].Shop != reports[j].Shop {
+			return reports[i].Shop < reports[j].Shop
+		}
+
+		if reports[i].Template != reports[j].Template {
+			return reports[i].Template < reports[j].Template
+		}
+
+		return reports[i].Method < reports[j].Method
+	})
+
+	return reports
+}
+
+func (monitor *VersionMonitor) record(notice VersionNotice) {
+	monitor.mutex.Lock()
+	defer monitor.mutex.Unlock()
+
+	now := monitor.now()
+	endpoint := Endpoint{notice.Method, notice.Endpoint}
+	key := deprecationKey{notice.Shop, endpoint}
+
+	report, ok := monitor.reports[key]
+	if !ok {
+		report = &DeprecationReport{
+			Endpoint:  endpoint,
+			Shop:      notice.Shop,
+			FirstSeen: now,
+		}
+		monitor.reports[key] = report
+	}
+
+	report.RequestedVersion = notice.RequestedVersion
+	report.ServedVersion = notice.ServedVersion
+	report.Count++
+	report.LastSeen = now
+
+	if notice.DeprecatedReason != "" {
+		report.DeprecatedReason = notice.DeprecatedReason
+	}
+}
+
+// safeMethod returns whether a request with the method does not change anything, so it can be failed after its response arrives
+func safeMethod(method string) bool {
+	return method == httpCode.MethodGet || method == httpCode.MethodHead
+}
+
+// requestedVersion returns the API version in the path e.g. 2024-01 in /admin/api/2024-01/orders.json, empty when it has none
+func requestedVersion(path string) string {
+	_, rest, found := strings.Cut(path, "/api/")
+	if !found {
+		return ""
+	}
+
+	version, _, _ := strings.Cut(rest, "/")
+
+	return version
+}
+
+// ErrVersionMismatch is thrown by a strict version monitor when a request is served by a different API version than requested
+type ErrVersionMismatch struct {
+	Requested string
+	Served    string
+}
+
+func (err ErrVersionMismatch) Error() string {
+	return fmt.Sprintf("requested API version %v but was served %v", err.Requested, err.Served)
+}
+
+// NewErrVersionMismatch builds the error
+func NewErrVersionMismatch(requested string, served string) ErrVersionMismatch {
+	return ErrVersionMismatch{
+		requested,
+		served,
+	}
+}
@@ -0,0 +1,104 @@
+package httpshopify
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MOHC-LTD/httpshopify/v2/internal/assertions"
+
+	"github.com/MOHC-LTD/shopify/v2"
+)
+
+func newVersionTestServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(HeaderAPIVersion, "2024-04")
+		w.Header().Set(HeaderAPIDeprecatedReason, "https://shopify.dev/changelog/orders")
+		w.Write([]byte(`{"order":{"id":1}}`))
+	}))
+}
+
+// Tests that the monitor reports version drift and deprecations by endpoint
+func TestVersionMonitor_Report(t *testing.T) {
+	server := newVersionTestServer()
+	defer server.Close()
+
+	notices := make([]VersionNotice, 0)
+	monitor := NewVersionMonitor(VersionMonitorSettings{OnNotice: func(notice VersionNotice) {
+		notices = append(notices, notice)
+	}})
+
+	shop := NewCustomShop(server.URL+"/admin/api/2024-01", "token", IsDefault, WithVersionMonitor(monitor))
+
+	for i := 0; i < 2; i++ {
+		_, err := shop.Orders().Get(1)
+		if err != nil {
+			assertions.ErrAssertionFailure(t, err)
+		}
+	}
+
+	if len(notices) != 2 || !notices[0].Drifted() {
+		assertions.ValueAssertionFailure(t, 2, notices)
+	}
+
+	report := monitor.Report()
+	if len(report) != 1 {
+		assertions.ValueAssertionFailure(t, 1, len(report))
+		return
+	}
+
+	expected := DeprecationReport{
+		Endpoint:         Endpoint{http.MethodGet, "orders/{id}.json"},
+		RequestedVersion: "2024-01",
+		ServedVersion:    "2024-04",
+		DeprecatedReason: "https://shopify.dev/changelog/orders",
+		Count:            2,
+	}
+
+	actual := report[0]
+	if actual.Endpoint != expected.Endpoint || actual.RequestedVersion != expected.RequestedVersion || actual.ServedVersion != expected.ServedVersion || actual.DeprecatedReason != expected.DeprecatedReason || actual.Count != expected.Count {
+		assertions.ValueAssertionFailure(t, expected, actual)
+	}
+}
+
+// Tests that a strict monitor fails GET requests served by a different version
+func TestVersionMonitor_Strict(t *testing.T) {
+	server := newVersionTestServer()
+	defer server.Close()
+
+	monitor := NewVersionMonitor(VersionMonitorSettings{Strict: true})
+	shop := NewCustomShop(server.URL+"/admin/api/2024-01", "token", IsDefault, WithVersionMonitor(monitor))
+
+	_, err := shop.Orders().Get(1)
+
+	var mismatch ErrVersionMismatch
+	if !errors.As(err, &mismatch) {
+		assertions.ValueAssertionFailure(t, ErrVersionMismatch{}, err)
+	}
+}
+
+// Tests that a strict monitor lets writes served by a different version succeed, as Shopify has already applied them
+func TestVersionMonitor_StrictWrite(t *testing.T) {
+	server := newVersionTestServer()
+	defer server.Close()
+
+	notices := 0
+	monitor := NewVersionMonitor(VersionMonitorSettings{Strict: true, OnNotice: func(notice VersionNotice) {
+		notices++
+	}})
+	shop := NewCustomShop(server.URL+"/admin/api/2024-01", "token", IsDefault, WithVersionMonitor(monitor))
+
+	order, err := shop.Orders().Create(shopify.Order{})
+	if err != nil {
+		assertions.ErrAssertionFailure(t, err)
+	}
+
+	if order.ID != 1 {
+		assertions.ValueAssertionFailure(t, int64(1), order.ID)
+	}
+
+	if notices != 1 {
+		assertions.ValueAssertionFailure(t, 1, notices)
+	}
+}
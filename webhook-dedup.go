@@ -0,0 +1,406 @@
+package httpshopify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultWebhookDeduplicationTTL is how long deliveries are remembered by default, covering the 48 hours Shopify retries a webhook for
+const DefaultWebhookDeduplicationTTL = 72 * time.Hour
+
+// WebhookStore records processed webhooks so that they are only handled once.
+/*
+	Entries expire at the time they are given, after which they are treated as if they were never recorded.
+	Implementations must be safe for concurrent use.
+*/
+type WebhookStore interface {
+	// Add records the value under the key, returning false when the key is already recorded and has not expired
+	Add(key string, value string, expiresAt time.Time) (bool, error)
+	// Get returns the value recorded under the key and whether it is recorded and has not expired
+	Get(key string) (string, bool, error)
+	// Put records the value under the key, replacing any recorded value
+	Put(key string, value string, expiresAt time.Time) error
+	// Delete removes the key
+	Delete(key string) error
+}
+
+type webhookStoreEntry struct {
+	Value     string    `json:"value"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (entry webhookStoreEntry) expired(now time.Time) bool {
+	return !now.Before(entry.ExpiresAt)
+}
+
+// memoryWebhookStorePruneSize is the number of entries a memory store holds before it first prunes expired entries
+const memoryWebhookStorePruneSize = 1024
+
+// MemoryWebhookStore is a WebhookStore held in memory, suitable for a single process.
+/*
+	Expired entries are removed when they are looked up, and otherwise once the store has doubled in size since it
+	last pruned, so that recording an entry costs the same however many entries are held.
+*/
+type MemoryWebhookStore struct {
+	mutex   sync.Mutex
+	entries map[string]webhookStoreEntry
+	pruneAt int
+	now     func() time.Time
+}
+
+// NewMemoryWebhookStore builds an empty in-memory store
+func NewMemoryWebhookStore() *MemoryWebhookStore {
+	return &MemoryWebhookStore{
+		entries: make(map[string]webhookStoreEntry),
+		pruneAt: memoryWebhookStorePruneSize,
+		now:     time.Now,
+	}
+}
+
+// Add records the value under the key, returning false when the key is already recorded and has not expired
+func (store *MemoryWebhookStore) Add(key string, value string, expiresAt time.Time) (bool, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if entry, ok := store.entries[key]; ok && !entry.expired(store.now()) {
+		return false, nil
+	}
+
+	store.entries[key] = webhookStoreEntry{value, expiresAt}
+	store.pruneIfGrown()
+
+	return true, nil
+}
+
+// Get returns the value recorded under the key and whether it is recorded and has not expired
+func (store *MemoryWebhookStore) Get(key string) (string, bool, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	entry, ok := store.entries[key]
+	if !ok {
+		return "", false, nil
+	}
+
+	if entry.expired(store.now()) {
+		delete(store.entries, key)
+		return "", false, nil
+	}
+
+	return entry.Value, true, nil
+}
+
+// Put records the value under the key, replacing any recorded value
+func (store *MemoryWebhookStore) Put(key string, value string, expiresAt time.Time) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.entries[key] = webhookStoreEntry{value, expiresAt}
+	store.pruneIfGrown()
+
+	return nil
+}
+
+// Delete removes the key
+func (store *MemoryWebhookStore) Delete(key string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	delete(store.entries, key)
+
+	return nil
+}
+
+// pruneIfGrown removes expired entries once the store has doubled in size since it last pruned. The mutex must be held.
+func (store *MemoryWebhookStore) pruneIfGrown() {
+	if len(store.entries) < store.pruneAt {
+		return
+	}
+
+	store.prune()
+
+	store.pruneAt = 2 * len(store.entries)
+	if store.pruneAt < memoryWebhookStorePruneSize {
+		store.pruneAt = memoryWebhookStorePruneSize
+	}
+}
+
+// prune removes expired entries. The mutex must be held.
+func (store *MemoryWebhookStore) prune() {
+	now := store.now()
+
+	for key, entry := range store.entries {
+		if entry.expired(now) {
+			delete(store.entries, key)
+		}
+	}
+}
+
+// FileWebhookStore is a WebhookStore persisted to a JSON file so that it survives restarts.
+/*
+	The whole file is rewritten on every change, so it is intended for low volume apps running a single process.
+	Use a shared store, such as a database, when several processes receive webhooks.
+*/
+type FileWebhookStore struct {
+	path   string
+	memory *MemoryWebhookStore
+}
+
+// NewFileWebhookStore builds a store persisted to the file at the path, loading any entries already in it
+func NewFileWebhookStore(path string) (*FileWebhookStore, error) {
+	store := &FileWebhookStore{
+		path:   path,
+		memory: NewMemoryWebhookStore(),
+	}
+
+	body, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(body) == 0 {
+		return store, nil
+	}
+
+	err = json.Unmarshal(body, &store.memory.entries)
+	if err != nil {
+		return nil, fmt.Errorf("webhook store %v is corrupt: %w", path, err)
+	}
+
+	return store, nil
+}
+
+// Add records the value under the key, returning false when the key is already recorded and has not expired
+func (store *FileWebhookStore) Add(key string, value string, expiresAt time.Time) (bool, error) {
+	store.memory.mutex.Lock()
+	defer store.memory.mutex.Unlock()
+
+	if entry, ok := store.memory.entries[key]; ok && !entry.expired(store.memory.now()) {
+		return false, nil
+	}
+
+	store.memory.entries[key] = webhookStoreEntry{value, expiresAt}
+
+	err := store.save()
+	if err != nil {
+		delete(store.memory.entries, key)
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Get returns the value recorded under the key and whether it is recorded and has not expired
+func (store *FileWebhookStore) Get(key string) (string, bool, error) {
+	return store.memory.Get(key)
+}
+
+// Put records the value under the key, replacing any recorded value
+func (store *FileWebhookStore) Put(key string, value string, expiresAt time.Time) error {
+	store.memory.mutex.Lock()
+	defer store.memory.mutex.Unlock()
+
+	store.memory.entries[key] = webhookStoreEntry{value, expiresAt}
+
+	return store.save()
+}
+
+// Delete removes the key
+func (store *FileWebhookStore) Delete(key string) error {
+	store.memory.mutex.Lock()
+	defer store.memory.mutex.Unlock()
+
+	delete(store.memory.entries, key)
+
+	return store.save()
+}
+
+// save prunes expired entries and atomically replaces the file. The mutex must be held.
+func (store *FileWebhookStore) save() error {
+	store.memory.prune()
+
+	body, err := json.Marshal(store.memory.entries)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(store.path), filepath.Base(store.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+
+	return os.Rename(file.Name(), store.path)
+}
+
+// WebhookDeduplicatorOption allows the deduplicator to be configured
+type WebhookDeduplicatorOption func(*WebhookDeduplicator)
+
+// WithWebhookDeduplicationTTL sets how long deliveries are remembered for
+func WithWebhookDeduplicationTTL(ttl time.Duration) WebhookDeduplicatorOption {
+	return func(deduplicator *WebhookDeduplicator) {
+		deduplicator.ttl = ttl
+	}
+}
+
+// WebhookDeduplicator makes webhook handlers idempotent.
+/*
+	Shopify delivers webhooks at least once, so the same delivery can arrive more than once and deliveries can
+	arrive out of order. The deduplicator records the X-Shopify-Webhook-Id and X-Shopify-Event-Id of every handled
+	webhook and acknowledges repeats without calling the handler again. It also records the updated_at of each
+	resource it sees, and acknowledges a payload older than one already handled so that an old snapshot never
+	overwrites a newer one.
+
+	When the handler fails the delivery is forgotten so that the retry from Shopify is handled.
+	Example:
+	deduplicator := httpshopify.NewWebhookDeduplicator(httpshopify.NewMemoryWebhookStore())
+	receiver.Handle("orders/updated", deduplicator.Wrap(handler))
+*/
+type WebhookDeduplicator struct {
+	store WebhookStore
+	ttl   time.Duration
+	now   func() time.Time
+}
+
+// NewWebhookDeduplicator builds a deduplicator that records deliveries in the store
+func NewWebhookDeduplicator(store WebhookStore, options ...WebhookDeduplicatorOption) WebhookDeduplicator {
+	deduplicator := WebhookDeduplicator{
+		store: store,
+		ttl:   DefaultWebhookDeduplicationTTL,
+		now:   time.Now,
+	}
+
+	for _, option := range options {
+		option(&deduplicator)
+	}
+
+	return deduplicator
+}
+
+// Wrap returns a handler that only calls the handler for new, in order deliveries
+func (deduplicator WebhookDeduplicator) Wrap(handler WebhookHandler) WebhookHandler {
+	return WebhookHandlerFunc(func(ctx context.Context, delivery WebhookDelivery) error {
+		return deduplicator.handle(ctx, delivery, handler)
+	})
+}
+
+func (deduplicator WebhookDeduplicator) handle(ctx context.Context, delivery WebhookDelivery, handler WebhookHandler) error {
+	expiresAt := deduplicator.now().Add(deduplicator.ttl)
+
+	claimed := make([]string, 0, 2)
+	release := func() {
+		for _, key := range claimed {
+			deduplicator.store.Delete(key)
+		}
+	}
+
+	for _, key := range webhookDeliveryKeys(delivery) {
+		added, err := deduplicator.store.Add(key, delivery.Topic, expiresAt)
+		if err != nil {
+			release()
+			return err
+		}
+
+		if !added {
+			release()
+			return nil
+		}
+
+		claimed = append(claimed, key)
+	}
+
+	versionKey, updatedAt, ok := webhookResourceVersion(delivery)
+	if ok {
+		stale, err := deduplicator.isStale(versionKey, updatedAt)
+		if err != nil {
+			release()
+			return err
+		}
+
+		if stale {
+			return nil
+		}
+	}
+
+	err := handler.HandleWebhook(ctx, delivery)
+	if err != nil {
+		release()
+		return err
+	}
+
+	if ok {
+		stale, err := deduplicator.isStale(versionKey, updatedAt)
+		if err != nil || stale {
+			return err
+		}
+
+		return deduplicator.store.Put(versionKey, updatedAt.Format(time.RFC3339Nano), expiresAt)
+	}
+
+	return nil
+}
+
+// isStale returns whether a newer version of the resource than updatedAt has already been handled
+func (deduplicator WebhookDeduplicator) isStale(key string, updatedAt time.Time) (bool, error) {
+	value, ok, err := deduplicator.store.Get(key)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	latest, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return false, nil
+	}
+
+	return updatedAt.Before(latest), nil
+}
+
+// webhookDeliveryKeys builds the store keys that identify the delivery
+func webhookDeliveryKeys(delivery WebhookDelivery) []string {
+	keys := make([]string, 0, 2)
+
+	if delivery.WebhookID != "" {
+		keys = append(keys, fmt.Sprintf("webhook:%v:%v", delivery.ShopDomain, delivery.WebhookID))
+	}
+
+	if delivery.EventID != "" {
+		keys = append(keys, fmt.Sprintf("event:%v:%v:%v", delivery.ShopDomain, delivery.Topic, delivery.EventID))
+	}
+
+	return keys
+}
+
+// webhookResourceVersion reads the id and updated_at of the payload, returning false when either is missing
+func webhookResourceVersion(delivery WebhookDelivery) (string, time.Time, bool) {
+	var payload struct {
+		ID        int64      `json:"id"`
+		UpdatedAt *time.Time `json:"updated_at"`
+	}
+
+	err := delivery.Decode(&payload)
+	if err != nil || payload.ID == 0 || payload.UpdatedAt == nil || payload.UpdatedAt.IsZero() {
+		return "", time.Time{}, false
+	}
+
+	resource, _, _ := strings.Cut(delivery.Topic, "/")
+
+	return fmt.Sprintf("version:%v:%v:%d", delivery.ShopDomain, resource, payload.ID), *payload.UpdatedAt, true
+}
@@ -0,0 +1,154 @@
+package httpshopify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/MOHC-LTD/httpshopify/v2/internal/assertions"
+)
+
+func newDeduplicatedDelivery(webhookID string, body string) WebhookDelivery {
+	return WebhookDelivery{
+		WebhookHeaders: WebhookHeaders{
+			Topic:      "orders/updated",
+			ShopDomain: "example.myshopify.com",
+			WebhookID:  webhookID,
+		},
+		Body: []byte(body),
+	}
+}
+
+func countingWebhookHandler(calls *int, err error) WebhookHandler {
+	return WebhookHandlerFunc(func(ctx context.Context, delivery WebhookDelivery) error {
+		*calls++
+		return err
+	})
+}
+
+// Tests that a repeated delivery is acknowledged without calling the handler again
+func TestWebhookDeduplicator_Duplicate(t *testing.T) {
+	calls := 0
+	handler := NewWebhookDeduplicator(NewMemoryWebhookStore()).Wrap(countingWebhookHandler(&calls, nil))
+
+	delivery := newDeduplicatedDelivery("a", `{"id":1}`)
+
+	for i := 0; i < 2; i++ {
+		err := handler.HandleWebhook(context.Background(), delivery)
+		if err != nil {
+			assertions.ErrAssertionFailure(t, err)
+		}
+	}
+
+	if calls != 1 {
+		assertions.ValueAssertionFailure(t, 1, calls)
+	}
+}
+
+// Tests that a delivery whose handler failed is handled again when it is retried
+func TestWebhookDeduplicator_RetryAfterError(t *testing.T) {
+	calls := 0
+	handler := NewWebhookDeduplicator(NewMemoryWebhookStore()).Wrap(countingWebhookHandler(&calls, errors.New("boom")))
+
+	delivery := newDeduplicatedDelivery("a", `{"id":1}`)
+
+	handler.HandleWebhook(context.Background(), delivery)
+	handler.HandleWebhook(context.Background(), delivery)
+
+	if calls != 2 {
+		assertions.ValueAssertionFailure(t, 2, calls)
+	}
+}
+
+// Tests that a payload older than one already handled is acknowledged without calling the handler
+func TestWebhookDeduplicator_OutOfOrder(t *testing.T) {
+	calls := 0
+	handler := NewWebhookDeduplicator(NewMemoryWebhookStore()).Wrap(countingWebhookHandler(&calls, nil))
+
+	handler.HandleWebhook(context.Background(), newDeduplicatedDelivery("newer", `{"id":1,"updated_at":"2024-01-02T00:00:00Z"}`))
+	handler.HandleWebhook(context.Background(), newDeduplicatedDelivery("older", `{"id":1,"updated_at":"2024-01-01T00:00:00Z"}`))
+	handler.HandleWebhook(context.Background(), newDeduplicatedDelivery("other", `{"id":2,"updated_at":"2024-01-01T00:00:00Z"}`))
+
+	if calls != 2 {
+		assertions.ValueAssertionFailure(t, 2, calls)
+	}
+}
+
+// Tests that an expired entry is treated as if it was never recorded
+func TestMemoryWebhookStore_Expiry(t *testing.T) {
+	store := NewMemoryWebhookStore()
+
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	store.Add("key", "value", now.Add(time.Minute))
+
+	added, _ := store.Add("key", "value", now.Add(time.Minute))
+	if added {
+		assertions.ValueAssertionFailure(t, false, added)
+	}
+
+	now = now.Add(2 * time.Minute)
+
+	_, ok, _ := store.Get("key")
+	if ok {
+		assertions.ValueAssertionFailure(t, false, ok)
+	}
+
+	added, _ = store.Add("key", "value", now.Add(time.Minute))
+	if !added {
+		assertions.ValueAssertionFailure(t, true, added)
+	}
+}
+
+// Tests that expired entries are pruned once the store has grown rather than on every add
+func TestMemoryWebhookStore_Prune(t *testing.T) {
+	store := NewMemoryWebhookStore()
+
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	for i := 0; i < memoryWebhookStorePruneSize-1; i++ {
+		store.Add(fmt.Sprint(i), "value", now.Add(time.Minute))
+	}
+
+	now = now.Add(2 * time.Minute)
+
+	store.Add("fresh", "value", now.Add(time.Minute))
+
+	if len(store.entries) != 1 {
+		assertions.ValueAssertionFailure(t, 1, len(store.entries))
+	}
+
+	if store.pruneAt != memoryWebhookStorePruneSize {
+		assertions.ValueAssertionFailure(t, memoryWebhookStorePruneSize, store.pruneAt)
+	}
+}
+
+// Tests that the file store keeps its entries when it is reopened
+func TestFileWebhookStore_Persists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "webhooks.json")
+
+	store, err := NewFileWebhookStore(path)
+	if err != nil {
+		assertions.ErrAssertionFailure(t, err)
+	}
+
+	err = store.Put("key", "value", time.Now().Add(time.Hour))
+	if err != nil {
+		assertions.ErrAssertionFailure(t, err)
+	}
+
+	reopened, err := NewFileWebhookStore(path)
+	if err != nil {
+		assertions.ErrAssertionFailure(t, err)
+	}
+
+	value, ok, _ := reopened.Get("key")
+	if !ok || value != "value" {
+		assertions.ValueAssertionFailure(t, "value", value)
+	}
+}
@@ -0,0 +1,335 @@
+package httpshopify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MOHC-LTD/shopify/v2"
+)
+
+// Headers sent by Shopify with every webhook
+const (
+	// WebhookHeaderHMAC is the base64 encoded HMAC-SHA256 of the body, signed with the app secret
+	WebhookHeaderHMAC = "X-Shopify-Hmac-Sha256"
+	// WebhookHeaderTopic is the topic of the webhook e.g. orders/create
+	WebhookHeaderTopic = "X-Shopify-Topic"
+	// WebhookHeaderShopDomain is the myshopify.com domain of the shop that sent the webhook
+	WebhookHeaderShopDomain = "X-Shopify-Shop-Domain"
+	// WebhookHeaderAPIVersion is the Admin API version used to serialize the payload
+	WebhookHeaderAPIVersion = "X-Shopify-API-Version"
+	// WebhookHeaderWebhookID is the unique ID of the delivery, which stays the same when it is retried
+	WebhookHeaderWebhookID = "X-Shopify-Webhook-Id"
+	// WebhookHeaderEventID is the unique ID of the event, shared by every webhook the event triggers
+	WebhookHeaderEventID = "X-Shopify-Event-Id"
+	// WebhookHeaderTriggeredAt is the date and time the event happened
+	WebhookHeaderTriggeredAt = "X-Shopify-Triggered-At"
+)
+
+// MaxWebhookBodySize is the largest webhook body, in bytes, that the receiver reads
+const MaxWebhookBodySize = 10 * 1024 * 1024
+
+// ErrWebhookUnverified is thrown when the HMAC of a webhook does not match its body
+var ErrWebhookUnverified = errors.New("webhook hmac could not be verified")
+
+// VerifyWebhook returns whether the HMAC header of a webhook was signed over the body with the secret.
+/*
+	The comparison is done in constant time.
+*/
+func VerifyWebhook(secret string, body []byte, hmacHeader string) bool {
+	expected, err := base64.StdEncoding.DecodeString(hmacHeader)
+	if err != nil || hmacHeader == "" {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// WebhookHeaders are the Shopify headers sent with a webhook
+type WebhookHeaders struct {
+	// Topic is the topic of the webhook e.g. orders/create
+	Topic string
+	// ShopDomain is the myshopify.com domain of the shop that sent the webhook
+	ShopDomain string
+	// APIVersion is the Admin API version used to serialize the payload
+	APIVersion string
+	// WebhookID is the unique ID of the delivery, which stays the same when it is retried
+	WebhookID string
+	// EventID is the unique ID of the event that triggered the webhook
+	EventID string
+	// TriggeredAt is the date and time the event happened. Zero when Shopify did not send it.
+	TriggeredAt time.Time
+}
+
+// ParseWebhookHeaders reads the Shopify headers of a webhook request
+func ParseWebhookHeaders(header http.Header) WebhookHeaders {
+	triggeredAt, _ := time.Parse(time.RFC3339Nano, header.Get(WebhookHeaderTriggeredAt))
+
+	return WebhookHeaders{
+		Topic:       header.Get(WebhookHeaderTopic),
+		ShopDomain:  header.Get(WebhookHeaderShopDomain),
+		APIVersion:  header.Get(WebhookHeaderAPIVersion),
+		WebhookID:   header.Get(WebhookHeaderWebhookID),
+		EventID:     header.Get(WebhookHeaderEventID),
+		TriggeredAt: triggeredAt,
+	}
+}
+
+// WebhookDelivery is a verified webhook sent by Shopify
+type WebhookDelivery struct {
+	WebhookHeaders
+	// Body is the raw payload of the webhook
+	Body []byte
+}
+
+// Decode decodes the payload of the webhook into the value
+func (delivery WebhookDelivery) Decode(value interface{}) error {
+	return json.Unmarshal(delivery.Body, value)
+}
+
+// Order decodes the payload of an orders/* webhook
+func (delivery WebhookDelivery) Order() (shopify.Order, error) {
+	var dto OrderDTO
+	err := delivery.Decode(&dto)
+	if err != nil {
+		return shopify.Order{}, err
+	}
+
+	return dto.ToShopify(), nil
+}
+
+// Product decodes the payload of a products/* webhook
+func (delivery WebhookDelivery) Product() (shopify.Product, error) {
+	var dto ProductDTO
+	err := delivery.Decode(&dto)
+	if err != nil {
+		return shopify.Product{}, err
+	}
+
+	return dto.ToShopify(), nil
+}
+
+// Customer decodes the payload of a customers/* webhook
+func (delivery WebhookDelivery) Customer() (shopify.Customer, error) {
+	var dto CustomerDTO
+	err := delivery.Decode(&dto)
+	if err != nil {
+		return shopify.Customer{}, err
+	}
+
+	return dto.ToShopify(), nil
+}
+
+// Fulfillment decodes the payload of a fulfillments/* webhook
+func (delivery WebhookDelivery) Fulfillment() (shopify.Fulfillment, error) {
+	var dto FulfillmentDTO
+	err := delivery.Decode(&dto)
+	if err != nil {
+		return shopify.Fulfillment{}, err
+	}
+
+	return dto.ToShopify(), nil
+}
+
+// Collection decodes the payload of a collections/* webhook
+func (delivery WebhookDelivery) Collection() (shopify.Collection, error) {
+	var dto CollectionDTO
+	err := delivery.Decode(&dto)
+	if err != nil {
+		return nil, err
+	}
+
+	return dto.ToShopify(), nil
+}
+
+// InventoryLevel decodes the payload of an inventory_levels/* webhook
+func (delivery WebhookDelivery) InventoryLevel() (shopify.InventoryLevel, error) {
+	var dto InventoryLevelDTO
+	err := delivery.Decode(&dto)
+	if err != nil {
+		return shopify.InventoryLevel{}, err
+	}
+
+	return dto.ToShopify(), nil
+}
+
+// InventoryItem decodes the payload of an inventory_items/* webhook
+func (delivery WebhookDelivery) InventoryItem() (shopify.InventoryItem, error) {
+	var dto InventoryItemDTO
+	err := delivery.Decode(&dto)
+	if err != nil {
+		return shopify.InventoryItem{}, err
+	}
+
+	return dto.ToShopify(), nil
+}
+
+// WebhookHandler handles a verified webhook.
+/*
+	Returning an error responds to Shopify with a 500 so that the webhook is retried.
+*/
+type WebhookHandler interface {
+	HandleWebhook(ctx context.Context, delivery WebhookDelivery) error
+}
+
+// WebhookHandlerFunc allows a function to be used as a WebhookHandler
+type WebhookHandlerFunc func(ctx context.Context, delivery WebhookDelivery) error
+
+// HandleWebhook calls the function
+func (fn WebhookHandlerFunc) HandleWebhook(ctx context.Context, delivery WebhookDelivery) error {
+	return fn(ctx, delivery)
+}
+
+// WebhookReceiver is an http.Handler that verifies webhooks and dispatches them to the handler of their topic.
+/*
+	Handlers are registered for a topic such as orders/create, or for every topic of a resource with a wildcard
+	such as orders/*. Webhooks without a handler are acknowledged so that Shopify does not retry them.
+	Example:
+	receiver := httpshopify.NewWebhookReceiver("app-secret")
+	receiver.HandleOrder("orders/create", func(ctx context.Context, delivery httpshopify.WebhookDelivery, order shopify.Order) error {
+		return nil
+	})
+	http.Handle("/webhooks", receiver)
+*/
+type WebhookReceiver struct {
+	secret   string
+	handlers map[string]WebhookHandler
+}
+
+// NewWebhookReceiver builds a receiver that verifies webhooks with the app secret
+func NewWebhookReceiver(secret string) *WebhookReceiver {
+	return &WebhookReceiver{
+		secret:   secret,
+		handlers: make(map[string]WebhookHandler),
+	}
+}
+
+// Handle registers the handler for the topic
+func (receiver *WebhookReceiver) Handle(topic string, handler WebhookHandler) {
+	receiver.handlers[topic] = handler
+}
+
+// HandleFunc registers the function for the topic
+func (receiver *WebhookReceiver) HandleFunc(topic string, fn func(ctx context.Context, delivery WebhookDelivery) error) {
+	receiver.Handle(topic, WebhookHandlerFunc(fn))
+}
+
+// HandleOrder registers the function for an orders/* topic, decoding the payload into an order
+func (receiver *WebhookReceiver) HandleOrder(topic string, fn func(ctx context.Context, delivery WebhookDelivery, order shopify.Order) error) {
+	receiver.HandleFunc(topic, func(ctx context.Context, delivery WebhookDelivery) error {
+		order, err := delivery.Order()
+		if err != nil {
+			return err
+		}
+
+		return fn(ctx, delivery, order)
+	})
+}
+
+// HandleProduct registers the function for a products/* topic, decoding the payload into a product
+func (receiver *WebhookReceiver) HandleProduct(topic string, fn func(ctx context.Context, delivery WebhookDelivery, product shopify.Product) error) {
+	receiver.HandleFunc(topic, func(ctx context.Context, delivery WebhookDelivery) error {
+		product, err := delivery.Product()
+		if err != nil {
+			return err
+		}
+
+		return fn(ctx, delivery, product)
+	})
+}
+
+// HandleCustomer registers the function for a customers/* topic, decoding the payload into a customer
+func (receiver *WebhookReceiver) HandleCustomer(topic string, fn func(ctx context.Context, delivery WebhookDelivery, customer shopify.Customer) error) {
+	receiver.HandleFunc(topic, func(ctx context.Context, delivery WebhookDelivery) error {
+		customer, err := delivery.Customer()
+		if err != nil {
+			return err
+		}
+
+		return fn(ctx, delivery, customer)
+	})
+}
+
+// HandleFulfillment registers the function for a fulfillments/* topic, decoding the payload into a fulfillment
+func (receiver *WebhookReceiver) HandleFulfillment(topic string, fn func(ctx context.Context, delivery WebhookDelivery, fulfillment shopify.Fulfillment) error) {
+	receiver.HandleFunc(topic, func(ctx context.Context, delivery WebhookDelivery) error {
+		fulfillment, err := delivery.Fulfillment()
+		if err != nil {
+			return err
+		}
+
+		return fn(ctx, delivery, fulfillment)
+	})
+}
+
+// handlerFor finds the handler of a topic, falling back to the wildcard handler of its resource
+func (receiver *WebhookReceiver) handlerFor(topic string) (WebhookHandler, bool) {
+	if handler, ok := receiver.handlers[topic]; ok {
+		return handler, true
+	}
+
+	resource, _, _ := strings.Cut(topic, "/")
+	handler, ok := receiver.handlers[resource+"/*"]
+
+	return handler, ok
+}
+
+// Receive reads and verifies the webhook of the request
+func (receiver *WebhookReceiver) Receive(request *http.Request) (WebhookDelivery, error) {
+	body, err := io.ReadAll(io.LimitReader(request.Body, MaxWebhookBodySize))
+	if err != nil {
+		return WebhookDelivery{}, err
+	}
+
+	if !VerifyWebhook(receiver.secret, body, request.Header.Get(WebhookHeaderHMAC)) {
+		return WebhookDelivery{}, ErrWebhookUnverified
+	}
+
+	return WebhookDelivery{
+		WebhookHeaders: ParseWebhookHeaders(request.Header),
+		Body:           body,
+	}, nil
+}
+
+// ServeHTTP verifies the webhook and dispatches it to the handler of its topic
+func (receiver *WebhookReceiver) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodPost {
+		writer.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	delivery, err := receiver.Receive(request)
+	if err != nil {
+		if errors.Is(err, ErrWebhookUnverified) {
+			writer.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	handler, ok := receiver.handlerFor(delivery.Topic)
+	if !ok {
+		writer.WriteHeader(http.StatusOK)
+		return
+	}
+
+	err = handler.HandleWebhook(request.Context(), delivery)
+	if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writer.WriteHeader(http.StatusOK)
+}
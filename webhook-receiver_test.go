@@ -0,0 +1,147 @@
+package httpshopify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MOHC-LTD/httpshopify/v2/internal/assertions"
+	"github.com/MOHC-LTD/shopify/v2"
+)
+
+const testWebhookSecret = "hush"
+
+func signWebhook(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func newWebhookRequest(topic string, body []byte, signature string) *http.Request {
+	request := httptest.NewRequest(http.MethodPost, "/webhooks", bytes.NewReader(body))
+	request.Header.Set(WebhookHeaderHMAC, signature)
+	request.Header.Set(WebhookHeaderTopic, topic)
+	request.Header.Set(WebhookHeaderShopDomain, "example.myshopify.com")
+	request.Header.Set(WebhookHeaderAPIVersion, "2024-01")
+	request.Header.Set(WebhookHeaderWebhookID, "b54557e4-bdd9-4b37-8a5f-bf7d70bcd043")
+
+	return request
+}
+
+// Tests that a webhook signed with the secret is verified
+func TestVerifyWebhook(t *testing.T) {
+	body := []byte(`{"id":1}`)
+
+	if !VerifyWebhook(testWebhookSecret, body, signWebhook(testWebhookSecret, body)) {
+		assertions.ValueAssertionFailure(t, true, false)
+	}
+}
+
+// Tests that a webhook signed with another secret, or not signed at all, is not verified
+func TestVerifyWebhook_Invalid(t *testing.T) {
+	body := []byte(`{"id":1}`)
+
+	for _, signature := range []string{signWebhook("other", body), "", "not base64!"} {
+		if VerifyWebhook(testWebhookSecret, body, signature) {
+			assertions.ValueAssertionFailure(t, false, true)
+		}
+	}
+}
+
+// Tests that the receiver rejects a webhook with an invalid HMAC without calling the handler
+func TestWebhookReceiver_Unverified(t *testing.T) {
+	receiver := NewWebhookReceiver(testWebhookSecret)
+
+	called := false
+	receiver.HandleFunc("orders/create", func(ctx context.Context, delivery WebhookDelivery) error {
+		called = true
+		return nil
+	})
+
+	body := []byte(`{"id":1}`)
+	recorder := httptest.NewRecorder()
+	receiver.ServeHTTP(recorder, newWebhookRequest("orders/create", body, signWebhook("other", body)))
+
+	if recorder.Code != http.StatusUnauthorized {
+		assertions.ValueAssertionFailure(t, http.StatusUnauthorized, recorder.Code)
+	}
+
+	if called {
+		assertions.AssertionFailure(t, "handler should not be called for an unverified webhook")
+	}
+}
+
+// Tests that the receiver decodes the payload and dispatches it to the handler of the topic
+func TestWebhookReceiver_HandleOrder(t *testing.T) {
+	receiver := NewWebhookReceiver(testWebhookSecret)
+
+	var received shopify.Order
+	var headers WebhookHeaders
+	receiver.HandleOrder("orders/create", func(ctx context.Context, delivery WebhookDelivery, order shopify.Order) error {
+		received = order
+		headers = delivery.WebhookHeaders
+		return nil
+	})
+
+	body := []byte(`{"id":450789469,"name":"#1001"}`)
+	recorder := httptest.NewRecorder()
+	receiver.ServeHTTP(recorder, newWebhookRequest("orders/create", body, signWebhook(testWebhookSecret, body)))
+
+	if recorder.Code != http.StatusOK {
+		assertions.ValueAssertionFailure(t, http.StatusOK, recorder.Code)
+	}
+
+	if received.ID != 450789469 {
+		assertions.ValueAssertionFailure(t, int64(450789469), received.ID)
+	}
+
+	if headers.ShopDomain != "example.myshopify.com" {
+		assertions.ValueAssertionFailure(t, "example.myshopify.com", headers.ShopDomain)
+	}
+
+	if headers.APIVersion != "2024-01" {
+		assertions.ValueAssertionFailure(t, "2024-01", headers.APIVersion)
+	}
+}
+
+// Tests that a wildcard handler receives every topic of its resource
+func TestWebhookReceiver_Wildcard(t *testing.T) {
+	receiver := NewWebhookReceiver(testWebhookSecret)
+
+	var topic string
+	receiver.HandleFunc("products/*", func(ctx context.Context, delivery WebhookDelivery) error {
+		topic = delivery.Topic
+		return nil
+	})
+
+	body := []byte(`{"id":1}`)
+	receiver.ServeHTTP(httptest.NewRecorder(), newWebhookRequest("products/update", body, signWebhook(testWebhookSecret, body)))
+
+	if topic != "products/update" {
+		assertions.ValueAssertionFailure(t, "products/update", topic)
+	}
+}
+
+// Tests that a handler error responds with a 500 so that Shopify retries the webhook
+func TestWebhookReceiver_HandlerError(t *testing.T) {
+	receiver := NewWebhookReceiver(testWebhookSecret)
+
+	receiver.HandleFunc("orders/create", func(ctx context.Context, delivery WebhookDelivery) error {
+		return errors.New("boom")
+	})
+
+	body := []byte(`{"id":1}`)
+	recorder := httptest.NewRecorder()
+	receiver.ServeHTTP(recorder, newWebhookRequest("orders/create", body, signWebhook(testWebhookSecret, body)))
+
+	if recorder.Code != http.StatusInternalServerError {
+		assertions.ValueAssertionFailure(t, http.StatusInternalServerError, recorder.Code)
+	}
+}
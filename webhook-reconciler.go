@@ -0,0 +1,249 @@
+package httpshopify
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/MOHC-LTD/shopify/v2"
+)
+
+// WebhookSubscription is a webhook subscription that a shop should have
+type WebhookSubscription struct {
+	// Topic is the event that triggers the webhook.
+	Topic string
+	// Address is the destination the webhook is sent to.
+	Address string
+	// Fields are the fields of the resource that are sent in the payload. All fields are sent when empty.
+	Fields []string
+}
+
+// WebhookPlan holds the changes needed to make the webhook subscriptions of a shop match the desired subscriptions
+type WebhookPlan struct {
+	// Create are the subscriptions to create
+	Create Webhooks
+	// Update are the existing subscriptions to change, holding their new fields
+	Update Webhooks
+	// Delete are the existing subscriptions to delete, including orphaned and duplicated subscriptions
+	Delete Webhooks
+}
+
+// IsEmpty returns whether the shop already has the desired subscriptions
+func (plan WebhookPlan) IsEmpty() bool {
+	return len(plan.Create) == 0 && len(plan.Update) == 0 && len(plan.Delete) == 0
+}
+
+// String describes the changes of the plan, one per line
+func (plan WebhookPlan) String() string {
+	lines := make([]string, 0, len(plan.Create)+len(plan.Update)+len(plan.Delete))
+
+	for _, webhook := range plan.Create {
+		lines = append(lines, fmt.Sprintf("create %v -> %v %v", webhook.Topic, webhook.Address, webhook.Fields))
+	}
+
+	for _, webhook := range plan.Update {
+		lines = append(lines, fmt.Sprintf("update %v %v -> %v %v", webhook.ID, webhook.Topic, webhook.Address, webhook.Fields))
+	}
+
+	for _, webhook := range plan.Delete {
+		lines = append(lines, fmt.Sprintf("delete %v %v -> %v", webhook.ID, webhook.Topic, webhook.Address))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// WebhookReconcilerOption allows the reconciler to be configured
+type WebhookReconcilerOption func(*WebhookReconciler)
+
+// WithWebhookFilter sets which existing subscriptions the reconciler manages, in place of those at the desired addresses.
+/*
+	Subscriptions that do not match the filter are never updated or deleted. Use this to clean up subscriptions
+	at an address that is no longer desired, for example when rotating to a new address, by filtering on every
+	address the service has used.
+*/
+func WithWebhookFilter(filter func(webhook Webhook) bool) WebhookReconcilerOption {
+	return func(reconciler *WebhookReconciler) {
+		reconciler.filter = filter
+	}
+}
+
+// WebhookReconciler makes the webhook subscriptions of a shop match a desired set of subscriptions.
+/*
+	By default only the existing subscriptions at the addresses of the desired subscriptions are managed, so that
+	several services can each reconcile their own subscriptions on the same shop without touching each other's.
+*/
+type WebhookReconciler struct {
+	repository WebhookRepository
+	filter     func(webhook Webhook) bool
+}
+
+// NewWebhookReconciler builds a reconciler for the subscriptions in the repository
+/*
+	Example:
+	reconciler := httpshopify.NewWebhookReconciler(shop.ExtendedWebhooks())
+	plan, err := reconciler.Reconcile([]httpshopify.WebhookSubscription{{Topic: "orders/create", Address: "https://example.com/hooks"}})
+*/
+func NewWebhookReconciler(repository WebhookRepository, options ...WebhookReconcilerOption) WebhookReconciler {
+	reconciler := WebhookReconciler{
+		repository: repository,
+	}
+
+	for _, option := range options {
+		option(&reconciler)
+	}
+
+	return reconciler
+}
+
+// Plan works out the changes needed to match the desired subscriptions without applying them
+func (reconciler WebhookReconciler) Plan(desired []WebhookSubscription) (WebhookPlan, error) {
+	existing, err := reconciler.repository.ListByQuery(WebhookQuery{})
+	if err != nil {
+		return WebhookPlan{}, err
+	}
+
+	filter := reconciler.filter
+	if filter == nil {
+		filter = desiredAddresses(desired)
+	}
+
+	managed := make(Webhooks, 0, len(existing))
+	for _, webhook := range existing {
+		if filter(webhook) {
+			managed = append(managed, webhook)
+		}
+	}
+
+	return planWebhooks(desired, managed), nil
+}
+
+// Apply makes the changes of the plan, stopping at the first change that fails.
+/*
+	Subscriptions are created before any are deleted, so that events are not missed while an address is rotated.
+*/
+func (reconciler WebhookReconciler) Apply(plan WebhookPlan) error {
+	for _, webhook := range plan.Create {
+		_, err := reconciler.repository.CreateSubscription(webhook)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, webhook := range plan.Update {
+		_, err := reconciler.repository.Update(webhook)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, webhook := range plan.Delete {
+		err := reconciler.repository.Delete(webhook.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Reconcile plans and applies the changes needed to match the desired subscriptions, returning the applied plan
+func (reconciler WebhookReconciler) Reconcile(desired []WebhookSubscription) (WebhookPlan, error) {
+	plan, err := reconciler.Plan(desired)
+	if err != nil {
+		return WebhookPlan{}, err
+	}
+
+	return plan, reconciler.Apply(plan)
+}
+
+// planWebhooks diffs the desired subscriptions against the existing ones.
+/*
+	A desired subscription is matched to an existing subscription with the same topic and address, which is updated
+	when its fields differ. Anything that is left over is either created or deleted. The address of a subscription is
+	never changed, as the subscription may belong to another service.
+*/
+func planWebhooks(desired []WebhookSubscription, existing Webhooks) WebhookPlan {
+	var plan WebhookPlan
+
+	matched := make(map[int64]bool, len(existing))
+	seen := make(map[string]bool, len(desired))
+
+	for _, subscription := range desired {
+		key := subscription.Topic + " " + subscription.Address
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		webhook, ok := findWebhook(existing, matched, func(webhook Webhook) bool {
+			return webhook.Topic == subscription.Topic && webhook.Address == subscription.Address
+		})
+		if !ok {
+			plan.Create = append(plan.Create, Webhook{
+				Webhook: shopify.Webhook{
+					Topic:   subscription.Topic,
+					Address: subscription.Address,
+				},
+				Fields: subscription.Fields,
+			})
+			continue
+		}
+
+		matched[webhook.ID] = true
+
+		if !sameWebhookFields(webhook.Fields, subscription.Fields) {
+			webhook.Fields = subscription.Fields
+			plan.Update = append(plan.Update, webhook)
+		}
+	}
+
+	for _, webhook := range existing {
+		if !matched[webhook.ID] {
+			plan.Delete = append(plan.Delete, webhook)
+		}
+	}
+
+	return plan
+}
+
+// desiredAddresses returns a filter for the subscriptions at the addresses of the desired subscriptions
+func desiredAddresses(desired []WebhookSubscription) func(webhook Webhook) bool {
+	addresses := make(map[string]bool, len(desired))
+	for _, subscription := range desired {
+		addresses[subscription.Address] = true
+	}
+
+	return func(webhook Webhook) bool {
+		return addresses[webhook.Address]
+	}
+}
+
+func findWebhook(webhooks Webhooks, matched map[int64]bool, predicate func(webhook Webhook) bool) (Webhook, bool) {
+	for _, webhook := range webhooks {
+		if !matched[webhook.ID] && predicate(webhook) {
+			return webhook, true
+		}
+	}
+
+	return Webhook{}, false
+}
+
+// sameWebhookFields returns whether both lists hold the same fields in any order
+func sameWebhookFields(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	sortedA := append([]string{}, a...)
+	sortedB := append([]string{}, b...)
+	sort.Strings(sortedA)
+	sort.Strings(sortedB)
+
+	for i := range sortedA {
+		if sortedA[i] != sortedB[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,219 @@
+package httpshopify
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/MOHC-LTD/httpshopify/v2/internal/assertions"
+	"github.com/MOHC-LTD/shopify/v2"
+)
+
+// fakeWebhookRepository is an in-memory webhook repository
+type fakeWebhookRepository struct {
+	webhooks map[int64]Webhook
+	nextID   int64
+}
+
+func newFakeWebhookRepository(webhooks ...Webhook) *fakeWebhookRepository {
+	repository := &fakeWebhookRepository{webhooks: map[int64]Webhook{}, nextID: 100}
+	for _, webhook := range webhooks {
+		repository.webhooks[webhook.ID] = webhook
+	}
+
+	return repository
+}
+
+func (r *fakeWebhookRepository) List() (shopify.Webhooks, error) {
+	webhooks, err := r.ListByQuery(WebhookQuery{})
+	return webhooks.ToShopify(), err
+}
+
+func (r *fakeWebhookRepository) ListByQuery(query WebhookQuery) (Webhooks, error) {
+	webhooks := make(Webhooks, 0, len(r.webhooks))
+	for id := int64(0); id < r.nextID; id++ {
+		if webhook, ok := r.webhooks[id]; ok {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+
+	return webhooks, nil
+}
+
+func (r *fakeWebhookRepository) Get(id int64) (Webhook, error) {
+	webhook, ok := r.webhooks[id]
+	if !ok {
+		return Webhook{}, NewErrWebhookNotFound(id)
+	}
+
+	return webhook, nil
+}
+
+func (r *fakeWebhookRepository) Create(webhook shopify.Webhook) (shopify.Webhook, error) {
+	created, err := r.CreateSubscription(Webhook{Webhook: webhook})
+	return created.Webhook, err
+}
+
+func (r *fakeWebhookRepository) CreateSubscription(webhook Webhook) (Webhook, error) {
+	webhook.ID = r.nextID
+	r.nextID++
+	r.webhooks[webhook.ID] = webhook
+
+	return webhook, nil
+}
+
+func (r *fakeWebhookRepository) Update(webhook Webhook) (Webhook, error) {
+	r.webhooks[webhook.ID] = webhook
+	return webhook, nil
+}
+
+func (r *fakeWebhookRepository) Delete(id int64) error {
+	delete(r.webhooks, id)
+	return nil
+}
+
+func (r *fakeWebhookRepository) Count(query WebhookQuery) (int, error) {
+	return len(r.webhooks), nil
+}
+
+func existingWebhook(id int64, topic string, address string, fields ...string) Webhook {
+	return Webhook{
+		Webhook: shopify.Webhook{ID: id, Topic: topic, Address: address},
+		Fields:  fields,
+	}
+}
+
+// Tests that the plan creates missing, updates changed and deletes orphaned and duplicated subscriptions
+func TestWebhookReconciler_Plan(t *testing.T) {
+	repository := newFakeWebhookRepository(
+		existingWebhook(1, "orders/create", "https://a.com/hooks", "id"),
+		existingWebhook(2, "orders/create", "https://a.com/hooks"),
+		existingWebhook(3, "products/update", "https://a.com/hooks"),
+		existingWebhook(4, "customers/create", "https://a.com/hooks"),
+	)
+
+	plan, err := NewWebhookReconciler(repository).Plan([]WebhookSubscription{
+		{Topic: "orders/create", Address: "https://a.com/hooks", Fields: []string{"id"}},
+		{Topic: "products/update", Address: "https://a.com/hooks", Fields: []string{"id", "title"}},
+		{Topic: "app/uninstalled", Address: "https://a.com/hooks"},
+	})
+	if err != nil {
+		assertions.ErrAssertionFailure(t, err)
+	}
+
+	expected := WebhookPlan{
+		Create: Webhooks{{Webhook: shopify.Webhook{Topic: "app/uninstalled", Address: "https://a.com/hooks"}}},
+		Update: Webhooks{existingWebhook(3, "products/update", "https://a.com/hooks", "id", "title")},
+		Delete: Webhooks{
+			existingWebhook(2, "orders/create", "https://a.com/hooks"),
+			existingWebhook(4, "customers/create", "https://a.com/hooks"),
+		},
+	}
+
+	if !reflect.DeepEqual(expected, plan) {
+		assertions.ValueAssertionFailure(t, expected, plan)
+	}
+}
+
+// Tests that reconciling twice leaves nothing to do the second time
+func TestWebhookReconciler_Reconcile(t *testing.T) {
+	repository := newFakeWebhookRepository(
+		existingWebhook(1, "orders/create", "https://a.com/hooks"),
+		existingWebhook(2, "orders/create", "https://a.com/hooks"),
+	)
+
+	desired := []WebhookSubscription{
+		{Topic: "orders/create", Address: "https://a.com/hooks", Fields: []string{"id", "updated_at"}},
+		{Topic: "orders/updated", Address: "https://a.com/hooks"},
+	}
+
+	reconciler := NewWebhookReconciler(repository)
+
+	_, err := reconciler.Reconcile(desired)
+	if err != nil {
+		assertions.ErrAssertionFailure(t, err)
+	}
+
+	plan, err := reconciler.Plan(desired)
+	if err != nil {
+		assertions.ErrAssertionFailure(t, err)
+	}
+
+	if !plan.IsEmpty() {
+		assertions.ValueAssertionFailure(t, WebhookPlan{}, plan)
+	}
+
+	if len(repository.webhooks) != 2 {
+		assertions.ValueAssertionFailure(t, 2, len(repository.webhooks))
+	}
+}
+
+// Tests that subscriptions outside the filter are left alone
+func TestWebhookReconciler_PlanWithFilter(t *testing.T) {
+	repository := newFakeWebhookRepository(
+		existingWebhook(1, "orders/create", "https://other-service.com/hooks"),
+	)
+
+	reconciler := NewWebhookReconciler(repository, WithWebhookFilter(func(webhook Webhook) bool {
+		return webhook.Address == "https://a.com/hooks"
+	}))
+
+	plan, err := reconciler.Plan(nil)
+	if err != nil {
+		assertions.ErrAssertionFailure(t, err)
+	}
+
+	if !plan.IsEmpty() {
+		assertions.ValueAssertionFailure(t, WebhookPlan{}, plan)
+	}
+}
+
+// Tests that subscriptions of other services are neither deleted nor moved to this service's address
+func TestWebhookReconciler_PlanWithForeignSubscription(t *testing.T) {
+	repository := newFakeWebhookRepository(
+		existingWebhook(1, "orders/create", "https://other-service.com/hooks"),
+		existingWebhook(2, "products/update", "https://other-service.com/hooks"),
+	)
+
+	plan, err := NewWebhookReconciler(repository).Plan([]WebhookSubscription{
+		{Topic: "orders/create", Address: "https://a.com/hooks"},
+	})
+	if err != nil {
+		assertions.ErrAssertionFailure(t, err)
+	}
+
+	expected := WebhookPlan{
+		Create: Webhooks{{Webhook: shopify.Webhook{Topic: "orders/create", Address: "https://a.com/hooks"}}},
+	}
+
+	if !reflect.DeepEqual(expected, plan) {
+		assertions.ValueAssertionFailure(t, expected, plan)
+	}
+}
+
+// Tests that a filter lets a subscription at an address that is no longer desired be replaced
+func TestWebhookReconciler_PlanRotatedAddress(t *testing.T) {
+	repository := newFakeWebhookRepository(
+		existingWebhook(1, "orders/create", "https://old.a.com/hooks"),
+	)
+
+	reconciler := NewWebhookReconciler(repository, WithWebhookFilter(func(webhook Webhook) bool {
+		return strings.HasSuffix(webhook.Address, "a.com/hooks")
+	}))
+
+	plan, err := reconciler.Plan([]WebhookSubscription{
+		{Topic: "orders/create", Address: "https://new.a.com/hooks"},
+	})
+	if err != nil {
+		assertions.ErrAssertionFailure(t, err)
+	}
+
+	expected := WebhookPlan{
+		Create: Webhooks{{Webhook: shopify.Webhook{Topic: "orders/create", Address: "https://new.a.com/hooks"}}},
+		Delete: Webhooks{existingWebhook(1, "orders/create", "https://old.a.com/hooks")},
+	}
+
+	if !reflect.DeepEqual(expected, plan) {
+		assertions.ValueAssertionFailure(t, expected, plan)
+	}
+}
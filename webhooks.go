@@ -2,12 +2,64 @@ package httpshopify
 
 import (
 	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/MOHC-LTD/httpshopify/v2/internal/http"
 	"github.com/MOHC-LTD/shopify/v2"
 )
 
+// Webhook is a Shopify webhook subscription along with the fields that the shopify package does not model
+type Webhook struct {
+	shopify.Webhook
+	// Format is the format in which the webhook subscription should send the data.
+	/*
+		Valid values are json and xml. Defaults to json.
+	*/
+	Format string
+	// Fields are the fields of the resource that are sent in the payload. All fields are sent when empty.
+	Fields []string
+	// MetafieldNamespaces are the namespaces of the metafields that are sent in the payload.
+	MetafieldNamespaces []string
+	// PrivateMetafieldNamespaces are the namespaces of the private metafields that are sent in the payload.
+	PrivateMetafieldNamespaces []string
+	// APIVersion is the Admin API version that Shopify uses to serialize the payload. Read-only.
+	APIVersion string
+}
+
+// Webhooks is a collection of webhook subscriptions
+type Webhooks []Webhook
+
+// WebhookQuery are properties that can be used to filter the returned webhook subscriptions
+type WebhookQuery struct {
+	// Topic restricts the webhook subscriptions to those for the topic
+	Topic string
+	// Address restricts the webhook subscriptions to those that send to the address
+	Address string
+}
+
+// WebhookRepository maintains the webhook subscriptions of a shop.
+/*
+	The repository returned by Shop.Webhooks also implements this interface.
+*/
+type WebhookRepository interface {
+	shopify.WebhookRepository
+	// ListByQuery retrieves the webhook subscriptions that match the query
+	ListByQuery(query WebhookQuery) (Webhooks, error)
+	// Get retrieves a single webhook subscription
+	Get(id int64) (Webhook, error)
+	// CreateSubscription creates a new webhook subscription with its format, fields and metafield namespaces
+	CreateSubscription(webhook Webhook) (Webhook, error)
+	// Update updates the address, format, fields and metafield namespaces of a webhook subscription
+	Update(webhook Webhook) (Webhook, error)
+	// Delete deletes a webhook subscription
+	Delete(id int64) error
+	// Count retrieves the number of webhook subscriptions that match the query
+	Count(query WebhookQuery) (int, error)
+}
+
 type webhookRepository struct {
 	client    http.Client
 	createURL func(endpoint string) string
@@ -21,31 +73,94 @@ func newWebhookRepository(client http.Client, createURL func(endpoint string) st
 }
 
 func (r webhookRepository) List() (shopify.Webhooks, error) {
-	url := r.createURL("webhooks.json")
-
-	body, _, err := r.client.Get(url, nil)
+	webhooks, err := r.ListByQuery(WebhookQuery{})
 	if err != nil {
 		return shopify.Webhooks{}, err
 	}
 
+	return webhooks.ToShopify(), nil
+}
+
+func (r webhookRepository) ListByQuery(query WebhookQuery) (Webhooks, error) {
+	webhooks := make(Webhooks, 0)
+
+	url := r.createURL(fmt.Sprintf("webhooks.json?%v", parseWebhookQuery(query, true)))
+
+	client, span := r.client.StartOperation("webhooks.List", url)
+
+	for {
+		body, headers, err := client.Get(url, nil)
+		if err != nil {
+			span.End(err)
+			return Webhooks{}, err
+		}
+
+		var response struct {
+			Webhooks WebhookDTOs `json:"webhooks"`
+		}
+
+		err = json.Unmarshal(body, &response)
+		if err != nil {
+			span.End(err)
+			return Webhooks{}, err
+		}
+
+		for _, dto := range response.Webhooks {
+			webhooks = append(webhooks, dto.ToWebhook())
+		}
+
+		links := ParseLinkHeader(headers.Get("Link"))
+
+		if !links.HasNext() {
+			break
+		}
+
+		url = links.Next
+	}
+
+	span.SetAttribute(http.AttributeRecords, len(webhooks))
+	span.End(nil)
+
+	return webhooks, nil
+}
+
+func (r webhookRepository) Get(id int64) (Webhook, error) {
+	url := r.createURL(fmt.Sprintf("webhooks/%d.json", id))
+
+	body, _, err := r.client.WithOperation("webhooks.Get").Get(url, nil)
+	if err != nil {
+		return Webhook{}, err
+	}
+
 	var response struct {
-		Webhooks WebhookDTOs `json:"webhooks"`
+		Webhook WebhookDTO `json:"webhook"`
 	}
 
 	err = json.Unmarshal(body, &response)
 	if err != nil {
-		return shopify.Webhooks{}, err
+		return Webhook{}, err
+	}
+
+	if response.Webhook.ID == 0 {
+		return Webhook{}, NewErrWebhookNotFound(id)
 	}
 
-	return response.Webhooks.ToShopify(), nil
+	return response.Webhook.ToWebhook(), nil
 }
 
 func (repository webhookRepository) Create(webhook shopify.Webhook) (shopify.Webhook, error) {
-	createDTO := WebhookDTO{
-		Topic:   webhook.Topic,
-		Address: webhook.Address,
+	created, err := repository.CreateSubscription(Webhook{Webhook: webhook})
+	if err != nil {
+		return shopify.Webhook{}, err
 	}
 
+	return created.Webhook, nil
+}
+
+func (repository webhookRepository) CreateSubscription(webhook Webhook) (Webhook, error) {
+	createDTO := BuildWebhookDTO(webhook)
+	createDTO.ID = 0
+
 	request := struct {
 		Webhook WebhookDTO `json:"webhook"`
 	}{
@@ -54,14 +169,14 @@ func (repository webhookRepository) Create(webhook shopify.Webhook) (shopify.Web
 
 	body, err := json.Marshal(request)
 	if err != nil {
-		return shopify.Webhook{}, err
+		return Webhook{}, err
 	}
 
 	url := repository.createURL("webhooks.json")
 
-	respBody, _, err := repository.client.Post(url, body, nil)
+	respBody, _, err := repository.client.WithOperation("webhooks.CreateSubscription").Post(url, body, nil)
 	if err != nil {
-		return shopify.Webhook{}, err
+		return Webhook{}, err
 	}
 
 	responseDTO := struct {
@@ -70,10 +185,102 @@ func (repository webhookRepository) Create(webhook shopify.Webhook) (shopify.Web
 
 	err = json.Unmarshal(respBody, &responseDTO)
 	if err != nil {
-		return shopify.Webhook{}, err
+		return Webhook{}, err
 	}
 
-	return responseDTO.ToShopify(), nil
+	return responseDTO.ToWebhook(), nil
+}
+
+func (repository webhookRepository) Update(webhook Webhook) (Webhook, error) {
+	fields := webhook.Fields
+	if fields == nil {
+		fields = []string{}
+	}
+
+	request := struct {
+		Webhook webhookUpdateDTO `json:"webhook"`
+	}{
+		Webhook: webhookUpdateDTO{
+			ID:                         webhook.ID,
+			Address:                    webhook.Address,
+			Format:                     webhook.Format,
+			Fields:                     fields,
+			MetafieldNamespaces:        webhook.MetafieldNamespaces,
+			PrivateMetafieldNamespaces: webhook.PrivateMetafieldNamespaces,
+		},
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return Webhook{}, err
+	}
+
+	url := repository.createURL(fmt.Sprintf("webhooks/%d.json", webhook.ID))
+
+	respBody, _, err := repository.client.WithOperation("webhooks.Update").Put(url, body, nil)
+	if err != nil {
+		return Webhook{}, err
+	}
+
+	var response struct {
+		Webhook WebhookDTO `json:"webhook"`
+	}
+
+	err = json.Unmarshal(respBody, &response)
+	if err != nil {
+		return Webhook{}, err
+	}
+
+	return response.Webhook.ToWebhook(), nil
+}
+
+func (repository webhookRepository) Delete(id int64) error {
+	url := repository.createURL(fmt.Sprintf("webhooks/%d.json", id))
+
+	_, _, err := repository.client.WithOperation("webhooks.Delete").Delete(url, nil)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repository webhookRepository) Count(query WebhookQuery) (int, error) {
+	url := repository.createURL(fmt.Sprintf("webhooks/count.json?%v", parseWebhookQuery(query, false)))
+
+	body, _, err := repository.client.WithOperation("webhooks.Count").Get(url, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var response struct {
+		Count int `json:"count"`
+	}
+
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return 0, err
+	}
+
+	return response.Count, nil
+}
+
+func parseWebhookQuery(query WebhookQuery, paginated bool) string {
+	params := url.Values{}
+
+	if paginated {
+		params.Add("limit", strconv.Itoa(250))
+	}
+
+	if query.Topic != "" {
+		params.Add("topic", query.Topic)
+	}
+
+	if query.Address != "" {
+		params.Add("address", query.Address)
+	}
+
+	return params.Encode()
 }
 
 // WebhookDTOs represents a list of webhooks in HTTP requests and responses
@@ -81,11 +288,26 @@ type WebhookDTOs []WebhookDTO
 
 // WebhookDTO represents a webhook in HTTP requests and responses
 type WebhookDTO struct {
-	ID        int64      `json:"id,omitempty"`
-	Address   string     `json:"address,omitempty"`
-	Topic     string     `json:"topic,omitempty"`
-	CreatedAt *time.Time `json:"created_at,omitempty"`
-	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+	ID                         int64      `json:"id,omitempty"`
+	Address                    string     `json:"address,omitempty"`
+	Topic                      string     `json:"topic,omitempty"`
+	Format                     string     `json:"format,omitempty"`
+	Fields                     []string   `json:"fields,omitempty"`
+	MetafieldNamespaces        []string   `json:"metafield_namespaces,omitempty"`
+	PrivateMetafieldNamespaces []string   `json:"private_metafield_namespaces,omitempty"`
+	APIVersion                 string     `json:"api_version,omitempty"`
+	CreatedAt                  *time.Time `json:"created_at,omitempty"`
+	UpdatedAt                  *time.Time `json:"updated_at,omitempty"`
+}
+
+// webhookUpdateDTO represents the changeable fields of a webhook in HTTP update requests
+type webhookUpdateDTO struct {
+	ID                         int64    `json:"id"`
+	Address                    string   `json:"address,omitempty"`
+	Format                     string   `json:"format,omitempty"`
+	Fields                     []string `json:"fields"`
+	MetafieldNamespaces        []string `json:"metafield_namespaces,omitempty"`
+	PrivateMetafieldNamespaces []string `json:"private_metafield_namespaces,omitempty"`
 }
 
 // ToShopify converts the DTO to the Shopify equivalent
@@ -119,3 +341,67 @@ func (dto WebhookDTO) ToShopify() shopify.Webhook {
 		UpdatedAt: updatedAt,
 	}
 }
+
+// ToWebhook converts the DTO to a webhook including the fields the Shopify equivalent does not hold
+func (dto WebhookDTO) ToWebhook() Webhook {
+	return Webhook{
+		Webhook:                    dto.ToShopify(),
+		Format:                     dto.Format,
+		Fields:                     dto.Fields,
+		MetafieldNamespaces:        dto.MetafieldNamespaces,
+		PrivateMetafieldNamespaces: dto.PrivateMetafieldNamespaces,
+		APIVersion:                 dto.APIVersion,
+	}
+}
+
+// BuildWebhookDTO builds the DTO from the webhook
+func BuildWebhookDTO(webhook Webhook) WebhookDTO {
+	var createdAt *time.Time
+	if !webhook.CreatedAt.IsZero() {
+		createdAt = &webhook.CreatedAt
+	}
+
+	var updatedAt *time.Time
+	if !webhook.UpdatedAt.IsZero() {
+		updatedAt = &webhook.UpdatedAt
+	}
+
+	return WebhookDTO{
+		ID:                         webhook.ID,
+		Address:                    webhook.Address,
+		Topic:                      webhook.Topic,
+		Format:                     webhook.Format,
+		Fields:                     webhook.Fields,
+		MetafieldNamespaces:        webhook.MetafieldNamespaces,
+		PrivateMetafieldNamespaces: webhook.PrivateMetafieldNamespaces,
+		CreatedAt:                  createdAt,
+		UpdatedAt:                  updatedAt,
+	}
+}
+
+// ToShopify converts the webhooks to the Shopify equivalent
+func (webhooks Webhooks) ToShopify() shopify.Webhooks {
+	shopifyWebhooks := make(shopify.Webhooks, 0, len(webhooks))
+
+	for _, webhook := range webhooks {
+		shopifyWebhooks = append(shopifyWebhooks, webhook.Webhook)
+	}
+
+	return shopifyWebhooks
+}
+
+// ErrWebhookNotFound is thrown when no webhook subscription is found with the id
+type ErrWebhookNotFound struct {
+	id int64
+}
+
+func (err ErrWebhookNotFound) Error() string {
+	return fmt.Sprintf("webhook %v not found", err.id)
+}
+
+// NewErrWebhookNotFound builds the error
+func NewErrWebhookNotFound(id int64) ErrWebhookNotFound {
+	return ErrWebhookNotFound{
+		id,
+	}
+}
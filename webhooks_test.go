@@ -1,10 +1,12 @@
 package httpshopify
 
 import (
+	"reflect"
 	"testing"
 	"time"
 
 	"github.com/MOHC-LTD/httpshopify/v2/internal/assertions"
+	"github.com/MOHC-LTD/shopify/v2"
 )
 
 // Tests that webhook can be built correctly when date fields are not nil
@@ -48,3 +50,34 @@ func TestWebhookDTO_ToShopifyEmptyFields(t *testing.T) {
 		assertions.ValueAssertionFailure(t, updatedAt, webhook.UpdatedAt)
 	}
 }
+
+// Tests that the webhook filters are encoded in the query
+func TestParseWebhookQuery(t *testing.T) {
+	actual := parseWebhookQuery(WebhookQuery{Topic: "orders/create", Address: "https://example.com/hooks"}, true)
+
+	expected := "address=https%3A%2F%2Fexample.com%2Fhooks&limit=250&topic=orders%2Fcreate"
+
+	if actual != expected {
+		assertions.ValueAssertionFailure(t, expected, actual)
+	}
+}
+
+// Tests that a webhook keeps its subscription options when converted to and from the DTO
+func TestBuildWebhookDTO(t *testing.T) {
+	webhook := Webhook{
+		Webhook: shopify.Webhook{
+			ID:      1,
+			Topic:   "orders/create",
+			Address: "https://example.com/hooks",
+		},
+		Format:              "json",
+		Fields:              []string{"id", "updated_at"},
+		MetafieldNamespaces: []string{"custom"},
+	}
+
+	actual := BuildWebhookDTO(webhook).ToWebhook()
+
+	if !reflect.DeepEqual(webhook, actual) {
+		assertions.ValueAssertionFailure(t, webhook, actual)
+	}
+}